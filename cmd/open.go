@@ -336,7 +336,8 @@ func (cmd *OpenCmd) openLocal(ctx devspacecontext.Context, domain string) error
 	// start port-forwarding for localhost access
 	ctx, t := ctx.WithNewTomb()
 	<-t.NotifyGo(func() error {
-		return portforwarding.StartPortForwarding(ctx, devPod, targetselector.NewTargetSelector(options), t)
+		_, err := portforwarding.StartPortForwarding(ctx, devPod, targetselector.NewTargetSelector(options), portforwarding.Options{}, t)
+		return err
 	})
 	if !t.Alive() {
 		return t.Err()
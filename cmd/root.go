@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/loft-sh/devspace/pkg/devspace/kill"
-	"github.com/mgutz/ansi"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config/loader"
 	"github.com/loft-sh/devspace/pkg/devspace/config/loader/variable/expression"
@@ -60,6 +59,8 @@ func NewRootCmd(f factory.Factory) *cobra.Command {
 				return nil
 			}
 
+			log.DisableColors(globalFlags.NoColors || os.Getenv("NO_COLOR") != "")
+
 			log := f.GetLog()
 			if globalFlags.Silent {
 				log.SetLevel(logrus.FatalLevel)
@@ -67,8 +68,6 @@ func NewRootCmd(f factory.Factory) *cobra.Command {
 				log.SetLevel(logrus.DebugLevel)
 			}
 
-			ansi.DisableColors(globalFlags.NoColors)
-
 			if globalFlags.KubeConfig != "" {
 				err := os.Setenv("KUBECONFIG", globalFlags.KubeConfig)
 				if err != nil {
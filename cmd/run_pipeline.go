@@ -59,7 +59,10 @@ type RunPipelineCmd struct {
 	ForceDeploy bool
 	SkipDeploy  bool
 
-	ShowUI bool
+	ShowUI     bool
+	WatchPorts bool
+
+	MaxConcurrentResets int
 
 	// used for testing to allow interruption
 	Ctx          context.Context
@@ -88,6 +91,8 @@ func (cmd *RunPipelineCmd) AddPipelineFlags(f factory.Factory, command *cobra.Co
 	command.Flags().BoolVar(&cmd.SkipPushLocalKubernetes, "skip-push-local-kube", cmd.SkipPushLocalKubernetes, "Skips image pushing, if a local kubernetes environment is detected")
 
 	command.Flags().BoolVar(&cmd.ShowUI, "show-ui", cmd.ShowUI, "Shows the ui server")
+	command.Flags().BoolVar(&cmd.WatchPorts, "watch-ports", cmd.WatchPorts, "If enabled will reload the port forwarding of running dev pods when the config changes")
+	command.Flags().IntVar(&cmd.MaxConcurrentResets, "max-concurrent-resets", cmd.MaxConcurrentResets, "The maximum number of dev pods that are reset in parallel (0 for infinite)")
 
 	if pipeline != nil {
 		for _, pipelineFlag := range pipeline.Flags {
@@ -249,9 +254,12 @@ type CommandOptions struct {
 
 	ConfigOptions *loader.ConfigOptions
 
-	Pipeline string
-	ShowUI   bool
-	UIPort   int
+	Pipeline   string
+	ShowUI     bool
+	UIPort     int
+	WatchPorts bool
+
+	MaxConcurrentResets int
 }
 
 func initialize(ctx context.Context, f factory.Factory, options *CommandOptions, logger log.Logger) (devspacecontext.Context, error) {
@@ -407,6 +415,9 @@ func (cmd *RunPipelineCmd) BuildOptions(configOptions *loader.ConfigOptions) *Co
 		ConfigOptions: configOptions,
 		Pipeline:      cmd.Pipeline,
 		ShowUI:        cmd.ShowUI,
+		WatchPorts:    cmd.WatchPorts,
+
+		MaxConcurrentResets: cmd.MaxConcurrentResets,
 	}
 }
 
@@ -438,10 +449,32 @@ func runPipeline(ctx devspacecontext.Context, args []string, options *CommandOpt
 	devCtxCancel, cancelDevCtx := context.WithCancel(ctx.Context())
 	ctx = ctx.WithContext(values.WithDevContext(ctx.Context(), devCtxCancel))
 
-	// create a new base dev pod manager
+	// create a new base dev pod manager, the resets of all dev pod managers share the same limit
+	devpod.SetResetConcurrency(options.MaxConcurrentResets)
 	devPodManager := devpod.NewManager(cancelDevCtx)
 	defer devPodManager.Close()
 
+	// reload port forwarding on config changes
+	if options.WatchPorts {
+		stopWatching, err := devpod.WatchConfig(ctx.Config().Path(), devPodManager, func() (*latest.Config, error) {
+			configLoader, err := loader.NewConfigLoader(ctx.Config().Path())
+			if err != nil {
+				return nil, err
+			}
+
+			configInterface, err := configLoader.Load(ctx.Context(), ctx.KubeClient(), options.ConfigOptions, log.Discard)
+			if err != nil {
+				return nil, err
+			}
+
+			return configInterface.Config(), nil
+		}, ctx.Log())
+		if err != nil {
+			return errors.Wrap(err, "watch config")
+		}
+		defer stopWatching()
+	}
+
 	// create dependency registry
 	dependencyRegistry := registry.NewDependencyRegistry(ctx.Config().Config().Name, options.DeployOptions.Render)
 
@@ -915,6 +915,14 @@ type DevPod struct {
 	// Ports defines port mappings from the remote pod that should be forwarded to your local
 	// computer
 	Ports []*PortMapping `yaml:"ports,omitempty" json:"ports,omitempty" jsonschema_extras:"group=ports"`
+	// PortsEnvFile is the path of a dotenv file DevSpace writes the local ports of the forwarded ports to,
+	// e.g. DEVSPACE_PORT_MY_APP_8080=54321. The file is removed as soon as the ports are not forwarded anymore.
+	PortsEnvFile string `yaml:"portsEnvFile,omitempty" json:"portsEnvFile,omitempty" jsonschema_extras:"group=ports"`
+	// PortsOrder defines the order the port forwarding and the reverse port forwarding of the dev pod
+	// are started in. With reverseFirst the port forwarding is only started after the reverse port
+	// forwarding is ready and with forwardFirst the other way around. Defaults to concurrent, which
+	// starts both at the same time.
+	PortsOrder PortsOrder `yaml:"portsOrder,omitempty" json:"portsOrder,omitempty" jsonschema:"enum=concurrent,enum=reverseFirst,enum=forwardFirst" jsonschema_extras:"group=ports"`
 
 	// PersistenceOptions are additional options for persisting paths within this pod
 	PersistenceOptions *PersistenceOptions `yaml:"persistenceOptions,omitempty" json:"persistenceOptions,omitempty" jsonschema_extras:"group=modifications"`
@@ -924,6 +932,19 @@ type DevPod struct {
 
 	// Open defines urls that should be opened as soon as they are reachable
 	Open []*OpenConfig `yaml:"open,omitempty" json:"open,omitempty" jsonschema_extras:"group=workflows_background,group_name=Background Dev Workflows"`
+	// QuietUntilError holds back the routine output of the dev pod and only prints the last
+	// messages together with an error once an error occurs. The file log of the dev pod is not affected.
+	QuietUntilError bool `yaml:"quietUntilError,omitempty" json:"quietUntilError,omitempty" jsonschema_extras:"group=workflows_background"`
+	// ReadinessProbe is a command that is executed in the container until it succeeds before
+	// the dev pod is considered ready
+	ReadinessProbe *ReadinessProbe `yaml:"readinessProbe,omitempty" json:"readinessProbe,omitempty" jsonschema_extras:"group=workflows_background"`
+	// LogLevels defines the log levels of the console and the file log of the dev pod independently,
+	// e.g. to only print warnings to the console but keep debug messages in the file log
+	LogLevels *DevPodLogLevels `yaml:"logLevels,omitempty" json:"logLevels,omitempty" jsonschema_extras:"group=workflows_background"`
+	// Labels are arbitrary labels of the dev pod, e.g. tier: frontend, that are passed to the port
+	// forwarding hooks, so that they can behave differently for different kinds of dev pods.
+	// They are not added to the pod.
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty" jsonschema_extras:"group=workflows_background"`
 
 	Containers map[string]*DevContainer `yaml:"containers,omitempty" json:"containers,omitempty" jsonschema_extras:"group=selector"`
 }
@@ -954,7 +975,12 @@ type DevContainer struct {
 	Resources *PodResources `yaml:"resources,omitempty" json:"resources,omitempty" jsonschema_extras:"group=modifications"`
 
 	// ReversePorts are port mappings to make local ports available inside the container
-	ReversePorts []*PortMapping `yaml:"reversePorts,omitempty" json:"reversePorts,omitempty" jsonschema_extras:"group=ports,group_name=Port Forwarding"`
+	ReversePorts []*ReversePortMapping `yaml:"reversePorts,omitempty" json:"reversePorts,omitempty" jsonschema_extras:"group=ports,group_name=Port Forwarding"`
+	// ReversePortsHelper is the path of a DevSpace helper binary that is already part of the image, e.g.
+	// /usr/local/bin/devspacehelper. If set, it is used for reverse port forwarding instead of injecting
+	// the DevSpace helper, which is useful for hardened images that cannot run the injected helper.
+	// If the helper cannot be run, DevSpace falls back to injecting the DevSpace helper.
+	ReversePortsHelper string `yaml:"reversePortsHelper,omitempty" json:"reversePortsHelper,omitempty" jsonschema_extras:"group=ports"`
 
 	// Sync allows you to sync certain local paths with paths inside the container
 	Sync []*SyncConfig `yaml:"sync,omitempty" json:"sync,omitempty" jsonschema_extras:"group=sync,group_name=File Sync"`
@@ -1094,14 +1120,206 @@ type PersistentPathInitContainer struct {
 type PortMapping struct {
 	// Port is a port mapping that maps the localPort:remotePort. So if
 	// you port forward the remote port will be available at the local port.
-	// If you do reverse port forwarding, the local port will be available
-	// at the remote port in the container. If only port is specified, local and
+	// If only port is specified, local and remote port are the same. The remote
+	// port can also be the name of a container port, e.g. 8080:http.
+	Port string `yaml:"port" json:"port"`
+
+	// Name is an optional label of the port mapping, e.g. web UI, that is shown
+	// next to the port in the logs and the dev pod status.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// BindAddress is the address DevSpace should listen on. Optional and defaults
+	// to localhost. Environment variables in the form $${ENV_VAR} are resolved whenever
+	// the port forwarding is started.
+	BindAddress string `yaml:"bindAddress,omitempty" json:"bindAddress,omitempty"`
+
+	// Container is the container, which can also be an init or ephemeral container, a named remote
+	// port like 8080:http is resolved against. The port forwarding fails if the pod doesn't have the
+	// container.
+	Container string `yaml:"container,omitempty" json:"container,omitempty"`
+
+	// Socket is an optional local unix socket path, e.g. /tmp/app.sock, the remote port is forwarded to
+	// instead of a local port. If set, port only specifies the remote port. The socket file is removed
+	// again when the port forwarding stops.
+	Socket string `yaml:"socket,omitempty" json:"socket,omitempty"`
+
+	// LabelSelector is an optional label selector expression, e.g. env in (dev,staging), that selects
+	// the pod the port is forwarded to instead of the pod of the dev container. The pod is searched in the
+	// namespace of the dev pod.
+	LabelSelector string `yaml:"labelSelector,omitempty" json:"labelSelector,omitempty"`
+
+	// AnnotationSelector is an annotation in the form key=value, e.g. role=primary, that selects the single
+	// pod of the labelSelector that currently has the annotation. The annotation is evaluated again on every
+	// reconnect, so that the port forwarding follows the annotated pod. If none or multiple pods have the
+	// annotation, DevSpace waits until exactly one pod has it. Requires labelSelector.
+	AnnotationSelector string `yaml:"annotationSelector,omitempty" json:"annotationSelector,omitempty"`
+
+	// PrintURL prints a clickable url to the local port after the port forwarding
+	// has started. Use a local port of 0 to let DevSpace pick a free port.
+	PrintURL bool `yaml:"printURL,omitempty" json:"printURL,omitempty"`
+
+	// URLScheme is the scheme of the printed url. Can be either http or https and defaults to http.
+	URLScheme string `yaml:"urlScheme,omitempty" json:"urlScheme,omitempty" jsonschema:"enum=http,enum=https"`
+
+	// URLPath is the path of the printed url, e.g. for apps that are served under a sub path.
+	URLPath string `yaml:"urlPath,omitempty" json:"urlPath,omitempty"`
+
+	// H2CProbe verifies that the remote port speaks HTTP/2 cleartext (h2c) with prior knowledge
+	// before the port forwarding is considered ready. Useful for gRPC services.
+	H2CProbe bool `yaml:"h2cProbe,omitempty" json:"h2cProbe,omitempty"`
+
+	// GRPCHealthCheck performs a check of the gRPC health checking protocol through the local port
+	// and only considers the port forwarding ready, if the service is serving.
+	GRPCHealthCheck *GRPCHealthCheck `yaml:"grpcHealthCheck,omitempty" json:"grpcHealthCheck,omitempty"`
+
+	// Hostname is an optional hostname, e.g. api.dev.local, the local port should be reachable at.
+	// DevSpace will add the hostname to the local hosts file while the port forwarding is running,
+	// which requires permission to edit the hosts file.
+	Hostname string `yaml:"hostname,omitempty" json:"hostname,omitempty"`
+
+	// Lazy will only listen on the local port and establish the port forwarding to the pod
+	// when the first connection is made.
+	Lazy bool `yaml:"lazy,omitempty" json:"lazy,omitempty"`
+
+	// LazyIdleTimeout is the time in seconds after which an unused lazy port forwarding
+	// is closed again. Defaults to 300 seconds.
+	LazyIdleTimeout int64 `yaml:"lazyIdleTimeout,omitempty" json:"lazyIdleTimeout,omitempty" jsonschema:"default=300"`
+
+	// AllPods forwards the port to all running pods matching the label selector of the port mapping
+	// instead of a single pod. Local connections are distributed round-robin across the pods and pods
+	// that appear or disappear are added or removed automatically.
+	AllPods bool `yaml:"allPods,omitempty" json:"allPods,omitempty"`
+
+	// Service forwards the port to the pods of the ready endpoints of the service with the given name
+	// in the namespace of the dev pod instead of the pods matching a label selector. The endpoints are
+	// resolved again periodically, so that the port forwarding follows rolling deployments. Requires
+	// allPods.
+	Service string `yaml:"service,omitempty" json:"service,omitempty"`
+
+	// HTTPLog logs the method, path, status and latency of the HTTP/1 requests forwarded through
+	// the port at info level. The traffic is parsed on a best-effort basis, connections that don't
+	// speak HTTP/1, e.g. TLS or gRPC, are forwarded untouched without being logged.
+	HTTPLog bool `yaml:"httpLog,omitempty" json:"httpLog,omitempty"`
+
+	// MaxConnections is the maximum number of concurrent connections forwarded through the port.
+	// New local connections beyond the limit are closed right away. Defaults to unlimited.
+	MaxConnections int `yaml:"maxConnections,omitempty" json:"maxConnections,omitempty"`
+
+	// AllowedSources are the IP addresses and CIDRs, e.g. 192.168.0.0/16, local connections to the port
+	// are accepted from. Connections from other sources are logged and closed right away. Defaults to
+	// all sources, which should be restricted when binding to a non-loopback address.
+	AllowedSources []string `yaml:"allowedSources,omitempty" json:"allowedSources,omitempty"`
+
+	// ReadBufferSize is the size in bytes of the socket receive buffer of the local connections and of
+	// the buffer the data sent to the pod is copied with, e.g. to speed up large uploads. Defaults to the
+	// buffer of the operating system and a 32 KiB copy buffer.
+	ReadBufferSize int `yaml:"readBufferSize,omitempty" json:"readBufferSize,omitempty"`
+
+	// WriteBufferSize is the size in bytes of the socket send buffer of the local connections and of
+	// the buffer the data received from the pod is copied with, e.g. to speed up large downloads. Defaults
+	// to the buffer of the operating system and a 32 KiB copy buffer.
+	WriteBufferSize int `yaml:"writeBufferSize,omitempty" json:"writeBufferSize,omitempty"`
+
+	// NoReconnect stops the port forwarding of this port if the connection to the pod is lost
+	// instead of restarting it, e.g. for ports of short lived jobs. The port gets its own port
+	// forwarder, so that the other ports of the dev pod keep reconnecting.
+	NoReconnect bool `yaml:"noReconnect,omitempty" json:"noReconnect,omitempty"`
+
+	// Protocol is the protocol of the remote port. Only tcp is supported, because the port forwarding
+	// of kubernetes only forwards TCP connections.
+	Protocol PortProtocol `yaml:"protocol,omitempty" json:"protocol,omitempty" jsonschema:"enum=tcp"`
+
+	// AllowUnready forwards the port to the newest pod of the label selector even if it is not running,
+	// not ready or terminating, e.g. to reach the debug port of a pod in CrashLoopBackOff between its
+	// restarts. The port forwarding might break at any time. Requires labelSelector.
+	AllowUnready bool `yaml:"allowUnready,omitempty" json:"allowUnready,omitempty"`
+
+	// TLS originates TLS to the remote port, e.g. for services that require mTLS, while the local port
+	// stays plaintext for local tools that cannot present client certificates.
+	TLS *PortTLS `yaml:"tls,omitempty" json:"tls,omitempty"`
+}
+
+// ReversePortMapping defines a local port that is made available inside the container
+type ReversePortMapping struct {
+	// Port is a port mapping that maps the localPort:remotePort. The local port will be
+	// available at the remote port in the container. If only port is specified, local and
 	// remote port are the same.
 	Port string `yaml:"port" json:"port"`
 
+	// Name is an optional label of the port mapping, e.g. debugger, that is shown
+	// next to the port in the logs and the dev pod status.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
 	// BindAddress is the address DevSpace should listen on. Optional and defaults
 	// to localhost.
 	BindAddress string `yaml:"bindAddress,omitempty" json:"bindAddress,omitempty"`
+
+	// TargetHost is the host on the local machine DevSpace should connect to. Optional
+	// and defaults to localhost.
+	TargetHost string `yaml:"targetHost,omitempty" json:"targetHost,omitempty"`
+
+	// Container is the container the reverse port forwarding should be started in. Optional and
+	// defaults to the container of the dev container this mapping is defined in. All containers of a
+	// pod share the same network, so each remote port can only be reverse forwarded once per pod.
+	Container string `yaml:"container,omitempty" json:"container,omitempty"`
+}
+
+// PortTLS defines how TLS is originated to a forwarded port
+type PortTLS struct {
+	// ServerName is the name the certificate of the remote service is verified against and that is
+	// sent via SNI, e.g. my-service.my-namespace.svc
+	ServerName string `yaml:"serverName,omitempty" json:"serverName,omitempty"`
+
+	// CA is the path to a PEM file with the certificate authorities the certificate of the remote
+	// service is verified with. Defaults to the certificate authorities of the system.
+	CA string `yaml:"ca,omitempty" json:"ca,omitempty"`
+
+	// Cert is the path to a PEM file with the client certificate that is presented to the remote
+	// service for mTLS. Requires key.
+	Cert string `yaml:"cert,omitempty" json:"cert,omitempty"`
+
+	// Key is the path to a PEM file with the private key of the client certificate
+	Key string `yaml:"key,omitempty" json:"key,omitempty"`
+}
+
+// PortProtocol is the protocol of a forwarded port
+type PortProtocol string
+
+const (
+	PortProtocolTCP PortProtocol = "tcp"
+)
+
+// PortsOrder is the order the port forwarding and reverse port forwarding are started in
+type PortsOrder string
+
+const (
+	PortsOrderConcurrent   PortsOrder = "concurrent"
+	PortsOrderReverseFirst PortsOrder = "reverseFirst"
+	PortsOrderForwardFirst PortsOrder = "forwardFirst"
+)
+
+// GRPCHealthCheck defines a check of the gRPC health checking protocol
+type GRPCHealthCheck struct {
+	// Service is the name of the service to check. If empty, the overall health of the server is checked.
+	Service string `yaml:"service,omitempty" json:"service,omitempty"`
+}
+
+// ReadinessProbe defines a command that signals that the dev pod is ready
+type ReadinessProbe struct {
+	// Command is the command to execute in the container, the dev pod is ready as soon as it exits with code 0
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+	// Container is the container to execute the command in, defaults to the container of the dev pod
+	Container string `yaml:"container,omitempty" json:"container,omitempty"`
+	// Timeout is the maximum time in seconds to wait for the command to succeed, defaults to 120
+	Timeout int64 `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// DevPodLogLevels defines the log levels of the sinks of a dev pod logger
+type DevPodLogLevels struct {
+	// Console is the level of messages that are printed to the console, defaults to the devspace log level
+	Console string `yaml:"console,omitempty" json:"console,omitempty" jsonschema:"enum=debug,enum=info,enum=warn,enum=error"`
+	// File is the level of messages that are written to the file log of the dev pod, defaults to the devspace log level
+	File string `yaml:"file,omitempty" json:"file,omitempty" jsonschema:"enum=debug,enum=info,enum=warn,enum=error"`
 }
 
 // OpenConfig defines what to open after services have been started
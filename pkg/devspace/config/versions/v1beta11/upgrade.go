@@ -677,7 +677,7 @@ func (c *Config) mergeDevConfig(log log.Logger) (map[string]*next.DevPod, error)
 					mapping += fmt.Sprintf(":%d", *pr.RemotePort)
 				}
 
-				devContainer.ReversePorts = append(devContainer.ReversePorts, &next.PortMapping{
+				devContainer.ReversePorts = append(devContainer.ReversePorts, &next.ReversePortMapping{
 					Port:        mapping,
 					BindAddress: pr.BindAddress,
 				})
@@ -2,6 +2,7 @@ package versions
 
 import (
 	"fmt"
+	"net"
 	"reflect"
 	"strings"
 	"unicode"
@@ -9,9 +10,12 @@ import (
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
 	k8sv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 	jsonyaml "sigs.k8s.io/yaml"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
 	"github.com/loft-sh/devspace/pkg/util/dockerfile"
 	"github.com/loft-sh/devspace/pkg/util/encoding"
 	"github.com/loft-sh/devspace/pkg/util/yamlutil"
@@ -384,7 +388,134 @@ func validateDev(config *latest.Config) error {
 			return errors.Errorf("dev.%s: image selector and label selector cannot be used together", devPodName)
 		}
 
-		err := validateDevContainer(fmt.Sprintf("dev.%s", devPodName), &devPod.DevContainer, devPod, false)
+		for index, port := range devPod.Ports {
+			if port.URLScheme != "" && port.URLScheme != "http" && port.URLScheme != "https" {
+				return errors.Errorf("dev.%s.ports[%d].urlScheme '%s' is not supported, please use either http or https", devPodName, index, port.URLScheme)
+			}
+			if port.Hostname != "" && len(validation.IsDNS1123Subdomain(port.Hostname)) > 0 {
+				return errors.Errorf("dev.%s.ports[%d].hostname '%s' is not a valid hostname", devPodName, index, port.Hostname)
+			}
+			if port.Protocol != "" && port.Protocol != latest.PortProtocolTCP {
+				return errors.Errorf("dev.%s.ports[%d].protocol '%s' is not supported, only tcp ports can be forwarded", devPodName, index, port.Protocol)
+			}
+			_, portName, named := portforward.ParseNamedPort(port.Port)
+			if named && len(validation.IsValidPortName(portName)) > 0 {
+				return errors.Errorf("dev.%s.ports[%d].port '%s' has an invalid remote port name", devPodName, index, port.Port)
+			}
+			if (port.Container != "" || named) && (port.Lazy || port.AllPods) {
+				return errors.Errorf("dev.%s.ports[%d]: container and named remote ports cannot be used together with lazy or allPods", devPodName, index)
+			}
+			if port.Socket != "" {
+				if strings.Contains(port.Port, ":") {
+					return errors.Errorf("dev.%s.ports[%d].port has to be a single remote port if socket is used", devPodName, index)
+				}
+				if port.BindAddress != "" || port.Hostname != "" || port.PrintURL || port.Lazy {
+					return errors.Errorf("dev.%s.ports[%d]: socket cannot be used together with bindAddress, hostname, printURL or lazy", devPodName, index)
+				}
+			}
+			if port.LabelSelector != "" {
+				_, err := labels.Parse(port.LabelSelector)
+				if err != nil {
+					return errors.Errorf("dev.%s.ports[%d].labelSelector '%s' is not a valid label selector: %v", devPodName, index, port.LabelSelector, err)
+				}
+			}
+			if port.AnnotationSelector != "" {
+				key, _, ok := strings.Cut(port.AnnotationSelector, "=")
+				if !ok || len(validation.IsQualifiedName(key)) > 0 {
+					return errors.Errorf("dev.%s.ports[%d].annotationSelector '%s' has to be in the form key=value", devPodName, index, port.AnnotationSelector)
+				} else if port.LabelSelector == "" || port.AllPods {
+					return errors.Errorf("dev.%s.ports[%d].annotationSelector requires a labelSelector and cannot be used together with allPods", devPodName, index)
+				}
+			}
+			if port.Lazy && port.H2CProbe {
+				return errors.Errorf("dev.%s.ports[%d]: lazy cannot be used together with h2cProbe", devPodName, index)
+			}
+			if port.GRPCHealthCheck != nil && (port.Lazy || port.AllPods) {
+				return errors.Errorf("dev.%s.ports[%d]: grpcHealthCheck cannot be used together with lazy or allPods", devPodName, index)
+			}
+			if port.Service != "" {
+				if len(validation.IsDNS1035Label(port.Service)) > 0 {
+					return errors.Errorf("dev.%s.ports[%d].service '%s' is not a valid service name", devPodName, index, port.Service)
+				} else if !port.AllPods || port.LabelSelector != "" {
+					return errors.Errorf("dev.%s.ports[%d].service requires allPods and cannot be used together with labelSelector", devPodName, index)
+				}
+			}
+			if port.AllPods {
+				if port.LabelSelector == "" && port.Service == "" {
+					return errors.Errorf("dev.%s.ports[%d].allPods requires a labelSelector or service", devPodName, index)
+				}
+				if port.Socket != "" || port.Lazy || port.H2CProbe {
+					return errors.Errorf("dev.%s.ports[%d]: allPods cannot be used together with socket, lazy or h2cProbe", devPodName, index)
+				}
+			}
+			if port.HTTPLog && (port.Lazy || port.AllPods) {
+				return errors.Errorf("dev.%s.ports[%d]: httpLog cannot be used together with lazy or allPods", devPodName, index)
+			}
+			if port.MaxConnections < 0 {
+				return errors.Errorf("dev.%s.ports[%d].maxConnections cannot be negative", devPodName, index)
+			} else if port.MaxConnections > 0 && (port.Lazy || port.AllPods) {
+				return errors.Errorf("dev.%s.ports[%d]: maxConnections cannot be used together with lazy or allPods", devPodName, index)
+			}
+			if len(port.AllowedSources) > 0 {
+				if _, err := portforward.ParseSourceAllowlist(port.AllowedSources); err != nil {
+					return errors.Errorf("dev.%s.ports[%d].allowedSources: %v", devPodName, index, err)
+				} else if port.Lazy || port.AllPods {
+					return errors.Errorf("dev.%s.ports[%d]: allowedSources cannot be used together with lazy or allPods", devPodName, index)
+				}
+			}
+			if port.ReadBufferSize < 0 || port.WriteBufferSize < 0 {
+				return errors.Errorf("dev.%s.ports[%d]: readBufferSize and writeBufferSize cannot be negative", devPodName, index)
+			} else if (port.ReadBufferSize > 0 || port.WriteBufferSize > 0) && (port.Lazy || port.AllPods) {
+				return errors.Errorf("dev.%s.ports[%d]: readBufferSize and writeBufferSize cannot be used together with lazy or allPods", devPodName, index)
+			}
+			if port.NoReconnect && (port.Lazy || port.AllPods) {
+				return errors.Errorf("dev.%s.ports[%d]: noReconnect cannot be used together with lazy or allPods", devPodName, index)
+			}
+			if port.AllowUnready && (port.LabelSelector == "" || port.Lazy || port.AllPods) {
+				return errors.Errorf("dev.%s.ports[%d].allowUnready requires a labelSelector and cannot be used together with lazy or allPods", devPodName, index)
+			}
+			if port.TLS != nil {
+				if port.TLS.ServerName == "" {
+					return errors.Errorf("dev.%s.ports[%d].tls.serverName is required", devPodName, index)
+				} else if (port.TLS.Cert == "") != (port.TLS.Key == "") {
+					return errors.Errorf("dev.%s.ports[%d].tls: cert and key have to be defined together", devPodName, index)
+				} else if port.Lazy || port.AllPods || port.H2CProbe {
+					return errors.Errorf("dev.%s.ports[%d]: tls cannot be used together with lazy, allPods or h2cProbe", devPodName, index)
+				}
+			}
+		}
+
+		if err := validateSharedRemotePorts(devPodName, devPod.Ports); err != nil {
+			return err
+		}
+
+		if devPod.PortsOrder != "" && devPod.PortsOrder != latest.PortsOrderConcurrent && devPod.PortsOrder != latest.PortsOrderReverseFirst && devPod.PortsOrder != latest.PortsOrderForwardFirst {
+			return errors.Errorf("dev.%s.portsOrder %s is invalid. Please choose one of %v", devPodName, string(devPod.PortsOrder), []latest.PortsOrder{latest.PortsOrderConcurrent, latest.PortsOrderReverseFirst, latest.PortsOrderForwardFirst})
+		}
+
+		if devPod.LogLevels != nil {
+			for _, sink := range [][2]string{{"console", devPod.LogLevels.Console}, {"file", devPod.LogLevels.File}} {
+				if sink[1] != "" && sink[1] != "debug" && sink[1] != "info" && sink[1] != "warn" && sink[1] != "error" {
+					return errors.Errorf("dev.%s.logLevels.%s %s is invalid. Please choose one of %v", devPodName, sink[0], sink[1], []string{"debug", "info", "warn", "error"})
+				}
+			}
+		}
+
+		if devPod.ReadinessProbe != nil {
+			if len(devPod.ReadinessProbe.Command) == 0 {
+				return errors.Errorf("dev.%s.readinessProbe.command is required", devPodName)
+			}
+			if devPod.ReadinessProbe.Timeout < 0 {
+				return errors.Errorf("dev.%s.readinessProbe.timeout cannot be negative", devPodName)
+			}
+		}
+
+		err := validateReversePorts(fmt.Sprintf("dev.%s", devPodName), devPod)
+		if err != nil {
+			return err
+		}
+
+		err = validateDevContainer(fmt.Sprintf("dev.%s", devPodName), &devPod.DevContainer, devPod, false)
 		if err != nil {
 			return err
 		}
@@ -401,6 +532,75 @@ func validateDev(config *latest.Config) error {
 	return nil
 }
 
+// validateSharedRemotePorts makes sure that ports that forward the same remote port of the same pod to
+// different local ports use the same forwarding options, because the options are applied per remote port
+func validateSharedRemotePorts(devPodName string, ports []*latest.PortMapping) error {
+	type sharedPort struct {
+		labelSelector      string
+		annotationSelector string
+		remote             uint16
+	}
+
+	shared := map[sharedPort]int{}
+	for index, port := range ports {
+		if port.Lazy || port.AllPods || port.Service != "" {
+			continue
+		}
+
+		mappings, err := portforward.ParsePorts([]string{port.Port})
+		if err != nil {
+			continue
+		}
+
+		key := sharedPort{labelSelector: port.LabelSelector, annotationSelector: port.AnnotationSelector, remote: mappings[0].Remote}
+		other, ok := shared[key]
+		if !ok {
+			shared[key] = index
+			continue
+		}
+
+		first := ports[other]
+		if first.HTTPLog != port.HTTPLog || first.MaxConnections != port.MaxConnections || first.ReadBufferSize != port.ReadBufferSize || first.WriteBufferSize != port.WriteBufferSize || !reflect.DeepEqual(first.TLS, port.TLS) || !reflect.DeepEqual(first.AllowedSources, port.AllowedSources) {
+			return errors.Errorf("dev.%s.ports[%d] forwards remote port %d like ports[%d], so httpLog, maxConnections, readBufferSize, writeBufferSize, tls and allowedSources have to be the same", devPodName, index, mappings[0].Remote, other)
+		}
+	}
+
+	return nil
+}
+
+// validateReversePorts makes sure that a remote port is only reverse forwarded once per pod,
+// because all containers of a pod share the same network
+func validateReversePorts(path string, devPod *latest.DevPod) error {
+	devContainers := []*latest.DevContainer{&devPod.DevContainer}
+	if len(devPod.Containers) > 0 {
+		devContainers = []*latest.DevContainer{}
+		for _, devContainer := range devPod.Containers {
+			devContainers = append(devContainers, devContainer)
+		}
+	}
+
+	remotePorts := map[uint16]string{}
+	for _, devContainer := range devContainers {
+		for _, port := range devContainer.ReversePorts {
+			mappings, err := portforward.ParsePorts([]string{port.Port})
+			if err != nil {
+				continue
+			}
+
+			container := port.Container
+			if container == "" {
+				container = devContainer.Container
+			}
+			if other, ok := remotePorts[mappings[0].Remote]; ok {
+				return errors.Errorf("%s: remote port %d is reverse forwarded to container '%s' and '%s', but containers of a pod share the same network", path, mappings[0].Remote, other, container)
+			}
+			remotePorts[mappings[0].Remote] = container
+		}
+	}
+
+	return nil
+}
+
 func validateDevContainer(path string, devContainer *latest.DevContainer, devPod *latest.DevPod, nameRequired bool) error {
 	if nameRequired && devContainer.Container == "" {
 		return errors.Errorf("%s.container is required", path)
@@ -444,10 +644,16 @@ func validateDevContainer(path string, devContainer *latest.DevContainer, devPod
 			}
 		}
 	}
+	if devContainer.ReversePortsHelper != "" && !strings.HasPrefix(devContainer.ReversePortsHelper, "/") {
+		return errors.Errorf("%s.reversePortsHelper '%s' has to be an absolute path", path, devContainer.ReversePortsHelper)
+	}
 	for index, port := range devContainer.ReversePorts {
 		if port.Port == "" {
 			return errors.Errorf("%s.reversePorts[%d].port is required", path, index)
 		}
+		if port.TargetHost != "" && net.ParseIP(port.TargetHost) == nil && len(validation.IsDNS1123Subdomain(port.TargetHost)) > 0 {
+			return errors.Errorf("%s.reversePorts[%d].targetHost '%s' is not a valid ip or hostname", path, index, port.TargetHost)
+		}
 	}
 	for j, p := range devContainer.PersistPaths {
 		if p.Path == "" {
@@ -150,7 +150,7 @@ func TestValidateDev(t *testing.T) {
 					"app": "MeApp",
 				},
 				DevContainer: latest.DevContainer{
-					ReversePorts: []*latest.PortMapping{
+					ReversePorts: []*latest.ReversePortMapping{
 						{
 							Port: fmt.Sprintf("%v:%v", 8080, 8080),
 						},
@@ -159,7 +159,7 @@ func TestValidateDev(t *testing.T) {
 				Containers: map[string]*latest.DevContainer{
 					"test": {
 						Container: "test",
-						ReversePorts: []*latest.PortMapping{
+						ReversePorts: []*latest.ReversePortMapping{
 							{
 								Port: fmt.Sprintf("%v:%v", 8081, 8081),
 							},
@@ -172,4 +172,254 @@ func TestValidateDev(t *testing.T) {
 
 	err = validateDev(config)
 	assert.Error(t, err, "dev.somename.reversePorts will be overwritten by dev.somename.containers[test], please specify dev.somename.containers[test].reversePorts instead")
+
+	// test conflicting reverse ports within one pod
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"somename": {
+				Name: "somename",
+				LabelSelector: map[string]string{
+					"app": "MeApp",
+				},
+				DevContainer: latest.DevContainer{
+					Container: "first",
+					ReversePorts: []*latest.ReversePortMapping{
+						{
+							Port: fmt.Sprintf("%v:%v", 8080, 9090),
+						},
+						{
+							Port:      fmt.Sprintf("%v:%v", 8081, 9090),
+							Container: "second",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename: remote port 9090 is reverse forwarded to container 'first' and 'second', but containers of a pod share the same network")
+
+	// test invalid label selector expression of a port
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"somename": {
+				Name:          "somename",
+				ImageSelector: "selecMe",
+				Ports: []*latest.PortMapping{
+					{
+						Port:          "8080",
+						LabelSelector: "env in (dev,staging)",
+					},
+					{
+						Port:          "9090",
+						LabelSelector: "env in (dev",
+					},
+				},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.ErrorContains(t, err, "dev.somename.ports[1].labelSelector 'env in (dev' is not a valid label selector")
+
+	// test allPods without label selector
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"somename": {
+				Name:          "somename",
+				ImageSelector: "selecMe",
+				Ports: []*latest.PortMapping{
+					{
+						Port:    "8080",
+						AllPods: true,
+					},
+				},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].allPods requires a labelSelector or service")
+
+	// test service without allPods
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:    "8080",
+			Service: "backend",
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].service requires allPods and cannot be used together with labelSelector")
+
+	// test annotationSelector without label selector
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:               "8080",
+			AnnotationSelector: "role=primary",
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].annotationSelector requires a labelSelector and cannot be used together with allPods")
+
+	// test invalid annotationSelector
+	config.Dev["somename"].Ports[0].AnnotationSelector = "role"
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].annotationSelector 'role' has to be in the form key=value")
+
+	// test negative maxConnections
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:           "8080",
+			MaxConnections: -1,
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].maxConnections cannot be negative")
+
+	// test remote port shared with different options
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port: "8080",
+		},
+		{
+			Port:    "8081:8080",
+			HTTPLog: true,
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[1] forwards remote port 8080 like ports[0], so httpLog, maxConnections, readBufferSize, writeBufferSize, tls and allowedSources have to be the same")
+
+	// test remote port shared with the same options
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:           "8080",
+			MaxConnections: 10,
+		},
+		{
+			Port:           "8081:8080",
+			MaxConnections: 10,
+		},
+	}
+
+	err = validateDev(config)
+	assert.NilError(t, err)
+
+	// test invalid allowed sources
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:           "8080",
+			AllowedSources: []string{"10.0.0.0/8", "localhost"},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].allowedSources: invalid source localhost: not a valid IP address or CIDR")
+
+	// test buffer sizes with lazy
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:           "8080",
+			Lazy:           true,
+			ReadBufferSize: 1024 * 1024,
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0]: readBufferSize and writeBufferSize cannot be used together with lazy or allPods")
+
+	// test tls without key
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port: "8443",
+			TLS: &latest.PortTLS{
+				ServerName: "my-service.default.svc",
+				Cert:       "client.crt",
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].tls: cert and key have to be defined together")
+
+	// test allowUnready without labelSelector
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:         "5005",
+			AllowUnready: true,
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].allowUnready requires a labelSelector and cannot be used together with lazy or allPods")
+
+	// test unknown protocol
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:     "8080",
+			Protocol: "udp",
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].protocol 'udp' is not supported, only tcp ports can be forwarded")
+
+	// test sctp protocol
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:     "8080",
+			Protocol: "sctp",
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0].protocol 'sctp' is not supported, only tcp ports can be forwarded")
+
+	// test noReconnect with lazy
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port:        "8080",
+			Lazy:        true,
+			NoReconnect: true,
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0]: noReconnect cannot be used together with lazy or allPods")
+
+	// test named remote port with lazy
+	config.Dev["somename"].Ports = []*latest.PortMapping{
+		{
+			Port: "8080:http",
+			Lazy: true,
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.ports[0]: container and named remote ports cannot be used together with lazy or allPods")
+
+	// test readiness probe without command
+	config = &latest.Config{
+		Dev: map[string]*latest.DevPod{
+			"somename": {
+				Name:           "somename",
+				ImageSelector:  "selecMe",
+				ReadinessProbe: &latest.ReadinessProbe{Timeout: 10},
+			},
+		},
+	}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.readinessProbe.command is required")
+
+	// test invalid log level
+	config.Dev["somename"].ReadinessProbe = nil
+	config.Dev["somename"].LogLevels = &latest.DevPodLogLevels{Console: "warn", File: "trace"}
+
+	err = validateDev(config)
+	assert.Error(t, err, "dev.somename.logLevels.file trace is invalid. Please choose one of [debug info warn error]")
 }
@@ -31,6 +31,9 @@ type Options struct {
 
 type PurgeOptions struct {
 	ForcePurge bool `long:"force-purge" description:"Forces purging of deployments even though they might be still in use by other DevSpace projects"`
+
+	VerifyRevert        bool  `long:"verify-revert" description:"If enabled, stop_dev waits until the original workload of a replaced dev pod is available again"`
+	VerifyRevertTimeout int64 `long:"verify-revert-timeout" description:"The timeout in seconds to wait for the original workload to become available again" default:"120"`
 }
 
 // Controller is the main deploying interface
@@ -0,0 +1,65 @@
+package devpod
+
+import (
+	"os"
+	"sort"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	logpkg "github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/stringutil"
+	"gopkg.in/yaml.v3"
+)
+
+// setColorOrder assigns the log colors of the dev pods in the order they are declared in the config
+// if ordered colors are enabled. Dev pods that are not declared in the config file itself, e.g.
+// because they come from an import, are ordered by name after the declared ones.
+func setColorOrder(ctx devspacecontext.Context) {
+	if !logpkg.OrderedColors() || ctx.Config() == nil || ctx.Config().Config() == nil {
+		return
+	}
+
+	dev := ctx.Config().Config().Dev
+	order := []string{}
+	for _, name := range declaredDevPods(ctx.Config().Path()) {
+		if _, ok := dev[name]; ok {
+			order = append(order, name)
+		}
+	}
+
+	remaining := []string{}
+	for name := range dev {
+		if !stringutil.Contains(order, name) {
+			remaining = append(remaining, name)
+		}
+	}
+	sort.Strings(remaining)
+	logpkg.SetColorOrder(append(order, remaining...))
+}
+
+// declaredDevPods returns the names of the dev pods in the order they are declared in the config file
+func declaredDevPods(path string) []string {
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(out, doc); err != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+
+	root := doc.Content[0].Content
+	for i := 0; i+1 < len(root); i += 2 {
+		if root[i].Value != "dev" || root[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+
+		names := []string{}
+		for j := 0; j < len(root[i+1].Content); j += 2 {
+			names = append(names, root[i+1].Content[j].Value)
+		}
+		return names
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"reflect"
 	syncpkg "sync"
 
 	"github.com/loft-sh/devspace/pkg/devspace/deploy"
@@ -23,6 +24,7 @@ import (
 	"github.com/loft-sh/devspace/pkg/devspace/services/proxycommands"
 	"github.com/loft-sh/devspace/pkg/devspace/services/ssh"
 	"github.com/loft-sh/devspace/pkg/devspace/services/terminal"
+	"github.com/loft-sh/devspace/pkg/util/backoff"
 	logpkg "github.com/loft-sh/devspace/pkg/util/log"
 	"github.com/loft-sh/devspace/pkg/util/tomb"
 	"github.com/sirupsen/logrus"
@@ -35,6 +37,7 @@ import (
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
 	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
 	"github.com/loft-sh/devspace/pkg/devspace/hook"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
 	"github.com/loft-sh/devspace/pkg/devspace/services/podreplace"
 	"github.com/loft-sh/devspace/pkg/devspace/services/portforwarding"
 	"github.com/loft-sh/devspace/pkg/devspace/services/sync"
@@ -44,6 +47,15 @@ import (
 
 var (
 	openMaxWait = 5 * time.Minute
+
+	// revertTimeout is the maximum time resetting a dev pod may take to revert the replaced pod
+	revertTimeout = 2 * time.Minute
+
+	// defaultRestartDelay is the default delay between failed restart attempts of a dev pod
+	defaultRestartDelay = 10 * time.Second
+
+	// logExecuteHooksFunc executes the hooks of dev pod events, it can be replaced in tests
+	logExecuteHooksFunc = hook.LogExecuteHooks
 )
 
 var (
@@ -54,19 +66,66 @@ var (
 
 type devPod struct {
 	selectedPod *selector.SelectedPodContainer
+	config      *latest.DevPod
+	options     Options
+	forwards    *portforwarding.Forwards
+
+	// ports are the forwarded port mappings, which differ from the ports of the config
+	// after they were reloaded. The config is shared and is never changed.
+	ports []*latest.PortMapping
 
 	m syncpkg.Mutex
 
-	done chan struct{}
-	err  error
+	done     chan struct{}
+	doneOnce syncpkg.Once
+	err      error
+	started  time.Time
+	ready    bool
+
+	onGiveUp    GiveUpCallback
+	onReconnect ReconnectCallback
+
+	// traffic counts the bytes forwarded by the port forwarding of the dev pod
+	traffic *portforward.Traffic
+
+	// history keeps the recent errors of the dev pod and its port forwarding
+	history *errorHistory
+
+	// selectedPods records the pod each port is forwarded to
+	selectedPods *portforwarding.SelectedPods
+
+	// logger is the logger of the dev pod that log sinks can be attached to
+	logger logpkg.Logger
+
+	// prefix is the log prefix of the dev pod, which can be renamed while it is running
+	prefix *logpkg.PrefixLabel
+
+	// fileLog pauses and resumes writing the logs of the dev pod to its log file
+	fileLog *logpkg.PauseSwitch
+
+	// t is the tomb of the currently running dev pod services
+	t *tomb.Tomb
+
+	// forwardingTomb is the tomb of the running port forwarding and restartForwarding
+	// starts the port forwarding again
+	forwardingTomb    *tomb.Tomb
+	restartForwarding func() error
 
 	cancelCtx context.Context
 	cancel    context.CancelFunc
 }
 
-func newDevPod() *devPod {
+func newDevPod(onGiveUp GiveUpCallback, onReconnect ReconnectCallback) *devPod {
 	return &devPod{
-		done: make(chan struct{}),
+		done:        make(chan struct{}),
+		onGiveUp:    onGiveUp,
+		onReconnect: onReconnect,
+		traffic:     &portforward.Traffic{},
+		history:     &errorHistory{},
+
+		selectedPods: &portforwarding.SelectedPods{},
+		fileLog:      &logpkg.PauseSwitch{},
+		prefix:       &logpkg.PrefixLabel{},
 	}
 }
 
@@ -78,6 +137,10 @@ func (d *devPod) Start(ctx devspacecontext.Context, devPodConfig *latest.DevPod,
 	}
 
 	d.cancelCtx, d.cancel = context.WithCancel(ctx.Context())
+	d.config = devPodConfig
+	d.ports = devPodConfig.Ports
+	d.options = options
+	d.started = time.Now()
 	ctx = ctx.WithContext(d.cancelCtx)
 	d.m.Unlock()
 
@@ -96,9 +159,49 @@ func (d *devPod) Start(ctx devspacecontext.Context, devPodConfig *latest.DevPod,
 		return err
 	}
 
+	// wait until the readiness probe succeeds
+	if devPodConfig.ReadinessProbe != nil {
+		err = d.waitForReadinessProbe(ctx, devPodConfig.ReadinessProbe)
+		if err != nil {
+			d.Stop()
+			return err
+		}
+	}
+
+	d.m.Lock()
+	d.ready = true
+	d.m.Unlock()
+
+	// print a heartbeat periodically
+	if options.HeartbeatInterval > 0 {
+		go d.heartbeat(ctx, time.Duration(options.HeartbeatInterval)*time.Second)
+	}
 	return nil
 }
 
+// heartbeat prints a one line status of the dev pod at the given interval until the
+// dev pod is stopped. Nothing is printed while the dev pod is reconnecting.
+func (d *devPod) heartbeat(ctx devspacecontext.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Context().Done():
+			return
+		case <-d.done:
+			return
+		case <-ticker.C:
+			status := d.Status()
+			if status.State != StateRunning {
+				continue
+			}
+
+			ctx.Log().Info(status.Heartbeat())
+		}
+	}
+}
+
 func (d *devPod) Err() error {
 	d.m.Lock()
 	defer d.m.Unlock()
@@ -106,6 +209,14 @@ func (d *devPod) Err() error {
 	return d.err
 }
 
+// Options returns the options the dev pod was started with
+func (d *devPod) Options() Options {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	return d.options
+}
+
 func (d *devPod) Done() <-chan struct{} {
 	return d.done
 }
@@ -119,6 +230,99 @@ func (d *devPod) Stop() {
 	<-d.done
 }
 
+// RestartReason describes why a dev pod is restarted
+type RestartReason string
+
+const (
+	// RestartReasonLostConnection restarts the dev pod because the connection to the pod was lost
+	RestartReasonLostConnection RestartReason = "lost-connection"
+	// RestartReasonConfigChanged restarts the dev pod intentionally because its config has changed
+	RestartReasonConfigChanged RestartReason = "config-changed"
+	// RestartReasonPortForwarding restarts only the port forwarding of the dev pod on request
+	RestartReasonPortForwarding RestartReason = "port-forwarding"
+)
+
+// isFailure returns true if the restart is caused by a problem, which means failed restart
+// attempts are logged and retried with a delay
+func (r RestartReason) isFailure() bool {
+	return r == RestartReasonLostConnection
+}
+
+// restartRequest stops the services of a dev pod to restart it with the given config
+type restartRequest struct {
+	config *latest.DevPod
+	reason RestartReason
+}
+
+func (r restartRequest) Error() string {
+	return "restart dev pod because of " + string(r.reason)
+}
+
+// logRestart logs the restart of the dev pod and executes the restart:dev hooks with its reason.
+// Restarts after a failure are already logged by their error, so they are only logged at debug level.
+func logRestart(ctx devspacecontext.Context, name string, reason RestartReason) {
+	level := logrus.InfoLevel
+	if reason.isFailure() {
+		level = logrus.DebugLevel
+	}
+	ctx.Log().Printf(level, "Restart dev %s because of %s", name, reason)
+	logExecuteHooksFunc(ctx, map[string]interface{}{
+		"dev_pod_name":   name,
+		"restart_reason": string(reason),
+		"summary":        fmt.Sprintf("Dev %s is restarting because of %s", name, reason),
+	}, hook.EventsForSingle("restart:dev", name).With("dev.restart")...)
+}
+
+// requestRestart restarts the running dev pod with the given config for the given reason.
+// Unlike a restart after a lost connection this doesn't check the pod first.
+func (d *devPod) requestRestart(devPodConfig *latest.DevPod, reason RestartReason) {
+	d.m.Lock()
+	t := d.t
+	d.m.Unlock()
+	if t != nil {
+		t.Kill(restartRequest{config: devPodConfig, reason: reason})
+	}
+}
+
+// ReloadPortForwarding changes the forwarded ports of the running dev pod to the
+// ports of the given config without restarting the dev pod. If anything else of the
+// config changed, e.g. the reverse ports, the dev pod is restarted with the new config.
+func (d *devPod) ReloadPortForwarding(devPodConfig *latest.DevPod) error {
+	d.m.Lock()
+	forwards := d.forwards
+	configChanged := d.config != nil && !reflect.DeepEqual(withoutPorts(d.config), withoutPorts(devPodConfig))
+	if !configChanged {
+		d.ports = devPodConfig.Ports
+	}
+	d.m.Unlock()
+	if configChanged {
+		d.requestRestart(devPodConfig, RestartReasonConfigChanged)
+		return nil
+	} else if forwards == nil {
+		return nil
+	}
+
+	return forwards.Update(devPodConfig.Ports)
+}
+
+// withPorts returns a copy of the config with the currently forwarded port mappings
+func (d *devPod) withPorts(devPodConfig *latest.DevPod) *latest.DevPod {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	copied := *devPodConfig
+	copied.Ports = d.ports
+	return &copied
+}
+
+// withoutPorts returns a copy of the config without the port mappings, which can be reloaded
+// without restarting the dev pod
+func withoutPorts(devPodConfig *latest.DevPod) *latest.DevPod {
+	copied := *devPodConfig
+	copied.Ports = nil
+	return &copied
+}
+
 func (d *devPod) startWithRetry(ctx devspacecontext.Context, devPodConfig *latest.DevPod, options Options) error {
 	t := &tomb.Tomb{}
 
@@ -133,9 +337,21 @@ func (d *devPod) startWithRetry(ctx devspacecontext.Context, devPodConfig *lates
 		if ctx.IsDone() {
 			<-t.Dead()
 			ctx.Log().Debugf("Stopped dev %s", devPodConfig.Name)
-			close(d.done)
+			d.markDone(nil)
+			return
+		}
+
+		// intentional restarts don't need to check the pod
+		if request, ok := t.Err().(restartRequest); ok {
+			d.m.Lock()
+			d.selectedPod = nil
+			d.config = request.config
+			d.ports = request.config.Ports
+			d.m.Unlock()
+			d.restart(ctx, request.config, options, request.reason)
 			return
 		}
+		d.history.add(ErrorSourceDev, t.Err())
 
 		// check if pod was terminated
 		d.m.Lock()
@@ -171,22 +387,22 @@ func (d *devPod) startWithRetry(ctx devspacecontext.Context, devPodConfig *lates
 					ctx.Log().Errorf("error restarting dev: %v", err)
 				}
 			} else if shouldRestart {
-				d.restart(ctx, devPodConfig, options)
+				d.restart(ctx, devPodConfig, options, RestartReasonLostConnection)
 				return
 			}
 		}
 
 		ctx.Log().Debugf("Stopped dev %s", devPodConfig.Name)
-		d.m.Lock()
-		d.err = t.Err()
-		d.m.Unlock()
-		close(d.done)
+		d.markDone(t.Err())
 	}(ctx)
 
 	// Create a new tomb and run it
+	d.m.Lock()
+	d.t = t
+	d.m.Unlock()
 	tombCtx := t.Context(ctx.Context())
 	ctx = ctx.WithContext(tombCtx)
-	<-t.NotifyGo(func() error {
+	<-t.NotifyGoNamed("start", func() error {
 		return d.start(ctx, devPodConfig, options, t)
 	})
 	if !t.Alive() {
@@ -196,7 +412,23 @@ func (d *devPod) startWithRetry(ctx devspacecontext.Context, devPodConfig *lates
 	return nil
 }
 
-func (d *devPod) restart(ctx devspacecontext.Context, devPodConfig *latest.DevPod, options Options) {
+// markDone marks the dev pod as stopped with the given error
+func (d *devPod) markDone(err error) {
+	d.doneOnce.Do(func() {
+		d.m.Lock()
+		d.err = err
+		d.m.Unlock()
+		close(d.done)
+	})
+}
+
+// restart starts the dev pod again until it succeeds. Failed attempts are retried after a
+// while, unless the restart was intentional, in which case the first failed attempt is
+// retried right away and only the following ones are treated as failures.
+func (d *devPod) restart(ctx devspacecontext.Context, devPodConfig *latest.DevPod, options Options, reason RestartReason) {
+	logRestart(ctx, devPodConfig.Name, reason)
+	restartBackoff := backoff.New(defaultRestartDelay).Merge(options.restartBackoff)
+	attempts := 0
 	for {
 		err := d.startWithRetry(ctx, devPodConfig, options)
 		if err != nil {
@@ -204,15 +436,44 @@ func (d *devPod) restart(ctx devspacecontext.Context, devPodConfig *latest.DevPo
 				return
 			}
 
-			ctx.Log().Infof("Restart dev %s because of: %v", devPodConfig.Name, err)
+			d.history.add(ErrorSourceDev, err)
+			if !reason.isFailure() {
+				ctx.Log().Debugf("Error restarting dev %s because of %s: %v", devPodConfig.Name, reason, err)
+				reason = RestartReasonLostConnection
+				continue
+			}
+
+			attempts++
+			if options.MaxRestartAttempts > 0 && attempts >= options.MaxRestartAttempts {
+				ctx.Log().Errorf("Giving up restarting dev %s after %d attempts: %v", devPodConfig.Name, attempts, err)
+				d.markDone(err)
+				if d.onGiveUp != nil {
+					d.onGiveUp(devPodConfig.Name, err)
+				}
+				hook.LogExecuteHooks(ctx, map[string]interface{}{
+					"dev_pod_name": devPodConfig.Name,
+					"attempts":     attempts,
+					"error":        err,
+					"summary":      fmt.Sprintf("Gave up restarting dev %s after %d attempts: %v", devPodConfig.Name, attempts, err),
+				}, hook.EventsForSingle("giveUp:dev", devPodConfig.Name).With("dev.giveUp")...)
+				return
+			}
+
+			class := backoff.Classify(err)
+			delay := restartBackoff.Get(class).Delay(attempts - 1)
+			ctx.Log().Printf(logpkg.RepeatedLevel(logrus.InfoLevel, attempts-1, options.QuietReconnect), "Restart dev %s because of: %v", devPodConfig.Name, err)
+			ctx.Log().Debugf("Restart dev %s in %s (%s error)", devPodConfig.Name, delay, class)
 			select {
 			case <-ctx.Context().Done():
 				return
-			case <-time.After(time.Second * 10):
+			case <-time.After(delay):
 				continue
 			}
 		}
 
+		if d.onReconnect != nil {
+			d.onReconnect(devPodConfig.Name, ReconnectDevPod)
+		}
 		return
 	}
 }
@@ -253,7 +514,7 @@ func (d *devPod) start(ctx devspacecontext.Context, devPodConfig *latest.DevPod,
 	var err error
 	selectedPod, err := targetselector.NewTargetSelector(options).SelectSingleContainer(ctx.Context(), ctx.KubeClient(), ctx.Log())
 	if err != nil {
-		return errors.Wrap(err, "waiting for pod to become ready")
+		return backoff.Selector(errors.Wrap(err, "waiting for pod to become ready"))
 	}
 
 	// check if the correct pod is matched
@@ -278,7 +539,7 @@ func (d *devPod) start(ctx devspacecontext.Context, devPodConfig *latest.DevPod,
 		return false
 	})
 	if err != nil {
-		return errors.Wrap(err, "select pod")
+		return backoff.Selector(errors.Wrap(err, "select pod"))
 	}
 	ctx.Log().Infof("Selected pod %s", ansi.Color(selectedPod.Pod.Name, "yellow+b"))
 
@@ -294,7 +555,7 @@ func (d *devPod) start(ctx devspacecontext.Context, devPodConfig *latest.DevPod,
 			if openConfig.URL != "" {
 				url := openConfig.URL
 				ctx.Log().Infof("Opening '%s' as soon as application will be started", openConfig.URL)
-				parent.Go(func() error {
+				parent.GoNamed("open "+url, func() error {
 					now := time.Now()
 					for time.Since(now) < openMaxWait {
 						select {
@@ -379,7 +640,11 @@ func (d *devPod) startLogs(ctx devspacecontext.Context, devPodConfig *latest.Dev
 			return true
 		}
 
-		parent.Go(func() error {
+		name := "logs"
+		if devContainer.Container != "" {
+			name += " " + devContainer.Container
+		}
+		parent.GoNamed(name, func() error {
 			return logs.StartLogs(ctx, devContainer, newTargetSelector(selectedPod.Pod.Name, selectedPod.Pod.Namespace, selectedPod.Container.Name, parent))
 		})
 
@@ -418,7 +683,7 @@ func (d *devPod) getTerminalDevContainer(devPodConfig *latest.DevPod) *latest.De
 }
 
 func (d *devPod) startAttach(ctx devspacecontext.Context, devContainer *latest.DevContainer, opts Options, selectedPod *selector.SelectedPodContainer, parent *tomb.Tomb) error {
-	parent.Go(func() error {
+	parent.GoNamed("attach", func() error {
 		id, err := logpkg.AcquireGlobalSilence()
 		if err != nil {
 			return err
@@ -458,7 +723,7 @@ func (d *devPod) startAttach(ctx devspacecontext.Context, devContainer *latest.D
 }
 
 func (d *devPod) startTerminal(ctx devspacecontext.Context, devContainer *latest.DevContainer, opts Options, selectedPod *selector.SelectedPodContainer, parent *tomb.Tomb) error {
-	parent.Go(func() error {
+	parent.GoNamed("terminal", func() error {
 		id, err := logpkg.AcquireGlobalSilence()
 		if err != nil {
 			return err
@@ -504,25 +769,25 @@ func (d *devPod) startServices(ctx devspacecontext.Context, devPod *latest.DevPo
 	}
 
 	// Start sync
-	syncDone := parent.NotifyGo(func() error {
+	syncDone := parent.NotifyGoNamed("sync", func() error {
 		if opts.DisableSync {
 			return nil
 		}
 
 		// add prefix
 		ctx := ctx.WithLogger(ctx.Log().WithPrefixColor("sync  ", "yellow+b"))
-		err := sync.StartSync(ctx, devPod, selector, parent)
+		err := sync.StartScopedSync(ctx, opts.scope, devPod, selector, parent)
 		return err
 	})
 
 	// Start Port Forwarding
-	portForwardingDone := parent.NotifyGo(func() error {
+	portForwardingDone := parent.NotifyGoNamed("port forwarding", func() error {
 		if opts.DisablePortForwarding {
 			return nil
 		}
 
 		ctx := ctx.WithLogger(ctx.Log().WithPrefixColor("ports ", "yellow+b"))
-		return portforwarding.StartPortForwarding(ctx, devPod, selector, parent)
+		return d.startPortForwarding(ctx, d.withPorts(devPod), selector, opts, parent)
 	})
 
 	// wait for both to finish
@@ -530,14 +795,14 @@ func (d *devPod) startServices(ctx devspacecontext.Context, devPod *latest.DevPo
 	<-portForwardingDone
 
 	// Start SSH
-	sshDone := parent.NotifyGo(func() error {
+	sshDone := parent.NotifyGoNamed("ssh", func() error {
 		// add ssh prefix
 		ctx := ctx.WithLogger(ctx.Log().WithPrefixColor("ssh   ", "yellow+b"))
 		return ssh.StartSSH(ctx, devPod, selector, parent)
 	})
 
 	// Start Reverse Commands
-	reverseCommandsDone := parent.NotifyGo(func() error {
+	reverseCommandsDone := parent.NotifyGoNamed("proxy commands", func() error {
 		// add proxy prefix
 		ctx := ctx.WithLogger(ctx.Log().WithPrefixColor("proxy ", "yellow+b"))
 		return proxycommands.StartProxyCommands(ctx, devPod, selector, parent)
@@ -556,6 +821,96 @@ func (d *devPod) startServices(ctx devspacecontext.Context, devPod *latest.DevPo
 	return nil
 }
 
+// startPortForwarding starts the port forwarding and reverse port forwarding of the dev pod
+// in their own tomb, so that they can be restarted without affecting the other services.
+// If the port forwarding stops on its own, the parent tomb is killed as well.
+func (d *devPod) startPortForwarding(ctx devspacecontext.Context, devPod *latest.DevPod, selector targetselector.TargetSelector, opts Options, parent *tomb.Tomb) error {
+	t := &tomb.Tomb{}
+	t.GoNamed("keep alive", func() error {
+		<-t.Dying()
+		return nil
+	})
+
+	forwards, err := portforwarding.StartPortForwarding(ctx.WithContext(t.Context(ctx.Context())), devPod, selector, portforwarding.Options{
+		QuietReconnect:       opts.QuietReconnect,
+		ReconnectGracePeriod: time.Duration(opts.ReconnectGracePeriod) * time.Millisecond,
+		InitTimeout:          time.Duration(opts.PortForwardingInitTimeout) * time.Second,
+		OnlyPorts:            opts.OnlyPorts,
+		RetryReadyTimeout:    opts.RetryReadyTimeout,
+		Traffic:              d.traffic,
+		StopSummary:          opts.PortForwardingSummary,
+		SelectedPods:         d.selectedPods,
+		Listen:               opts.LocalListener,
+		RestartBackoff:       opts.restartBackoff,
+		OnError: func(err error) {
+			d.history.add(ErrorSourcePortForwarding, err)
+		},
+		OnReconnect: func() {
+			if d.onReconnect != nil {
+				d.onReconnect(devPod.Name, ReconnectPortForwarding)
+			}
+		},
+	}, t)
+	if err != nil {
+		t.Kill(err)
+		<-t.Dead()
+		return err
+	}
+
+	started := parent.TryGoNamed("port forwarding supervisor", func() error {
+		select {
+		case <-parent.Dying():
+			// the port forwarding is started again by a requested restart of the dev pod
+			var reason error
+			if _, ok := parent.Err().(restartRequest); ok {
+				reason = portforwarding.ErrRestartRequested
+			}
+			t.Kill(reason)
+			<-t.Dead()
+		case <-t.Dead():
+			d.m.Lock()
+			restarting := d.forwardingTomb != t
+			d.m.Unlock()
+			if !restarting {
+				parent.Kill(t.Err())
+			}
+		}
+		return nil
+	})
+	if !started {
+		t.Kill(nil)
+		<-t.Dead()
+		return errors.New("dev pod is shutting down")
+	}
+
+	d.m.Lock()
+	d.forwards = forwards
+	d.forwardingTomb = t
+	d.restartForwarding = func() error {
+		logRestart(ctx, devPod.Name, RestartReasonPortForwarding)
+		return d.startPortForwarding(ctx, d.withPorts(devPod), selector, opts, parent)
+	}
+	d.m.Unlock()
+	return nil
+}
+
+// RestartForwarding stops the port forwarding and reverse port forwarding of the dev pod and
+// starts them again with the same config. Other services like sync are not affected.
+func (d *devPod) RestartForwarding() error {
+	d.m.Lock()
+	t := d.forwardingTomb
+	restart := d.restartForwarding
+	d.forwardingTomb = nil
+	d.m.Unlock()
+	if t == nil {
+		return errors.New("port forwarding is not running")
+	}
+
+	t.Kill(portforwarding.ErrRestartRequested)
+	<-t.Dead()
+	return restart()
+}
+
 func needPodReplace(devPodConfig *latest.DevPod) bool {
 	if len(devPodConfig.Patches) > 0 {
 		return true
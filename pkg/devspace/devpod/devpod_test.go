@@ -0,0 +1,69 @@
+package devpod
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config"
+	"github.com/loft-sh/devspace/pkg/devspace/config/remotecache"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRequestRestart(t *testing.T) {
+	restarted := make(chan map[string]interface{}, 1)
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() { logExecuteHooksFunc = oldLogExecuteHooksFunc }()
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		if events[0] == "restart:dev:*" {
+			restarted <- extraEnv
+		}
+	}
+
+	devPodConfig := &latest.DevPod{Name: "app"}
+	conf := config.NewConfig(nil, nil, &latest.Config{Dev: map[string]*latest.DevPod{"app": devPodConfig}}, nil, remotecache.NewCache("", "test"), nil, "")
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat)).WithConfig(conf).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+
+	// there is no pod, so the dev pod keeps waiting for it
+	dp := newDevPod(nil, nil)
+	dp.config = devPodConfig
+	go func() {
+		_ = dp.startWithRetry(ctx, devPodConfig, Options{DisablePodReplace: true})
+	}()
+	for {
+		dp.m.Lock()
+		started := dp.t != nil
+		dp.m.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	dp.requestRestart(&latest.DevPod{Name: "app", Namespace: "other"}, RestartReasonConfigChanged)
+	select {
+	case env := <-restarted:
+		assert.Equal(t, env["dev_pod_name"], "app")
+		assert.Equal(t, env["restart_reason"], "config-changed")
+	case <-time.After(time.Second * 10):
+		t.Fatal("restart:dev hooks were not executed")
+	}
+
+	cancel()
+	<-dp.Done()
+	assert.Assert(t, strings.Contains(out.String(), "Restart dev app because of config-changed"), out.String())
+	dp.m.Lock()
+	defer dp.m.Unlock()
+	assert.Equal(t, dp.config.Namespace, "other")
+}
@@ -0,0 +1,70 @@
+package devpod
+
+import (
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/util/backoff"
+)
+
+// maxErrorHistory is the maximum number of recent errors that are kept per dev pod
+const maxErrorHistory = 20
+
+// ErrorSource is the part of a dev pod an error happened in
+type ErrorSource string
+
+const (
+	// ErrorSourceDev are errors that caused the dev pod to restart or that happened while restarting it
+	ErrorSourceDev ErrorSource = "dev"
+	// ErrorSourcePortForwarding are errors that caused the port forwarding to restart or that
+	// happened while restarting it
+	ErrorSourcePortForwarding ErrorSource = "portForwarding"
+)
+
+// ErrorRecord is an error a dev pod ran into while it was running
+type ErrorRecord struct {
+	// Time is the time the error happened
+	Time time.Time
+
+	// Source is the part of the dev pod the error happened in
+	Source ErrorSource
+
+	// Class is the class of the error that decides the restart backoff
+	Class backoff.Class
+
+	// Err is the error itself
+	Err error
+}
+
+// errorHistory keeps the most recent errors of a dev pod, older errors are dropped
+type errorHistory struct {
+	m       sync.Mutex
+	records []ErrorRecord
+}
+
+func (h *errorHistory) add(source ErrorSource, err error) {
+	if err == nil {
+		return
+	}
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.records = append(h.records, ErrorRecord{
+		Time:   time.Now(),
+		Source: source,
+		Class:  backoff.Classify(err),
+		Err:    err,
+	})
+	if len(h.records) > maxErrorHistory {
+		h.records = append([]ErrorRecord{}, h.records[len(h.records)-maxErrorHistory:]...)
+	}
+}
+
+// list returns a copy of the recorded errors, the oldest first
+func (h *errorHistory) list() []ErrorRecord {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	return append([]ErrorRecord{}, h.records...)
+}
@@ -0,0 +1,93 @@
+package devpod
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/hook"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+)
+
+// idleCheckInterval is the maximum interval in which the traffic of a dev pod is checked
+var idleCheckInterval = time.Second * 10
+
+// watchIdle stops the dev pod after its port forwarding didn't forward any traffic for the
+// given timeout. Every forwarded byte and every new connection resets the timeout.
+func (d *devPodManager) watchIdle(ctx devspacecontext.Context, devPodConfig *latest.DevPod, dp *devPod, timeout time.Duration) {
+	if len(devPodConfig.Ports) == 0 {
+		ctx.Log().Debugf("Dev %s won't be stopped when idle, because it has no port forwarding", devPodConfig.Name)
+		return
+	}
+
+	ctx.Log().Infof("Dev %s will be stopped after %s without forwarded traffic", devPodConfig.Name, timeout)
+	interval := idleCheckInterval
+	if timeout < interval {
+		interval = timeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastActive := time.Now()
+	lastTraffic := trafficTotal(dp.traffic)
+	for {
+		select {
+		case <-ctx.Context().Done():
+			return
+		case <-dp.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := trafficTotal(dp.traffic)
+		if current != lastTraffic {
+			lastTraffic = current
+			lastActive = time.Now()
+			continue
+		}
+
+		idle := time.Since(lastActive)
+		if idle < timeout {
+			continue
+		}
+
+		ctx.Log().Warnf("Stopping dev %s, because it didn't forward any traffic for %s", devPodConfig.Name, idle.Round(time.Second))
+		if !d.stopIdle(ctx, devPodConfig.Name, dp) {
+			return
+		}
+
+		hook.LogExecuteHooks(ctx, map[string]interface{}{
+			"dev_pod_name": devPodConfig.Name,
+			"idle":         idle.Round(time.Second).String(),
+			"summary":      fmt.Sprintf("Stopped dev %s, because it didn't forward any traffic for %s", devPodConfig.Name, idle.Round(time.Second)),
+		}, hook.EventsForSingle("idle:dev", devPodConfig.Name).With("dev.idle")...)
+		return
+	}
+}
+
+// stopIdle stops the dev pod if it wasn't replaced in the meantime and returns if it was stopped
+func (d *devPodManager) stopIdle(ctx devspacecontext.Context, name string, dp *devPod) bool {
+	lock := d.lockFactory.GetLock(name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	d.m.Lock()
+	current := d.devPods[name]
+	d.m.Unlock()
+	if current != dp {
+		return false
+	}
+
+	d.stop(ctx, name, false)
+	return true
+}
+
+// trafficTotal sums up the forwarded bytes and the served connections
+func trafficTotal(traffic *portforward.Traffic) int64 {
+	if traffic == nil {
+		return 0
+	}
+
+	return traffic.Sent() + traffic.Received() + traffic.Connections()
+}
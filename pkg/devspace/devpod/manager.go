@@ -2,16 +2,23 @@ package devpod
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
 	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
 	"github.com/loft-sh/devspace/pkg/devspace/context/values"
 	"github.com/loft-sh/devspace/pkg/devspace/deploy"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
 	"github.com/loft-sh/devspace/pkg/devspace/services/podreplace"
+	"github.com/loft-sh/devspace/pkg/util/backoff"
 	"github.com/loft-sh/devspace/pkg/util/lockfactory"
 	logpkg "github.com/loft-sh/devspace/pkg/util/log"
 	"github.com/loft-sh/devspace/pkg/util/stringutil"
+	"github.com/sirupsen/logrus"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
@@ -22,8 +29,51 @@ type Options struct {
 	DisablePortForwarding bool `long:"disable-port-forwarding" description:"If enabled will not start any port forwarding configuration"`
 	DisablePodReplace     bool `long:"disable-pod-replace" description:"If enabled will not replace any pods"`
 	DisableOpen           bool `long:"disable-open" description:"If enabled will not replace any pods"`
+
+	MaxRestartAttempts        int      `long:"max-restart-attempts" description:"The maximum number of attempts to restart a dev pod that lost connection. 0 means unlimited"`
+	QuietReconnect            bool     `long:"quiet-reconnect" description:"If enabled will only print the first of repeated reconnect messages, all others are printed at debug level"`
+	DedupLogs                 bool     `long:"dedup-logs" description:"If enabled will collapse identical consecutive log messages of a dev pod into a single line"`
+	RetryReadyTimeout         bool     `long:"retry-ready-timeout" description:"If enabled will keep retrying to start the port forwarding of slow starting pods instead of failing after the ready timeout"`
+	HeartbeatInterval         int      `long:"heartbeat-interval" description:"If set, prints a one line status of each dev pod every given seconds"`
+	LogReplayBufferSize       int      `long:"log-replay-buffer-size" description:"The number of recent log messages of each dev pod that are replayed to log sinks attached later. Defaults to 500"`
+	PortForwardingInitTimeout int      `long:"port-forwarding-init-timeout" description:"The seconds to wait for the port forwarding of a dev pod to start. Defaults to 300 or no timeout if retry-ready-timeout is enabled, a negative value waits indefinitely"`
+	OnlyPorts                 []string `long:"only-ports" description:"If set will only start the port forwardings and reverse port forwardings with one of the given names. Defaults to the comma separated names of DEVSPACE_ONLY_PORTS"`
+	ReconnectGracePeriod      int      `long:"reconnect-grace-period" description:"The milliseconds to wait before the first attempt to restart a port forwarding after an error. Defaults to 500, a negative value restarts immediately"`
+	PortForwardingSummary     bool     `long:"port-forwarding-summary" description:"If enabled will print the served connections, the forwarded bytes, the uptime and the reconnects of a port forwarding when it stops"`
+	IdleTimeout               int      `long:"idle-timeout" description:"If set, stops a dev pod after its port forwarding didn't forward any traffic for the given seconds. Every forwarded byte and every new connection resets the timeout"`
+	PreStopTimeout            int      `long:"pre-stop-timeout" description:"The maximum seconds to wait for the preStop:dev hooks of a dev pod before it is stopped anyway. Defaults to 30"`
+	FileLogOnStop             string   `long:"file-log-on-stop" description:"What happens to the log file of a dev pod when it is stopped, either keep, archive to rename it with a timestamp or delete. Defaults to keep"`
+	RestartBackoff            []string `long:"restart-backoff" description:"The delay between failed restart attempts of dev pods and port forwardings per error class as class=initial[:max[:factor]], e.g. permission=10s:10m:2. The classes are default, connectionLost, selector and permission. Unset classes inherit default, which waits 10 seconds for dev pods and 15 seconds for port forwardings, permission doubles the delay up to 5 minutes"`
+
+	// LocalListener creates the local listeners of the port forwarding instead of net.Listen,
+	// e.g. to listen within a specific network namespace. It can only be set programmatically.
+	LocalListener portforward.ListenFunc
+
+	// restartBackoff is the parsed RestartBackoff
+	restartBackoff backoff.Config
+
+	// scope is the id of the manager that started the dev pod, the file logs are scoped by it
+	scope string
 }
 
+// GiveUpCallback is called with the dev pod name and the last error when
+// DevSpace stops trying to restart a dev pod that lost connection
+type GiveUpCallback func(name string, err error)
+
+// ReconnectTarget is the part of a dev pod that reconnected
+type ReconnectTarget string
+
+const (
+	// ReconnectDevPod means the whole dev pod was restarted
+	ReconnectDevPod ReconnectTarget = "devPod"
+	// ReconnectPortForwarding means only the port forwarding of the dev pod was restarted
+	ReconnectPortForwarding ReconnectTarget = "portForwarding"
+)
+
+// ReconnectCallback is called with the dev pod name and what reconnected every time
+// a dev pod or its port forwarding was restarted successfully, e.g. after it lost connection
+type ReconnectCallback func(name string, target ReconnectTarget)
+
 type Manager interface {
 	// StartMultiple will start multiple or all dev pods
 	StartMultiple(ctx devspacecontext.Context, devPods []string, options Options) error
@@ -34,26 +84,98 @@ type Manager interface {
 	// Stop will stop a specific DevPod
 	Stop(ctx devspacecontext.Context, name string)
 
+	// Restart will stop a specific DevPod and start it again with the config
+	// from the context and the options it was started with
+	Restart(ctx devspacecontext.Context, name string) error
+
 	// List lists the currently active dev pods
 	List() []string
 
+	// Status returns the status of the currently active dev pods
+	Status() []Status
+
+	// Debug returns the tombs of the dev pods by name with their state and the goroutines
+	// that didn't return yet, which helps to find out which dev pod blocks a shutdown
+	Debug() map[string][]TombStatus
+
+	// Traffic returns the traffic forwarded by all running dev pods. It only reads
+	// counters and is cheap enough to be called every second.
+	Traffic() TrafficSummary
+
+	// Errors returns the recent errors of the running dev pod with the given name and its port
+	// forwarding, the oldest first. Only the last 20 errors are kept.
+	Errors(name string) []ErrorRecord
+
+	// StatusAll returns the status of all dev pods defined in the given config,
+	// including the ones that were not started yet
+	StatusAll(config *latest.Config) []Status
+
+	// ReloadPortForwarding updates the port forwarding of the running dev pods
+	// to match the given config without restarting them
+	ReloadPortForwarding(config *latest.Config) error
+
+	// RestartForwarding restarts only the port forwarding and reverse port forwarding
+	// of the running dev pod with the given name
+	RestartForwarding(name string) error
+
+	// AddLogSink attaches the sink to the logs of the running dev pod with the given name.
+	// The recent log messages of the dev pod are replayed to the sink first.
+	AddLogSink(name string, sink logpkg.Logger) error
+
+	// SetLogPrefix changes the log prefix of the running dev pod with the given name, e.g. to
+	// show the pod it is connected to. The prefix keeps its color and an empty prefix restores
+	// the default one. The prefix is reset when the dev pod is started again.
+	SetLogPrefix(name, prefix string) error
+
+	// PauseFileLog stops writing the logs of the running dev pod with the given name to
+	// its log file, the logs are still printed to the console
+	PauseFileLog(name string) error
+
+	// ResumeFileLog continues writing the logs of the running dev pod with the given
+	// name to its log file
+	ResumeFileLog(name string) error
+
+	// OnGiveUp registers a callback that is called when a dev pod is not
+	// restarted anymore after it lost connection
+	OnGiveUp(callback GiveUpCallback)
+
+	// OnReconnect registers a callback that is called every time a dev pod or
+	// its port forwarding reconnected successfully
+	OnReconnect(callback ReconnectCallback)
+
 	// Close will close the manager and wait for all dev pods to stop
 	Close()
 
 	// Wait will wait until all DevPods are stopped
 	Wait() error
+
+	// WaitWithTimeout will wait until all DevPods are stopped or the timeout is reached.
+	// A timeout of 0 waits indefinitely.
+	WaitWithTimeout(timeout time.Duration) error
 }
 
 type devPodManager struct {
+	id          string
 	lockFactory lockfactory.LockFactory
 
-	m       sync.Mutex
-	cancels []context.CancelFunc
-	devPods map[string]*devPod
+	m        sync.Mutex
+	cancels  []context.CancelFunc
+	devPods  map[string]*devPod
+	onGiveUp []GiveUpCallback
+
+	onReconnect []ReconnectCallback
 }
 
 func NewManager(cancel context.CancelFunc) Manager {
+	return NewManagerWithID(cancel, "")
+}
+
+// NewManagerWithID creates a new manager whose dev pods write their file logs
+// scoped by the given id. This allows running multiple managers in the same
+// process without dev pods with the same name sharing a log file.
+func NewManagerWithID(cancel context.CancelFunc, id string) Manager {
 	return &devPodManager{
+		id:          id,
 		cancels:     []context.CancelFunc{cancel},
 		lockFactory: lockfactory.NewDefaultLockFactory(),
 		devPods:     map[string]*devPod{},
@@ -72,6 +194,230 @@ func (d *devPodManager) List() []string {
 	return retArr
 }
 
+func (d *devPodManager) OnGiveUp(callback GiveUpCallback) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.onGiveUp = append(d.onGiveUp, callback)
+}
+
+func (d *devPodManager) giveUp(name string, err error) {
+	d.m.Lock()
+	callbacks := append([]GiveUpCallback{}, d.onGiveUp...)
+	d.m.Unlock()
+
+	for _, callback := range callbacks {
+		callback(name, err)
+	}
+}
+
+func (d *devPodManager) OnReconnect(callback ReconnectCallback) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	d.onReconnect = append(d.onReconnect, callback)
+}
+
+func (d *devPodManager) reconnect(name string, target ReconnectTarget) {
+	d.m.Lock()
+	callbacks := append([]ReconnectCallback{}, d.onReconnect...)
+	d.m.Unlock()
+
+	for _, callback := range callbacks {
+		callback(name, target)
+	}
+}
+
+func (d *devPodManager) Status() []Status {
+	retArr := []Status{}
+	for _, dp := range d.copyDevPods() {
+		retArr = append(retArr, dp.Status())
+	}
+
+	return retArr
+}
+
+func (d *devPodManager) Debug() map[string][]TombStatus {
+	debug := map[string][]TombStatus{}
+	for name, dp := range d.copyDevPods() {
+		debug[name] = dp.Tombs()
+	}
+
+	return debug
+}
+
+// copyDevPods returns a copy of the dev pods, so that they can be queried without
+// holding the manager lock
+func (d *devPodManager) copyDevPods() map[string]*devPod {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	devPods := map[string]*devPod{}
+	for k, v := range d.devPods {
+		devPods[k] = v
+	}
+
+	return devPods
+}
+
+func (d *devPodManager) Traffic() TrafficSummary {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	summary := TrafficSummary{}
+	for _, dp := range d.devPods {
+		summary.add(dp.traffic)
+	}
+
+	return summary
+}
+
+func (d *devPodManager) Errors(name string) []ErrorRecord {
+	d.m.Lock()
+	dp := d.devPods[name]
+	d.m.Unlock()
+	if dp == nil {
+		return nil
+	}
+
+	return dp.history.list()
+}
+
+func (d *devPodManager) StatusAll(config *latest.Config) []Status {
+	devPods := d.copyDevPods()
+	retArr := []Status{}
+	for name := range config.Dev {
+		dp, ok := devPods[name]
+		if !ok {
+			retArr = append(retArr, Status{
+				Name:  name,
+				State: StateNotStarted,
+			})
+			continue
+		}
+
+		status := dp.Status()
+		status.Name = name
+		retArr = append(retArr, status)
+	}
+
+	sort.Slice(retArr, func(i, j int) bool {
+		return retArr[i].Name < retArr[j].Name
+	})
+	return retArr
+}
+
+func (d *devPodManager) ReloadPortForwarding(config *latest.Config) error {
+	devPods := d.copyDevPods()
+	errors := []error{}
+	for name, dp := range devPods {
+		devPodConfig, ok := config.Dev[name]
+		if !ok {
+			continue
+		}
+
+		err := dp.ReloadPortForwarding(devPodConfig)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("reload port forwarding of dev %s: %v", name, err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errors)
+}
+
+func (d *devPodManager) RestartForwarding(name string) error {
+	d.m.Lock()
+	dp := d.devPods[name]
+	d.m.Unlock()
+	if dp == nil {
+		return fmt.Errorf("dev %s is not running", name)
+	}
+
+	err := dp.RestartForwarding()
+	if err != nil {
+		return fmt.Errorf("restart port forwarding of dev %s: %v", name, err)
+	}
+
+	return nil
+}
+
+func (d *devPodManager) AddLogSink(name string, sink logpkg.Logger) error {
+	d.m.Lock()
+	var logger logpkg.Logger
+	var prefix *logpkg.PrefixLabel
+	if dp, ok := d.devPods[name]; ok {
+		logger = dp.logger
+		prefix = dp.prefix
+	}
+	d.m.Unlock()
+	if logger == nil {
+		return fmt.Errorf("dev %s is not running", name)
+	}
+
+	// sinks of the replay logger don't get the dev pod prefix
+	logger.AddSink(sink.WithPrefixLabel(prefix, logpkg.ColorForPrefix(name)))
+	return nil
+}
+
+func (d *devPodManager) SetLogPrefix(name, prefix string) error {
+	d.m.Lock()
+	var label *logpkg.PrefixLabel
+	if dp, ok := d.devPods[name]; ok && dp.logger != nil {
+		label = dp.prefix
+	}
+	d.m.Unlock()
+	if label == nil {
+		return fmt.Errorf("dev %s is not running", name)
+	}
+
+	label.Set(logPrefix(name, prefix))
+	return nil
+}
+
+// logPrefix returns the log prefix of a dev pod, the default one if prefix is empty. A space
+// is appended if necessary, so the prefix isn't glued to the message.
+func logPrefix(name, prefix string) string {
+	if prefix == "" {
+		return "dev:" + name + " "
+	} else if !strings.HasSuffix(prefix, " ") {
+		return prefix + " "
+	}
+
+	return prefix
+}
+
+func (d *devPodManager) PauseFileLog(name string) error {
+	fileLog, err := d.fileLog(name)
+	if err != nil {
+		return err
+	}
+
+	fileLog.Pause()
+	return nil
+}
+
+func (d *devPodManager) ResumeFileLog(name string) error {
+	fileLog, err := d.fileLog(name)
+	if err != nil {
+		return err
+	}
+
+	fileLog.Resume()
+	return nil
+}
+
+func (d *devPodManager) fileLog(name string) (*logpkg.PauseSwitch, error) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	dp, ok := d.devPods[name]
+	if !ok {
+		return nil, fmt.Errorf("dev %s is not running", name)
+	}
+
+	return dp.fileLog, nil
+}
+
 func (d *devPodManager) Close() {
 	d.m.Lock()
 	for _, cancel := range d.cancels {
@@ -83,6 +429,15 @@ func (d *devPodManager) Close() {
 }
 
 func (d *devPodManager) StartMultiple(ctx devspacecontext.Context, devPods []string, options Options) error {
+	if len(devPods) > 0 {
+		unmatched := unmatchedDevPods(ctx.Config().Config().Dev, devPods)
+		if len(unmatched) == len(devPods) {
+			return fmt.Errorf("no dev configuration matched %s, please make sure the dev configuration exists", strings.Join(unmatched, ", "))
+		} else if len(unmatched) > 0 {
+			ctx.Log().Warnf("No dev configuration matched %s, skipping", strings.Join(unmatched, ", "))
+		}
+	}
+
 	devCtx, _ := values.DevContextFrom(ctx.Context())
 	select {
 	case <-devCtx.Done():
@@ -95,7 +450,9 @@ func (d *devPodManager) StartMultiple(ctx devspacecontext.Context, devPods []str
 	d.cancels = append(d.cancels, cancel)
 	d.m.Unlock()
 	ctx = ctx.WithContext(cancelCtx)
+	setColorOrder(ctx)
 
+	started := []string{}
 	initChans := []chan struct{}{}
 	errors := make(chan error, len(ctx.Config().Config().Dev))
 	for devPodName, devPod := range ctx.Config().Config().Dev {
@@ -103,6 +460,7 @@ func (d *devPodManager) StartMultiple(ctx devspacecontext.Context, devPods []str
 			continue
 		}
 
+		started = append(started, devPodName)
 		initChan := make(chan struct{})
 		initChans = append(initChans, initChan)
 		go func(devPod *latest.DevPod) {
@@ -125,17 +483,74 @@ func (d *devPodManager) StartMultiple(ctx devspacecontext.Context, devPods []str
 		case <-initChan:
 		}
 	}
+	if len(aggregatedErrors) > 0 || len(errors) > 0 {
+		return utilerrors.NewAggregate(aggregatedErrors)
+	}
+
+	statuses := []Status{}
+	for _, status := range d.Status() {
+		if stringutil.Contains(started, status.Name) {
+			statuses = append(statuses, status)
+		}
+	}
+	printSummary(ctx.Log(), statuses)
+	return nil
+}
+
+// unmatchedDevPods returns the names of the filter that don't match any configured dev pod
+func unmatchedDevPods(dev map[string]*latest.DevPod, filter []string) []string {
+	unmatched := []string{}
+	for _, name := range filter {
+		if _, ok := dev[name]; !ok {
+			unmatched = append(unmatched, name)
+		}
+	}
+
+	return unmatched
+}
+
+// DevPodAlreadyExists is returned by Start if the dev pod is already running
+type DevPodAlreadyExists struct {
+	// Name is the name of the running dev pod
+	Name string
+
+	// Status is the current status of the running dev pod
+	Status Status
+}
+
+func (e DevPodAlreadyExists) Error() string {
+	details := []string{}
+	if !e.Status.Started.IsZero() {
+		details = append(details, "running for "+time.Since(e.Status.Started).Round(time.Second).String())
+	}
+	if e.Status.Pod != "" {
+		details = append(details, "pod "+e.Status.Pod)
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("dev pod %s already exists, please make sure to stop the dev pod before rerunning it", e.Name)
+	}
 
-	return utilerrors.NewAggregate(aggregatedErrors)
+	return fmt.Sprintf("dev pod %s already exists (%s), please make sure to stop the dev pod before rerunning it", e.Name, strings.Join(details, ", "))
 }
 
-type DevPodAlreadyExists struct{}
+// WaitTimeoutError is returned by WaitWithTimeout if the dev pods did not stop in time
+type WaitTimeoutError struct {
+	// Pending are the names of the dev pods that were still running
+	Pending []string
+
+	// Tombs are the tombs of the pending dev pods at the time of the timeout
+	Tombs map[string][]TombStatus
+}
 
-func (DevPodAlreadyExists) Error() string {
-	return "dev pod already exists, please make sure to stop the dev pod before rerunning it"
+func (e WaitTimeoutError) Error() string {
+	return fmt.Sprintf("timed out waiting for dev pods to stop: %s", strings.Join(e.Pending, ", "))
 }
 
 func (d *devPodManager) Wait() error {
+	return d.WaitWithTimeout(0)
+}
+
+func (d *devPodManager) WaitWithTimeout(timeout time.Duration) error {
 	devPods := map[string]*devPod{}
 	d.m.Lock()
 	for k, v := range d.devPods {
@@ -143,9 +558,32 @@ func (d *devPodManager) Wait() error {
 	}
 	d.m.Unlock()
 
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
 	errors := []error{}
 	for _, dp := range devPods {
-		<-dp.Done()
+		select {
+		case <-dp.Done():
+		case <-deadline:
+			pending := []string{}
+			tombs := map[string][]TombStatus{}
+			for name, dp := range devPods {
+				select {
+				case <-dp.Done():
+				default:
+					pending = append(pending, name)
+					tombs[name] = dp.Tombs()
+				}
+			}
+
+			sort.Strings(pending)
+			return WaitTimeoutError{Pending: pending, Tombs: tombs}
+		}
 
 		err := dp.Err()
 		if err != nil {
@@ -161,6 +599,20 @@ func (d *devPodManager) Start(originalContext devspacecontext.Context, devPodCon
 	lock.Lock()
 	defer lock.Unlock()
 
+	return d.start(originalContext, devPodConfig, options)
+}
+
+func (d *devPodManager) start(originalContext devspacecontext.Context, devPodConfig *latest.DevPod, options Options) (*devPod, error) {
+	restartBackoff, err := backoff.Parse(options.RestartBackoff)
+	if err != nil {
+		return nil, fmt.Errorf("parse restart backoff: %v", err)
+	}
+	options.restartBackoff = restartBackoff
+	options.scope = d.id
+	if !isFileLogPolicy(logpkg.FileLogPolicy(options.FileLogOnStop)) {
+		return nil, fmt.Errorf("invalid file log on stop %s, please use one of %v", options.FileLogOnStop, logpkg.FileLogPolicies)
+	}
+
 	var dp *devPod
 	d.m.Lock()
 	dp = d.devPods[devPodConfig.Name]
@@ -169,24 +621,57 @@ func (d *devPodManager) Start(originalContext devspacecontext.Context, devPodCon
 		case <-dp.Done():
 		default:
 			d.m.Unlock()
-			return nil, DevPodAlreadyExists{}
+			return nil, DevPodAlreadyExists{
+				Name:   devPodConfig.Name,
+				Status: dp.Status(),
+			}
 		}
 	}
 
 	// create a new dev pod
-	dp = newDevPod()
+	dp = newDevPod(d.giveUp, d.reconnect)
 	d.devPods[devPodConfig.Name] = dp
 	d.m.Unlock()
 
 	// create a DevPod logger
-	prefix := "dev:" + devPodConfig.Name + " "
-	unionLogger := originalContext.Log().WithPrefix(prefix).WithSink(logpkg.GetDevPodFileLogger(prefix))
+	prefix := logPrefix(devPodConfig.Name, "")
+	dp.prefix.Set(prefix)
+	consoleLogger := originalContext.Log()
+	if devPodConfig.LogLevels != nil && devPodConfig.LogLevels.Console != "" {
+		consoleLogger = consoleLogger.WithLevel(parseLogLevel(devPodConfig.LogLevels.Console))
+	}
+	unionLogger := logpkg.NewPrefixLabelLoggerWithKey(dp.prefix, devPodConfig.Name, consoleLogger)
+	if devPodConfig.QuietUntilError {
+		unionLogger = logpkg.NewQuietLogger(unionLogger, logpkg.DefaultQuietBufferSize)
+	}
+	if !logpkg.FileLogsDisabled() {
+		fileLogger := logpkg.GetScopedDevPodFileLogger(d.id, prefix)
+		if devPodConfig.LogLevels != nil && devPodConfig.LogLevels.File != "" {
+			// sinks are written independently of the console level
+			fileLogger = fileLogger.WithLevel(parseLogLevel(devPodConfig.LogLevels.File))
+		}
+		if devPodConfig.QuietUntilError {
+			// the quiet logger doesn't prefix its sinks, so the file log is prefixed separately
+			fileLogger = fileLogger.WithPrefixLabel(dp.prefix, "")
+		}
+		unionLogger = unionLogger.WithSink(logpkg.NewPausableLogger(fileLogger, dp.fileLog))
+	}
+	if options.DedupLogs {
+		unionLogger = logpkg.NewDedupLogger(unionLogger, logpkg.DefaultDedupWindow)
+	}
+	unionLogger = logpkg.NewReplayLogger(unionLogger, options.LogReplayBufferSize)
+	d.m.Lock()
+	dp.logger = unionLogger
+	d.m.Unlock()
 
 	// start the dev pod
-	err := dp.Start(originalContext.WithLogger(unionLogger), devPodConfig, options)
+	err = dp.Start(originalContext.WithLogger(unionLogger), devPodConfig, options)
 	if err != nil {
 		return nil, err
 	}
+	if options.IdleTimeout > 0 && !options.DisablePortForwarding {
+		go d.watchIdle(originalContext.WithLogger(unionLogger), devPodConfig, dp, time.Duration(options.IdleTimeout)*time.Second)
+	}
 
 	return dp, nil
 }
@@ -196,14 +681,69 @@ func (d *devPodManager) Reset(ctx devspacecontext.Context, name string, options
 	lock.Lock()
 	defer lock.Unlock()
 
-	d.stop(name)
+	d.stop(ctx, name, false)
 	devPod, ok := ctx.Config().RemoteCache().GetDevPod(name)
-	if ok {
-		_, err := podreplace.NewPodReplacer().RevertReplacePod(ctx, &devPod, options)
+	if !ok {
+		return nil
+	}
+
+	release, err := acquireReset(ctx.Context(), name, ctx.Log())
+	if err != nil {
 		return err
 	}
+	defer release()
 
-	return nil
+	timeout := revertTimeout
+	if options != nil && options.VerifyRevert {
+		timeout += time.Duration(options.VerifyRevertTimeout) * time.Second
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Context(), timeout)
+	defer cancel()
+
+	// the revert runs in the background, so that an unresponsive api server
+	// cannot block the lock of the dev pod forever
+	done := make(chan error, 1)
+	go func() {
+		_, err := podreplace.NewPodReplacer().RevertReplacePod(ctx.WithContext(timeoutCtx), &devPod, options)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && timeoutCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s reverting the replaced pod of dev %s: %v", timeout, name, err)
+		}
+		return err
+	case <-timeoutCtx.Done():
+		if timeoutCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s reverting the replaced pod of dev %s", timeout, name)
+		}
+		return timeoutCtx.Err()
+	}
+}
+
+func (d *devPodManager) Restart(ctx devspacecontext.Context, name string) error {
+	devPodConfig, ok := ctx.Config().Config().Dev[name]
+	if !ok {
+		return fmt.Errorf("dev %s is not defined in the config", name)
+	}
+
+	lock := d.lockFactory.GetLock(name)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// reuse the options the dev pod was started with
+	options := Options{}
+	d.m.Lock()
+	dp := d.devPods[name]
+	d.m.Unlock()
+	if dp != nil {
+		options = dp.Options()
+	}
+
+	d.stop(ctx, name, true)
+	_, err := d.start(ctx, devPodConfig, options)
+	return err
 }
 
 func (d *devPodManager) Stop(ctx devspacecontext.Context, name string) {
@@ -211,10 +751,12 @@ func (d *devPodManager) Stop(ctx devspacecontext.Context, name string) {
 	lock.Lock()
 	defer lock.Unlock()
 
-	d.stop(name)
+	d.stop(ctx, name, false)
 }
 
-func (d *devPodManager) stop(name string) {
+// stop stops the dev pod with the given name. The log file is kept if the dev pod is restarted,
+// otherwise it is archived or deleted as defined by the options of the dev pod.
+func (d *devPodManager) stop(ctx devspacecontext.Context, name string, restart bool) {
 	d.m.Lock()
 	dp := d.devPods[name]
 	d.m.Unlock()
@@ -222,9 +764,43 @@ func (d *devPodManager) stop(name string) {
 		return
 	}
 
+	// give the hooks a chance to clean up before the dev pod is torn down
+	options := dp.Options()
+	preStop(ctx, name, options)
+
 	// stop the dev pod
 	dp.Stop()
 	d.m.Lock()
 	delete(d.devPods, name)
 	d.m.Unlock()
+	if restart || logpkg.FileLogsDisabled() {
+		return
+	}
+
+	err := logpkg.ReleaseScopedDevPodFileLog(d.id, logPrefix(name, ""), logpkg.FileLogPolicy(options.FileLogOnStop))
+	if err != nil {
+		ctx.Log().Warnf("Error releasing the log file of dev %s: %v", name, err)
+	}
+}
+
+func isFileLogPolicy(policy logpkg.FileLogPolicy) bool {
+	if policy == "" {
+		return true
+	}
+	for _, p := range logpkg.FileLogPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLogLevel parses a validated log level of the dev pod config
+func parseLogLevel(level string) logrus.Level {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return logrus.InfoLevel
+	}
+
+	return parsed
 }
@@ -0,0 +1,142 @@
+package devpod
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config"
+	"github.com/loft-sh/devspace/pkg/devspace/config/remotecache"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/context/values"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/devspace/services/portforwarding"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// blockingPodSelector blocks selecting a pod until the context is done
+type blockingPodSelector struct {
+	selecting chan struct{}
+}
+
+func (b *blockingPodSelector) SelectSinglePod(ctx context.Context, client kubectl.Client, log log.Logger) (*corev1.Pod, error) {
+	b.selecting <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newStartMultipleContext(devCtx context.Context, out *bytes.Buffer) devspacecontext.Context {
+	conf := config.NewConfig(nil, nil, &latest.Config{Dev: map[string]*latest.DevPod{
+		"frontend": {Name: "frontend"},
+		"backend":  {Name: "backend"},
+	}}, nil, nil, nil, "")
+	logger := log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat)
+	return devspacecontext.NewContext(values.WithDevContext(context.Background(), devCtx), nil, logger).WithConfig(conf)
+}
+
+func TestStartMultipleNoneMatched(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := newStartMultipleContext(context.Background(), out)
+
+	err := NewManager(nil).StartMultiple(ctx, []string{"fronted", "backed"}, Options{})
+	assert.Error(t, err, "no dev configuration matched fronted, backed, please make sure the dev configuration exists")
+}
+
+func TestStartMultiplePartiallyMatched(t *testing.T) {
+	// the dev context is already done, so nothing is started after the check
+	devCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	out := &bytes.Buffer{}
+	ctx := newStartMultipleContext(devCtx, out)
+
+	err := NewManager(nil).StartMultiple(ctx, []string{"frontend", "backed"}, Options{})
+	assert.Equal(t, err, context.Canceled)
+	assert.Assert(t, strings.Contains(out.String(), "No dev configuration matched backed, skipping"), out.String())
+	assert.Assert(t, !strings.Contains(out.String(), "frontend"), out.String())
+}
+
+func TestDebugWhileForwardIsStarting(t *testing.T) {
+	devPodConfig := &latest.DevPod{Name: "test"}
+	conf := config.NewConfig(nil, nil, &latest.Config{Dev: map[string]*latest.DevPod{"test": devPodConfig}}, nil, nil, nil, "")
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithConfig(conf).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+
+	parent := &tomb.Tomb{}
+	parent.Go(func() error {
+		<-parent.Dying()
+		return nil
+	})
+	selector := &blockingPodSelector{selecting: make(chan struct{}, 1)}
+	forwards, err := portforwarding.StartPortForwarding(ctx, devPodConfig, nil, portforwarding.Options{PodSelector: selector}, parent)
+	assert.NilError(t, err)
+
+	dp := newDevPod(nil, nil)
+	dp.config = devPodConfig
+	dp.forwards = forwards
+	dp.forwardingTomb = parent
+	manager := NewManager(nil).(*devPodManager)
+	manager.devPods["test"] = dp
+
+	// the added port forwarding is stuck selecting the pod
+	reloaded := make(chan struct{})
+	go func() {
+		defer close(reloaded)
+		_ = dp.ReloadPortForwarding(&latest.DevPod{Name: "test", Ports: []*latest.PortMapping{{Port: "8080"}}})
+	}()
+	<-selector.selecting
+
+	done := make(chan map[string][]TombStatus)
+	go func() {
+		done <- manager.Debug()
+	}()
+	select {
+	case debug := <-done:
+		assert.Equal(t, len(debug["test"]), 1)
+		assert.Equal(t, debug["test"][0].Name, "port forwarding")
+	case <-time.After(time.Second * 5):
+		t.Fatal("debug is blocked while a port forwarding is starting")
+	}
+
+	cancel()
+	<-reloaded
+	parent.Kill(nil)
+	_ = parent.Wait()
+}
+
+func TestStopReleasesFileLog(t *testing.T) {
+	oldLogdir := log.Logdir
+	defer func() { log.Logdir = oldLogdir }()
+	log.Logdir = t.TempDir() + "/"
+
+	devPodConfig := &latest.DevPod{Name: "app"}
+	conf := config.NewConfig(nil, nil, &latest.Config{Dev: map[string]*latest.DevPod{"app": devPodConfig}}, nil, remotecache.NewCache("", "test"), nil, "")
+	// the context is already cancelled, so the dev pod stops right after it was started
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.NewStreamLogger(&bytes.Buffer{}, &bytes.Buffer{}, logrus.InfoLevel)).WithConfig(conf).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+
+	manager := NewManagerWithID(nil, "scope").(*devPodManager)
+	_, _ = manager.Start(ctx, devPodConfig, Options{FileLogOnStop: string(log.FileLogDelete)})
+	manager.devPods["app"].logger.Info("message")
+	// the services of the dev pod, e.g. the sync, write to the file logs of the scope as well
+	assert.Equal(t, manager.devPods["app"].Options().scope, "scope")
+	path := filepath.Join(log.Logdir, "dev.scope.dev:app.log")
+	_, err := os.Stat(path)
+	assert.NilError(t, err)
+
+	manager.Stop(ctx, "app")
+	_, err = os.Stat(path)
+	assert.Assert(t, os.IsNotExist(err), "log file of the stopped dev pod was not deleted: %v", err)
+}
@@ -0,0 +1,45 @@
+package devpod
+
+import (
+	"context"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/hook"
+)
+
+// defaultPreStopTimeout is the maximum time to wait for the preStop:dev hooks if no timeout is configured
+const defaultPreStopTimeout = time.Second * 30
+
+// preStop executes the preStop:dev hooks of the dev pod and waits until they are done or the
+// timeout of the options is reached. The hooks run with their own context, because the context
+// of the dev pod is usually cancelled already when DevSpace shuts down.
+func preStop(ctx devspacecontext.Context, name string, options Options) {
+	if ctx == nil {
+		return
+	}
+
+	timeout := defaultPreStopTimeout
+	if options.PreStopTimeout > 0 {
+		timeout = time.Duration(options.PreStopTimeout) * time.Second
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		hook.LogExecuteHooks(ctx.WithContext(timeoutCtx), map[string]interface{}{
+			"dev_pod_name": name,
+			"timeout":      timeout.String(),
+		}, hook.EventsForSingle("preStop:dev", name).With("dev.preStop")...)
+	}()
+
+	select {
+	case <-done:
+	case <-timeoutCtx.Done():
+		ctx.Log().Warnf("PreStop hooks of dev %s didn't finish within %s, stopping it anyway", name, timeout)
+	}
+}
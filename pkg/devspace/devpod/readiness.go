@@ -0,0 +1,72 @@
+package devpod
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/pkg/errors"
+)
+
+var (
+	// defaultReadinessProbeTimeout is the default time to wait for the readiness probe to succeed
+	defaultReadinessProbeTimeout = 2 * time.Minute
+
+	// readinessProbeInterval is the initial wait between two probe attempts, it is doubled
+	// after each failed attempt up to readinessProbeMaxInterval
+	readinessProbeInterval    = time.Second
+	readinessProbeMaxInterval = 10 * time.Second
+)
+
+// waitForReadinessProbe executes the readiness probe command in the selected pod until it
+// succeeds, the timeout is reached or the dev pod is stopped
+func (d *devPod) waitForReadinessProbe(ctx devspacecontext.Context, probe *latest.ReadinessProbe) error {
+	d.m.Lock()
+	selectedPod := d.selectedPod
+	d.m.Unlock()
+	if selectedPod == nil {
+		return errors.New("readiness probe: no pod selected")
+	}
+
+	container := probe.Container
+	if container == "" {
+		container = selectedPod.Container.Name
+	}
+	timeout := defaultReadinessProbeTimeout
+	if probe.Timeout > 0 {
+		timeout = time.Duration(probe.Timeout) * time.Second
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Context(), timeout)
+	defer cancel()
+
+	command := strings.Join(probe.Command, " ")
+	ctx.Log().Infof("Waiting for readiness probe '%s' to succeed...", command)
+	interval := readinessProbeInterval
+	for attempt := 1; ; attempt++ {
+		stdout, stderr, err := ctx.KubeClient().ExecBuffered(timeoutCtx, selectedPod.Pod, container, probe.Command, nil)
+		if err == nil {
+			ctx.Log().Donef("Readiness probe '%s' succeeded after %d attempt(s)", command, attempt)
+			return nil
+		}
+
+		ctx.Log().Debugf("Readiness probe attempt %d failed: %v (stdout: %s, stderr: %s)", attempt, err, strings.TrimSpace(string(stdout)), strings.TrimSpace(string(stderr)))
+		select {
+		case <-timeoutCtx.Done():
+			if ctx.IsDone() {
+				return ctx.Context().Err()
+			}
+
+			ctx.Log().Infof("Readiness probe '%s' failed after %d attempt(s)", command, attempt)
+			return errors.Errorf("readiness probe '%s' didn't succeed within %s: %v", command, timeout, err)
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > readinessProbeMaxInterval {
+			interval = readinessProbeMaxInterval
+		}
+	}
+}
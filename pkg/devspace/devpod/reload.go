@@ -0,0 +1,33 @@
+package devpod
+
+import (
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/watch"
+	logpkg "github.com/loft-sh/devspace/pkg/util/log"
+)
+
+// WatchConfig watches the given config path and reloads the port forwarding of the
+// running dev pods each time the config changes. The returned function stops the watcher.
+func WatchConfig(configPath string, manager Manager, load func() (*latest.Config, error), log logpkg.Logger) (func(), error) {
+	watcher, err := watch.New([]string{configPath}, nil, time.Second, func(changed []string, deleted []string) error {
+		config, err := load()
+		if err != nil {
+			log.Warnf("Error reloading config %s: %v", configPath, err)
+			return nil
+		}
+
+		err = manager.ReloadPortForwarding(config)
+		if err != nil {
+			log.Warnf("Error reloading port forwarding: %v", err)
+		}
+		return nil
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher.Start()
+	return watcher.Stop, nil
+}
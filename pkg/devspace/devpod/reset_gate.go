@@ -0,0 +1,56 @@
+package devpod
+
+import (
+	"context"
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/util/log"
+)
+
+var (
+	resetGateMutex sync.Mutex
+
+	// resetGate limits the number of concurrent resets of all managers, nil means unlimited
+	resetGate chan struct{}
+)
+
+// SetResetConcurrency limits the number of dev pods that are reset at the same time by the
+// managers of this process, so that resetting many dev pods at once doesn't overwhelm the
+// api server with pod reverts. A concurrency of 0 resets all dev pods at the same time, which
+// is the default.
+func SetResetConcurrency(concurrency int) {
+	resetGateMutex.Lock()
+	defer resetGateMutex.Unlock()
+
+	if concurrency <= 0 {
+		resetGate = nil
+		return
+	}
+
+	resetGate = make(chan struct{}, concurrency)
+}
+
+// acquireReset waits until the dev pod may be reset and returns a func that has to be
+// called after the reset is done
+func acquireReset(ctx context.Context, name string, log log.Logger) (func(), error) {
+	resetGateMutex.Lock()
+	gate := resetGate
+	resetGateMutex.Unlock()
+	if gate == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case gate <- struct{}{}:
+		return func() { <-gate }, nil
+	default:
+	}
+
+	log.Debugf("Waiting for other dev pods to be reset before resetting dev %s", name)
+	select {
+	case gate <- struct{}{}:
+		return func() { <-gate }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
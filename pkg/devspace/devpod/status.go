@@ -0,0 +1,306 @@
+package devpod
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/loader"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/loft-sh/devspace/pkg/devspace/services/portforwarding"
+	logpkg "github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+)
+
+// State is the state of a dev pod
+type State string
+
+const (
+	StateNotStarted State = "not-started"
+	StateStarting   State = "starting"
+	StateRunning    State = "running"
+	StateStopped    State = "stopped"
+)
+
+// Status describes the current state of a running dev pod
+type Status struct {
+	// Name is the name of the dev pod
+	Name string
+
+	// State is the state of the dev pod
+	State State
+
+	// Pod is the namespace/name of the pod the dev pod is connected to
+	Pod string
+
+	// Started is the time the dev pod was started
+	Started time.Time
+
+	// Ports are the forwarded and reverse forwarded ports
+	Ports []PortStatus
+
+	// Sync are the synced paths
+	Sync []string
+
+	// BytesSent and BytesReceived are the bytes forwarded to and from the pod
+	BytesSent     int64
+	BytesReceived int64
+
+	// Tombs are the tombs of the dev pod services with their goroutines that didn't return yet
+	Tombs []TombStatus
+}
+
+// TombStatus describes a tomb of a dev pod, e.g. to find out what blocks a shutdown
+type TombStatus struct {
+	// Name is the name of the tomb, e.g. dev or port forwarding
+	Name string
+
+	// State is alive, dying or dead. A tomb that stays dying has goroutines that don't return.
+	State tomb.State
+
+	// Goroutines are the names of the goroutines of the tomb that are still running
+	Goroutines []string
+}
+
+func newTombStatus(name string, t *tomb.Tomb) TombStatus {
+	return TombStatus{
+		Name:       name,
+		State:      t.State(),
+		Goroutines: t.Goroutines(),
+	}
+}
+
+// TrafficSummary is the traffic forwarded by the port forwarding of multiple dev pods
+type TrafficSummary struct {
+	// DevPods is the number of dev pods that were summed up
+	DevPods int
+
+	// BytesSent and BytesReceived are the bytes forwarded to and from the pods
+	BytesSent     int64
+	BytesReceived int64
+
+	// Connections is the number of connections that were forwarded and ActiveConnections
+	// the number of the ones that are still open
+	Connections       int64
+	ActiveConnections int64
+}
+
+// add adds the counters of the traffic to the summary
+func (s *TrafficSummary) add(traffic *portforward.Traffic) {
+	s.DevPods++
+	if traffic == nil {
+		return
+	}
+
+	s.BytesSent += traffic.Sent()
+	s.BytesReceived += traffic.Received()
+	s.Connections += traffic.Connections()
+	s.ActiveConnections += traffic.Active()
+}
+
+func (s TrafficSummary) String() string {
+	return fmt.Sprintf("%d dev pod(s) sent %s, received %s, %d open of %d connection(s)", s.DevPods, portforward.FormatBytes(s.BytesSent), portforward.FormatBytes(s.BytesReceived), s.ActiveConnections, s.Connections)
+}
+
+// Heartbeat returns a one line summary of the status
+func (s Status) Heartbeat() string {
+	ports := 0
+	for _, p := range s.Ports {
+		if !p.Reverse {
+			ports++
+		}
+	}
+
+	return fmt.Sprintf("Dev %s is running on pod %s since %s, forwarding %d port(s), sent %s, received %s", s.Name, s.Pod, time.Since(s.Started).Round(time.Second).String(), ports, portforward.FormatBytes(s.BytesSent), portforward.FormatBytes(s.BytesReceived))
+}
+
+// PortStatus describes a single forwarded port of a dev pod
+type PortStatus struct {
+	Local  int
+	Remote int
+
+	// Name is the optional label of the port mapping
+	Name string
+
+	// Socket is the local unix socket the remote port is forwarded to
+	Socket string
+
+	// Reverse is true if the remote port is forwarded to the local port
+	Reverse bool
+
+	// Pod is the namespace/name of the pod the port is currently forwarded to.
+	// It is empty for reverse forwarded ports and ports that are not forwarded yet.
+	Pod string
+
+	// port is the port of the port mapping
+	port string
+}
+
+func (p PortStatus) String() string {
+	formatted := fmt.Sprintf("%d -> %d", p.Local, p.Remote)
+	if p.Socket != "" {
+		formatted = fmt.Sprintf("%s -> %d", p.Socket, p.Remote)
+	} else if p.Reverse {
+		formatted = fmt.Sprintf("%d <- %d", p.Local, p.Remote)
+	}
+	if p.Name != "" {
+		formatted += " (" + p.Name + ")"
+	}
+
+	return formatted
+}
+
+// Status returns the current status of the dev pod. The port forwarding is queried without
+// holding the dev pod lock, so that a port forwarding that is starting doesn't block it.
+func (d *devPod) Status() Status {
+	d.m.Lock()
+	status := Status{
+		State:   StateStarting,
+		Started: d.started,
+	}
+	if d.traffic != nil {
+		status.BytesSent = d.traffic.Sent()
+		status.BytesReceived = d.traffic.Received()
+	}
+	if d.selectedPod != nil {
+		status.Pod = d.selectedPod.Pod.Namespace + "/" + d.selectedPod.Pod.Name
+		if d.ready {
+			status.State = StateRunning
+		}
+	}
+	select {
+	case <-d.done:
+		status.State = StateStopped
+	default:
+	}
+	t, forwardingTomb, forwards := d.t, d.forwardingTomb, d.forwards
+	config, portMappings := d.config, d.ports
+	d.m.Unlock()
+
+	status.Tombs = tombStatuses(t, forwardingTomb, forwards)
+	if config == nil {
+		return status
+	}
+
+	status.Name = config.Name
+	if forwards != nil {
+		portMappings = forwards.Mappings()
+	}
+	for _, p := range portStatuses(portMappings) {
+		if d.selectedPods != nil {
+			p.Pod = d.selectedPods.Get(p.port)
+		}
+		status.Ports = append(status.Ports, p)
+	}
+	loader.EachDevContainer(config, func(devContainer *latest.DevContainer) bool {
+		status.Ports = append(status.Ports, reversePortStatuses(devContainer.ReversePorts)...)
+		for _, s := range devContainer.Sync {
+			status.Sync = append(status.Sync, s.Path)
+		}
+		return true
+	})
+
+	return status
+}
+
+// Tombs returns the tombs of the running dev pod services
+func (d *devPod) Tombs() []TombStatus {
+	d.m.Lock()
+	t, forwardingTomb, forwards := d.t, d.forwardingTomb, d.forwards
+	d.m.Unlock()
+
+	return tombStatuses(t, forwardingTomb, forwards)
+}
+
+// tombStatuses returns the status of the given dev pod tombs, the ones that are nil are skipped
+func tombStatuses(t, forwardingTomb *tomb.Tomb, forwards *portforwarding.Forwards) []TombStatus {
+	tombs := []TombStatus{}
+	if t != nil {
+		tombs = append(tombs, newTombStatus("dev", t))
+	}
+	if forwardingTomb != nil {
+		tombs = append(tombs, newTombStatus("port forwarding", forwardingTomb))
+	}
+	if forwards != nil {
+		for index, t := range forwards.Tombs() {
+			tombs = append(tombs, newTombStatus(fmt.Sprintf("port forwarding group %d", index), t))
+		}
+	}
+
+	return tombs
+}
+
+func portStatuses(portMappings []*latest.PortMapping) []PortStatus {
+	ports := []PortStatus{}
+	for _, portMapping := range portMappings {
+		port, ok := newPortStatus(portMapping.Port, portMapping.Name, false)
+		if !ok {
+			continue
+		}
+
+		port.Socket = portMapping.Socket
+		ports = append(ports, port)
+	}
+
+	return ports
+}
+
+func reversePortStatuses(portMappings []*latest.ReversePortMapping) []PortStatus {
+	ports := []PortStatus{}
+	for _, portMapping := range portMappings {
+		port, ok := newPortStatus(portMapping.Port, portMapping.Name, true)
+		if ok {
+			ports = append(ports, port)
+		}
+	}
+
+	return ports
+}
+
+// newPortStatus parses the port of a port mapping, false is returned if it is invalid
+func newPortStatus(port, name string, reverse bool) (PortStatus, bool) {
+	mappings, err := portforward.ParsePorts([]string{port})
+	if err != nil {
+		return PortStatus{}, false
+	}
+
+	return PortStatus{
+		Local:   int(mappings[0].Local),
+		Remote:  int(mappings[0].Remote),
+		Name:    name,
+		Reverse: reverse,
+		port:    port,
+	}, true
+}
+
+// printSummary prints a table of the forwarded ports and synced paths of the given dev pods
+func printSummary(log logpkg.Logger, statuses []Status) {
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	values := [][]string{}
+	for _, status := range statuses {
+		if len(status.Ports) == 0 && len(status.Sync) == 0 {
+			continue
+		}
+
+		ports := []string{}
+		for _, p := range status.Ports {
+			ports = append(ports, p.String())
+		}
+
+		values = append(values, []string{
+			status.Name,
+			strings.Join(ports, ", "),
+			strings.Join(status.Sync, ", "),
+		})
+	}
+	if len(values) == 0 {
+		return
+	}
+
+	logpkg.PrintTable(log, []string{"Dev", "Ports", "Sync"}, values)
+}
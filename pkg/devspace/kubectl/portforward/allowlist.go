@@ -0,0 +1,91 @@
+package portforward
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SourceAllowlist are the networks local connections are accepted from. An empty allowlist
+// accepts connections from all sources.
+type SourceAllowlist []*net.IPNet
+
+// ParseSourceAllowlist parses IP addresses and CIDRs, e.g. 127.0.0.1 or 10.0.0.0/8. An IP
+// address stands for a single host.
+func ParseSourceAllowlist(sources []string) (SourceAllowlist, error) {
+	allowlist := SourceAllowlist{}
+	for _, source := range sources {
+		source = strings.TrimSpace(source)
+		if strings.Contains(source, "/") {
+			_, network, err := net.ParseCIDR(source)
+			if err != nil {
+				return nil, errors.Errorf("invalid source %s: not a valid CIDR", source)
+			}
+
+			allowlist = append(allowlist, network)
+			continue
+		}
+
+		ip := net.ParseIP(source)
+		if ip == nil {
+			return nil, errors.Errorf("invalid source %s: not a valid IP address or CIDR", source)
+		}
+
+		bits := net.IPv6len * 8
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = net.IPv4len * 8
+		}
+		allowlist = append(allowlist, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return allowlist, nil
+}
+
+// Allows returns true if connections from the given address are accepted. Addresses that
+// are not IP addresses, e.g. of unix sockets, are only accepted by an empty allowlist.
+func (s SourceAllowlist) Allows(addr net.Addr) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	default:
+		if addr == nil {
+			return false
+		}
+
+		host, _, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return false
+		}
+		ip = net.ParseIP(host)
+	}
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range s {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetSourceAllowlist only accepts local connections to the given remote port from the sources
+// of the allowlist. Connections from other sources are logged and closed right away.
+func (pf *PortForwarder) SetSourceAllowlist(remotePort uint16, allowlist SourceAllowlist) {
+	if pf.allowlists == nil {
+		pf.allowlists = map[uint16]SourceAllowlist{}
+	}
+
+	pf.allowlists[remotePort] = allowlist
+}
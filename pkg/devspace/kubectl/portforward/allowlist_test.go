@@ -0,0 +1,27 @@
+package portforward
+
+import (
+	"net"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSourceAllowlist(t *testing.T) {
+	allowlist, err := ParseSourceAllowlist([]string{"127.0.0.1", "10.0.0.0/8", "::1"})
+	assert.NilError(t, err)
+	assert.Assert(t, allowlist.Allows(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 1234}))
+	assert.Assert(t, allowlist.Allows(&net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 1234}))
+	assert.Assert(t, allowlist.Allows(&net.TCPAddr{IP: net.ParseIP("::1"), Port: 1234}))
+	assert.Assert(t, !allowlist.Allows(&net.TCPAddr{IP: net.ParseIP("127.0.0.2"), Port: 1234}))
+	assert.Assert(t, !allowlist.Allows(&net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 1234}))
+	assert.Assert(t, !allowlist.Allows(&net.UnixAddr{Name: "/tmp/socket", Net: "unix"}))
+
+	// an empty allowlist accepts all sources
+	assert.Assert(t, SourceAllowlist(nil).Allows(&net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 1234}))
+
+	for _, invalid := range []string{"localhost", "10.0.0.0/33", ""} {
+		_, err := ParseSourceAllowlist([]string{invalid})
+		assert.Assert(t, err != nil, "expected an error for %s", invalid)
+	}
+}
@@ -0,0 +1,56 @@
+package portforward
+
+import (
+	"io"
+)
+
+// BufferSizes are the sizes in bytes of the buffers a forwarded connection is copied with. Sizes of 0
+// keep the defaults, which are the socket buffers of the operating system and a 32 KiB copy buffer.
+type BufferSizes struct {
+	// Read is the size of the receive buffer of the local socket and of the buffer the data
+	// sent to the pod is copied with
+	Read int
+
+	// Write is the size of the send buffer of the local socket and of the buffer the data
+	// received from the pod is copied with
+	Write int
+}
+
+// socketBuffers is implemented by connections whose socket buffers can be resized, e.g. *net.TCPConn
+type socketBuffers interface {
+	SetReadBuffer(bytes int) error
+	SetWriteBuffer(bytes int) error
+}
+
+// apply resizes the socket buffers of the local connection, connections that don't
+// support it keep their buffers
+func (b BufferSizes) apply(conn io.ReadWriteCloser) error {
+	socket, ok := conn.(socketBuffers)
+	if !ok {
+		return nil
+	}
+
+	if b.Read > 0 {
+		if err := socket.SetReadBuffer(b.Read); err != nil {
+			return err
+		}
+	}
+	if b.Write > 0 {
+		if err := socket.SetWriteBuffer(b.Write); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyBuffer copies from src to dst with a buffer of the given size or with io.Copy if the size
+// is 0. The reader and the writer are wrapped, because io.CopyBuffer would ignore the buffer
+// if either of them implements io.WriterTo or io.ReaderFrom.
+func copyBuffer(dst io.Writer, src io.Reader, size int) (int64, error) {
+	if size <= 0 {
+		return io.Copy(dst, src)
+	}
+
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{src}, make([]byte, size))
+}
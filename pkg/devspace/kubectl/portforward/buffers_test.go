@@ -0,0 +1,95 @@
+package portforward
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// tcpPair returns both ends of a loopback tcp connection
+func tcpPair(t testing.TB) (net.Conn, net.Conn) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	}()
+
+	client, err := net.Dial("tcp", listener.Addr().String())
+	assert.NilError(t, err)
+	server := <-accepted
+	assert.Assert(t, server != nil)
+	t.Cleanup(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+	return client, server
+}
+
+func TestBufferSizes(t *testing.T) {
+	client, server := tcpPair(t)
+	assert.NilError(t, BufferSizes{Read: 1024 * 1024, Write: 1024 * 1024}.apply(server))
+	pipe, _ := net.Pipe()
+	defer pipe.Close()
+	assert.NilError(t, BufferSizes{Read: 1024}.apply(pipe), "connections without socket buffers should keep their buffers")
+
+	data := bytes.Repeat([]byte("devspace"), 1024*64)
+	go func() {
+		_, _ = client.Write(data)
+		_ = client.Close()
+	}()
+
+	out := &bytes.Buffer{}
+	n, err := copyBuffer(out, server, 1024*256)
+	assert.NilError(t, err)
+	assert.Equal(t, n, int64(len(data)))
+	assert.Assert(t, bytes.Equal(out.Bytes(), data))
+}
+
+// BenchmarkCopyBuffer measures the throughput of copying a large transfer from a local
+// connection to another connection, like the copy loop of a forwarded connection
+func BenchmarkCopyBuffer(b *testing.B) {
+	const transfer = 64 * 1024 * 1024
+	chunk := make([]byte, 1024*1024)
+	for _, sizes := range []BufferSizes{{}, {Read: 256 * 1024}, {Read: 1024 * 1024}} {
+		name := "default"
+		if sizes.Read > 0 {
+			name = FormatBytes(int64(sizes.Read))
+		}
+
+		b.Run(fmt.Sprintf("buffer=%s", name), func(b *testing.B) {
+			b.SetBytes(transfer)
+			for i := 0; i < b.N; i++ {
+				client, server := tcpPair(b)
+				assert.NilError(b, sizes.apply(server))
+				go func() {
+					for written := 0; written < transfer; written += len(chunk) {
+						if _, err := client.Write(chunk); err != nil {
+							return
+						}
+					}
+					_ = client.Close()
+				}()
+
+				// the remote side is another connection, whose writes cost a syscall each like the
+				// frames of the stream to the pod
+				remote, remoteServer := tcpPair(b)
+				go func() {
+					_, _ = io.Copy(io.Discard, remoteServer)
+				}()
+
+				dst := &countingWriter{Writer: remote, traffic: &Traffic{}, field: sentField}
+				n, err := copyBuffer(dst, server, sizes.Read)
+				assert.NilError(b, err)
+				assert.Equal(b, n, int64(transfer))
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package portforward
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/util/log"
+)
+
+// tapBufferSize is the number of chunks a tap stream buffers before it gives up parsing
+const tapBufferSize = 64
+
+// httpTap logs the HTTP/1 requests and responses of a forwarded connection. The traffic is
+// parsed on a best-effort basis from copies of the forwarded data, so the forwarding itself is
+// never blocked or changed. The tap stops parsing as soon as the traffic is not HTTP/1 anymore,
+// e.g. for other protocols or upgraded connections, or the parser cannot keep up.
+type httpTap struct {
+	log log.Logger
+
+	request  *tapStream
+	response *tapStream
+	requests chan tappedRequest
+}
+
+type tappedRequest struct {
+	method  string
+	uri     string
+	started time.Time
+}
+
+func newHTTPTap(logger log.Logger) *httpTap {
+	h := &httpTap{
+		log:      logger,
+		request:  newTapStream(),
+		response: newTapStream(),
+		requests: make(chan tappedRequest, tapBufferSize),
+	}
+
+	go h.parseRequests()
+	go h.parseResponses()
+	return h
+}
+
+func (h *httpTap) parseRequests() {
+	defer close(h.requests)
+	defer h.request.close()
+
+	reader := bufio.NewReader(h.request)
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+
+		started := time.Now()
+		select {
+		case h.requests <- tappedRequest{method: req.Method, uri: req.RequestURI, started: started}:
+		default:
+			return
+		}
+
+		_, err = io.Copy(io.Discard, req.Body)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (h *httpTap) parseResponses() {
+	defer h.response.close()
+
+	reader := bufio.NewReader(h.response)
+	for {
+		// wait for the response before waiting for the request, so servers that
+		// send data first don't block the parser
+		_, err := reader.Peek(1)
+		if err != nil {
+			return
+		}
+
+		req, ok := <-h.requests
+		if !ok {
+			return
+		}
+
+		resp, err := http.ReadResponse(reader, &http.Request{Method: req.method})
+		for err == nil && resp.StatusCode >= 100 && resp.StatusCode < 200 && resp.StatusCode != http.StatusSwitchingProtocols {
+			// informational responses are followed by the actual response
+			resp, err = http.ReadResponse(reader, &http.Request{Method: req.method})
+		}
+		if err != nil {
+			return
+		}
+
+		h.log.Infof("%s %s -> %s (%s)", req.method, req.uri, resp.Status, time.Since(req.started).Round(time.Millisecond))
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			return
+		}
+
+		_, err = io.Copy(io.Discard, resp.Body)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// close stops parsing the connection
+func (h *httpTap) close() {
+	h.request.close()
+	h.response.close()
+}
+
+// tapStream receives copies of the forwarded data and provides them to the parser.
+// Writes never block, if the parser doesn't keep up the stream is closed instead.
+type tapStream struct {
+	m      sync.Mutex
+	chunks chan []byte
+	closed bool
+
+	buffer []byte
+}
+
+func newTapStream() *tapStream {
+	return &tapStream{
+		chunks: make(chan []byte, tapBufferSize),
+	}
+}
+
+func (t *tapStream) Write(p []byte) (int, error) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.closed {
+		return len(p), nil
+	}
+
+	select {
+	case t.chunks <- append([]byte{}, p...):
+	default:
+		t.closed = true
+		close(t.chunks)
+	}
+	return len(p), nil
+}
+
+func (t *tapStream) Read(p []byte) (int, error) {
+	if len(t.buffer) == 0 {
+		chunk, ok := <-t.chunks
+		if !ok {
+			return 0, io.EOF
+		}
+
+		t.buffer = chunk
+	}
+
+	n := copy(p, t.buffer)
+	t.buffer = t.buffer[n:]
+	return n, nil
+}
+
+func (t *tapStream) close() {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if !t.closed {
+		t.closed = true
+		close(t.chunks)
+	}
+}
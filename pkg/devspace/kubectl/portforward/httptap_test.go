@@ -0,0 +1,68 @@
+package portforward
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+type syncBuffer struct {
+	m sync.Mutex
+	b bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.b.String()
+}
+
+func waitForOutput(out *syncBuffer, lines int) string {
+	deadline := time.Now().Add(5 * time.Second)
+	for strings.Count(out.String(), "\n") < lines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return out.String()
+}
+
+func TestHTTPTap(t *testing.T) {
+	out := &syncBuffer{}
+	tap := newHTTPTap(log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat))
+
+	// requests on a keep alive connection, written in arbitrary chunks
+	_, _ = tap.request.Write([]byte("POST /api/items HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\n\r\nhel"))
+	_, _ = tap.request.Write([]byte("loGET /health HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	_, _ = tap.response.Write([]byte("HTTP/1.1 201 Created\r\nContent-Length: 2\r\n\r\nok"))
+	_, _ = tap.response.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"))
+
+	output := waitForOutput(out, 2)
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, len(lines), 2, output)
+	assert.Assert(t, strings.HasPrefix(lines[0], "POST /api/items -> 201 Created ("), output)
+	assert.Assert(t, strings.HasPrefix(lines[1], "GET /health -> 200 OK ("), output)
+	tap.close()
+
+	// other protocols are not logged
+	out = &syncBuffer{}
+	tap = newHTTPTap(log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat))
+	_, _ = tap.request.Write([]byte("\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03"))
+	_, _ = tap.response.Write([]byte("SSH-2.0-OpenSSH_8.9\r\n"))
+	tap.close()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, out.String(), "")
+}
@@ -0,0 +1,33 @@
+package portforward
+
+import "sync/atomic"
+
+// oneShot lets a port forwarder forward a single local connection only
+type oneShot struct {
+	// taken is 1 after the connection was accepted
+	taken int32
+	done  chan struct{}
+}
+
+// SetOneShot makes the port forwarder forward only the first local connection, e.g. to trigger a
+// webhook once. The local listeners are closed as soon as the connection is accepted and ForwardPorts
+// returns after it is finished. The returned channel is closed when the connection is finished.
+func (pf *PortForwarder) SetOneShot() <-chan struct{} {
+	pf.oneShot = &oneShot{done: make(chan struct{})}
+	return pf.oneShot.done
+}
+
+// oneShotDone returns the channel that is closed after the connection of a one-shot port
+// forwarder is finished, nil blocks forever for other port forwarders
+func (pf *PortForwarder) oneShotDone() <-chan struct{} {
+	if pf.oneShot == nil {
+		return nil
+	}
+
+	return pf.oneShot.done
+}
+
+// take returns true for the first connection and false for all later ones
+func (o *oneShot) take() bool {
+	return atomic.CompareAndSwapInt32(&o.taken, 0, 1)
+}
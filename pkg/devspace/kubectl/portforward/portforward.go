@@ -18,19 +18,23 @@ package portforward
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
 	"io"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/httpstream"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"net"
 	"net/http"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // PortForwardProtocolV1Name is the subprotocol used for port forwarding.
@@ -56,6 +60,158 @@ type PortForwarder struct {
 	errOut        io.Writer
 
 	log log.Logger
+
+	// connLog is the logger connection lifecycle events are logged to
+	// at debug level
+	connLog log.Logger
+
+	// traffic counts the bytes forwarded through all connections
+	traffic *Traffic
+
+	// listen creates the local listeners, defaults to net.Listen
+	listen ListenFunc
+
+	// active is the number of connections that are currently forwarded
+	active int64
+
+	// httpLogs are the loggers the HTTP requests of remote ports are logged to
+	httpLogs map[uint16]log.Logger
+
+	// limits are the maximum numbers of concurrent connections of remote ports
+	limits map[uint16]*connectionLimit
+
+	// buffers are the buffer sizes the connections of remote ports are copied with
+	buffers map[uint16]BufferSizes
+
+	// tlsConfigs are the configs TLS is originated with to remote ports
+	tlsConfigs map[uint16]*tls.Config
+
+	// allowlists are the sources local connections to remote ports are accepted from
+	allowlists map[uint16]SourceAllowlist
+
+	// oneShot is set if only a single connection should be forwarded
+	oneShot *oneShot
+}
+
+// connectionLimit limits the number of concurrent connections of a port
+type connectionLimit struct {
+	max    int64
+	active int64
+
+	// refusing is 1 while new connections are refused
+	refusing int32
+}
+
+// acquire returns true if another connection is allowed
+func (c *connectionLimit) acquire() bool {
+	if atomic.AddInt64(&c.active, 1) > c.max {
+		atomic.AddInt64(&c.active, -1)
+		return false
+	}
+
+	return true
+}
+
+func (c *connectionLimit) release() {
+	atomic.AddInt64(&c.active, -1)
+}
+
+// ListenFunc creates a local listener on the given network and address in net.Listen style
+type ListenFunc func(network, address string) (net.Listener, error)
+
+// Traffic counts the bytes and connections that were forwarded. It is safe for concurrent
+// use and can be shared by multiple port forwarders.
+type Traffic struct {
+	sent        int64
+	received    int64
+	connections int64
+	active      int64
+
+	// parent is also counted if set
+	parent *Traffic
+}
+
+// NewTraffic returns a counter that also adds everything it counts to the parent, if it is not nil
+func NewTraffic(parent *Traffic) *Traffic {
+	return &Traffic{parent: parent}
+}
+
+// Sent returns the number of bytes sent from the local ports to the pod
+func (t *Traffic) Sent() int64 {
+	return atomic.LoadInt64(&t.sent)
+}
+
+// Received returns the number of bytes received from the pod
+func (t *Traffic) Received() int64 {
+	return atomic.LoadInt64(&t.received)
+}
+
+// Connections returns the number of connections that were forwarded to the pod
+func (t *Traffic) Connections() int64 {
+	return atomic.LoadInt64(&t.connections)
+}
+
+// Active returns the number of connections that are currently forwarded to the pod
+func (t *Traffic) Active() int64 {
+	return atomic.LoadInt64(&t.active)
+}
+
+// add adds n to the counter selected by field of the traffic and all its parents
+func (t *Traffic) add(field func(t *Traffic) *int64, n int64) {
+	for ; t != nil; t = t.parent {
+		atomic.AddInt64(field(t), n)
+	}
+}
+
+func sentField(t *Traffic) *int64        { return &t.sent }
+func receivedField(t *Traffic) *int64    { return &t.received }
+func connectionsField(t *Traffic) *int64 { return &t.connections }
+func activeField(t *Traffic) *int64      { return &t.active }
+
+// FormatBytes formats the given number of bytes human readable
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// countingWriter adds the number of written bytes to a counter of the traffic
+type countingWriter struct {
+	io.Writer
+
+	traffic *Traffic
+	field   func(t *Traffic) *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	c.traffic.add(c.field, int64(n))
+	return n, err
+}
+
+// BindPermissionError is returned if the port forwarder is not permitted to
+// listen on a local address, e.g. because the port is privileged
+type BindPermissionError struct {
+	Address string
+	Port    uint16
+	Err     error
+}
+
+func (e *BindPermissionError) Error() string {
+	return fmt.Sprintf("permission denied to listen on %s: please use a local port above 1023 or run DevSpace with the required privileges", net.JoinHostPort(e.Address, strconv.Itoa(int(e.Port))))
+}
+
+func (e *BindPermissionError) Unwrap() error {
+	return e.Err
 }
 
 // ForwardedPort contains a Local:Remote port pairing.
@@ -116,6 +272,29 @@ func ParsePorts(ports []string) ([]ForwardedPort, error) {
 	return forwards, nil
 }
 
+// ParseNamedPort parses a port specification with a named remote port, e.g. 8888:http or :http,
+// and returns the local port and the name of the remote port. ok is false if the specification
+// doesn't have a named remote port.
+func ParseNamedPort(port string) (uint16, string, bool) {
+	parts := strings.Split(port, ":")
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", false
+	} else if _, err := strconv.ParseUint(parts[1], 10, 16); err == nil {
+		return 0, "", false
+	}
+
+	localString := parts[0]
+	if localString == "" {
+		localString = "0"
+	}
+	localPort, err := strconv.ParseUint(localString, 10, 16)
+	if err != nil {
+		return 0, "", false
+	}
+
+	return uint16(localPort), parts[1], true
+}
+
 type listenAddress struct {
 	address     string
 	protocol    string
@@ -189,10 +368,66 @@ func NewOnAddresses(dialer httpstream.Dialer, addresses []string, ports []string
 	}, nil
 }
 
+// SetConnectionLogger sets the logger the lifecycle of each forwarded connection
+// is logged to. Connections are only logged if the logger has debug level.
+func (pf *PortForwarder) SetConnectionLogger(logger log.Logger) {
+	pf.connLog = logger
+}
+
+// SetTrafficCounter sets the counter the bytes forwarded through all connections are added to
+func (pf *PortForwarder) SetTrafficCounter(traffic *Traffic) {
+	pf.traffic = traffic
+}
+
+// SetListenFunc sets the function the local listeners are created with. This allows
+// to listen within a specific network namespace or on a specific interface.
+func (pf *PortForwarder) SetListenFunc(listen ListenFunc) {
+	pf.listen = listen
+}
+
+// SetHTTPLogger logs the HTTP requests forwarded to the given remote port to the logger.
+// The traffic is parsed on a best-effort basis, connections that are not HTTP/1 are
+// forwarded without being logged.
+func (pf *PortForwarder) SetHTTPLogger(remotePort uint16, logger log.Logger) {
+	if pf.httpLogs == nil {
+		pf.httpLogs = map[uint16]log.Logger{}
+	}
+
+	pf.httpLogs[remotePort] = logger
+}
+
+// SetConnectionLimit limits the number of concurrent connections forwarded to the given
+// remote port. New local connections beyond the limit are closed right away.
+func (pf *PortForwarder) SetConnectionLimit(remotePort uint16, max int) {
+	if pf.limits == nil {
+		pf.limits = map[uint16]*connectionLimit{}
+	}
+
+	pf.limits[remotePort] = &connectionLimit{max: int64(max)}
+}
+
+// SetBufferSizes sets the sizes of the socket and copy buffers of the connections forwarded to
+// the given remote port, e.g. to increase the throughput of large transfers
+func (pf *PortForwarder) SetBufferSizes(remotePort uint16, sizes BufferSizes) {
+	if pf.buffers == nil {
+		pf.buffers = map[uint16]BufferSizes{}
+	}
+
+	pf.buffers[remotePort] = sizes
+}
+
+// ActiveConnections returns the number of connections that are currently forwarded
+func (pf *PortForwarder) ActiveConnections() int {
+	return int(atomic.LoadInt64(&pf.active))
+}
+
 func (pf *PortForwarder) raiseError(err error) {
 	go func() {
 		if pf.errChan != nil {
-			pf.errChan <- err
+			select {
+			case pf.errChan <- err:
+			case <-pf.stopChan:
+			}
 		}
 	}()
 
@@ -236,6 +471,13 @@ func (pf *PortForwarder) forward() error {
 	for i := range pf.ports {
 		port := &pf.ports[i]
 		err = pf.listenOnPort(port)
+
+		// missing permissions won't go away by retrying
+		var permissionErr *BindPermissionError
+		if errors.As(err, &permissionErr) {
+			return permissionErr
+		}
+
 		switch {
 		case err == nil:
 			listenSuccess = true
@@ -257,6 +499,7 @@ func (pf *PortForwarder) forward() error {
 	// wait for interrupt or conn closure
 	select {
 	case <-pf.stopChan:
+	case <-pf.oneShotDone():
 	case <-pf.streamConn.CloseChan():
 		pf.raiseError(errors.New("lost connection to pod"))
 	}
@@ -267,23 +510,35 @@ func (pf *PortForwarder) forward() error {
 // listenOnPort delegates listener creation and waits for connections on requested bind addresses.
 // An error is raised based on address groups (default and localhost) and their failure modes
 func (pf *PortForwarder) listenOnPort(port *ForwardedPort) error {
-	var errors []error
+	var errs []error
 	failCounters := make(map[string]int, 2)
 	successCounters := make(map[string]int, 2)
 	for _, addr := range pf.addresses {
 		err := pf.listenOnPortAndAddress(port, addr.protocol, addr.address)
 		if err != nil {
-			errors = append(errors, err)
+			var permissionErr *BindPermissionError
+			if errors.As(err, &permissionErr) && addr.failureMode == "any" {
+				return permissionErr
+			}
+
+			errs = append(errs, err)
 			failCounters[addr.failureMode]++
 		} else {
 			successCounters[addr.failureMode]++
 		}
 	}
 	if successCounters["all"] == 0 && failCounters["all"] > 0 {
-		return fmt.Errorf("%s: %v", "Listeners failed to create with the following errors", errors)
+		for _, err := range errs {
+			var permissionErr *BindPermissionError
+			if errors.As(err, &permissionErr) {
+				return permissionErr
+			}
+		}
+
+		return fmt.Errorf("%s: %v", "Listeners failed to create with the following errors", errs)
 	}
 	if failCounters["any"] > 0 {
-		return fmt.Errorf("%s: %v", "Listeners failed to create with the following errors", errors)
+		return fmt.Errorf("%s: %v", "Listeners failed to create with the following errors", errs)
 	}
 	return nil
 }
@@ -303,8 +558,17 @@ func (pf *PortForwarder) listenOnPortAndAddress(port *ForwardedPort, protocol st
 // getListener creates a listener on the interface targeted by the given hostname on the given port with
 // the given protocol. protocol is in net.Listen style which basically admits values like tcp, tcp4, tcp6
 func (pf *PortForwarder) getListener(protocol string, hostname string, port *ForwardedPort) (net.Listener, error) {
-	listener, err := net.Listen(protocol, net.JoinHostPort(hostname, strconv.Itoa(int(port.Local))))
+	listen := pf.listen
+	if listen == nil {
+		listen = net.Listen
+	}
+
+	listener, err := listen(protocol, net.JoinHostPort(hostname, strconv.Itoa(int(port.Local))))
 	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return nil, &BindPermissionError{Address: hostname, Port: port.Local, Err: err}
+		}
+
 		return nil, fmt.Errorf("unable to create listener: Error %s", err)
 	}
 	listenerAddress := listener.Addr().String()
@@ -339,7 +603,47 @@ func (pf *PortForwarder) waitForConnection(listener net.Listener, port Forwarded
 				}
 				return
 			}
-			go pf.handleConnection(conn, port)
+			if allowlist := pf.allowlists[port.Remote]; !allowlist.Allows(conn.RemoteAddr()) {
+				if pf.connLog != nil {
+					pf.connLog.Warnf("Rejecting connection from %s on port %d -> %d, because the source is not allowed", conn.RemoteAddr(), port.Local, port.Remote)
+				}
+				_ = conn.Close()
+				continue
+			}
+			if pf.oneShot != nil {
+				if !pf.oneShot.take() {
+					_ = conn.Close()
+					continue
+				}
+
+				// no further connections are accepted, the next accept returns because of the closed listener
+				pf.Close()
+				go func() {
+					defer close(pf.oneShot.done)
+					pf.handleConnection(conn, port)
+				}()
+				continue
+			}
+
+			limit := pf.limits[port.Remote]
+			if limit == nil {
+				go pf.handleConnection(conn, port)
+				continue
+			}
+			if !limit.acquire() {
+				// only log the first refused connection until connections are accepted again
+				if atomic.CompareAndSwapInt32(&limit.refusing, 0, 1) && pf.connLog != nil {
+					pf.connLog.Warnf("Refusing new connections on port %d -> %d, because the limit of %d connections is reached", port.Local, port.Remote, limit.max)
+				}
+				_ = conn.Close()
+				continue
+			}
+
+			atomic.StoreInt32(&limit.refusing, 0)
+			go func() {
+				defer limit.release()
+				pf.handleConnection(conn, port)
+			}()
 		}
 	}
 }
@@ -355,6 +659,8 @@ func (pf *PortForwarder) nextRequestID() int {
 // handleConnection copies data between the local connection and the stream to
 // the remote server.
 func (pf *PortForwarder) handleConnection(conn io.ReadWriteCloser, port ForwardedPort) {
+	atomic.AddInt64(&pf.active, 1)
+	defer atomic.AddInt64(&pf.active, -1)
 	defer conn.Close()
 
 	if pf.out != nil {
@@ -362,6 +668,14 @@ func (pf *PortForwarder) handleConnection(conn io.ReadWriteCloser, port Forwarde
 	}
 
 	requestID := pf.nextRequestID()
+	debug := pf.connLog != nil && pf.connLog.GetLevel() >= logrus.DebugLevel
+	if debug {
+		if netConn, ok := conn.(net.Conn); ok {
+			pf.connLog.Debugf("Accepted connection %d on port %d -> %d from %s", requestID, port.Local, port.Remote, netConn.RemoteAddr().String())
+		} else {
+			pf.connLog.Debugf("Accepted connection %d on port %d -> %d", requestID, port.Local, port.Remote)
+		}
+	}
 
 	// create error stream
 	headers := http.Header{}
@@ -398,12 +712,56 @@ func (pf *PortForwarder) handleConnection(conn io.ReadWriteCloser, port Forwarde
 		return
 	}
 
+	// originate TLS to the remote port, the local connection stays plaintext
+	var remote io.ReadWriter = dataStream
+	closeRemote := dataStream.Close
+	if config := pf.tlsConfigs[port.Remote]; config != nil {
+		tlsConn := tls.Client(&streamConn{ReadWriteCloser: dataStream}, config)
+		err = tlsConn.Handshake()
+		if err != nil {
+			pf.log.Errorf("error in tls handshake with port %d: %v", port.Remote, err)
+			_ = dataStream.Close()
+			<-errorChan
+			return
+		}
+
+		remote = tlsConn
+		closeRemote = func() error {
+			_ = tlsConn.CloseWrite()
+			return dataStream.Close()
+		}
+	}
+
 	localError := make(chan struct{})
 	remoteDone := make(chan struct{})
 
+	buffers := pf.buffers[port.Remote]
+	if err := buffers.apply(conn); err != nil {
+		pf.log.Warnf("error setting buffer sizes of connection on port %d -> %d: %v", port.Local, port.Remote, err)
+	}
+
+	var bytesSent, bytesReceived int64
+	var localWriter io.Writer = conn
+	var remoteWriter io.Writer = remote
+	if pf.traffic != nil {
+		pf.traffic.add(connectionsField, 1)
+		pf.traffic.add(activeField, 1)
+		defer pf.traffic.add(activeField, -1)
+		localWriter = &countingWriter{Writer: conn, traffic: pf.traffic, field: receivedField}
+		remoteWriter = &countingWriter{Writer: remote, traffic: pf.traffic, field: sentField}
+	}
+	if logger, ok := pf.httpLogs[port.Remote]; ok {
+		tap := newHTTPTap(logger)
+		defer tap.close()
+
+		localWriter = io.MultiWriter(localWriter, tap.response)
+		remoteWriter = io.MultiWriter(remoteWriter, tap.request)
+	}
 	go func() {
 		// Copy from the remote side to the local port.
-		if _, err := io.Copy(conn, dataStream); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		n, err := copyBuffer(localWriter, remote, buffers.Write)
+		atomic.StoreInt64(&bytesReceived, n)
+		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
 			pf.log.Errorf("error copying from remote stream to local connection: %v", err)
 			//pf.raiseError(fmt.Errorf("error copying from remote stream to local connection: %v", err))
 			// runtime.HandleError(fmt.Errorf("error copying from remote stream to local connection: %v", err))
@@ -415,10 +773,12 @@ func (pf *PortForwarder) handleConnection(conn io.ReadWriteCloser, port Forwarde
 
 	go func() {
 		// inform server we're not sending any more data after copy unblocks
-		defer dataStream.Close()
+		defer closeRemote()
 
 		// Copy from the local port to the remote side.
-		if _, err := io.Copy(dataStream, conn); err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
+		n, err := copyBuffer(remoteWriter, conn, buffers.Read)
+		atomic.StoreInt64(&bytesSent, n)
+		if err != nil && !strings.Contains(err.Error(), "use of closed network connection") {
 			pf.log.Errorf("error copying from local connection to remote stream: %v", err)
 			//pf.raiseError(fmt.Errorf("error copying from local connection to remote stream: %v", err))
 			// runtime.HandleError(fmt.Errorf("error copying from local connection to remote stream: %v", err))
@@ -428,13 +788,21 @@ func (pf *PortForwarder) handleConnection(conn io.ReadWriteCloser, port Forwarde
 	}()
 
 	// wait for either a local->remote error or for copying from remote->local to finish
+	reason := "remote closed connection"
 	select {
 	case <-remoteDone:
 	case <-localError:
+		reason = "error copying from local connection"
 	}
 
 	// always expect something on errorChan (it may be nil)
 	err = <-errorChan
+	if debug {
+		if err != nil {
+			reason = err.Error()
+		}
+		pf.connLog.Debugf("Closed connection %d on port %d -> %d (sent %d bytes, received %d bytes): %s", requestID, port.Local, port.Remote, atomic.LoadInt64(&bytesSent), atomic.LoadInt64(&bytesReceived), reason)
+	}
 	if err != nil {
 		// Fail for errors like container not running or No such container
 		if strings.Contains(err.Error(), "container") {
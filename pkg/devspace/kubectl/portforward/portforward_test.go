@@ -0,0 +1,163 @@
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+func TestTraffic(t *testing.T) {
+	parent := &Traffic{}
+	child := NewTraffic(parent)
+
+	child.add(connectionsField, 1)
+	child.add(activeField, 1)
+	_, err := (&countingWriter{Writer: &bytes.Buffer{}, traffic: child, field: sentField}).Write([]byte("hello"))
+	assert.NilError(t, err)
+	_, err = (&countingWriter{Writer: &bytes.Buffer{}, traffic: parent, field: receivedField}).Write([]byte("hi"))
+	assert.NilError(t, err)
+
+	// the child counts its own traffic, the parent counts both
+	assert.Equal(t, child.Connections(), int64(1))
+	assert.Equal(t, child.Sent(), int64(5))
+	assert.Equal(t, child.Received(), int64(0))
+	assert.Equal(t, parent.Connections(), int64(1))
+	assert.Equal(t, parent.Sent(), int64(5))
+	assert.Equal(t, parent.Received(), int64(2))
+	assert.Equal(t, parent.Active(), int64(1))
+
+	child.add(activeField, -1)
+	assert.Equal(t, child.Active(), int64(0))
+	assert.Equal(t, parent.Active(), int64(0))
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, FormatBytes(512), "512 B")
+	assert.Equal(t, FormatBytes(1536), "1.5 KiB")
+	assert.Equal(t, FormatBytes(3*1024*1024), "3.0 MiB")
+}
+
+// fakeStreamConnection echoes every data stream and records the remote port it was created for
+type fakeStreamConnection struct {
+	m           sync.Mutex
+	remotePorts []string
+
+	closeOnce sync.Once
+	closed    chan bool
+}
+
+func (f *fakeStreamConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	if headers.Get(v1.StreamType) == v1.StreamTypeError {
+		return &fakeStream{ReadWriteCloser: emptyStream{}, headers: headers}, nil
+	}
+
+	f.m.Lock()
+	f.remotePorts = append(f.remotePorts, headers.Get(v1.PortHeader))
+	f.m.Unlock()
+
+	upReader, upWriter := io.Pipe()
+	downReader, downWriter := io.Pipe()
+	go func() {
+		_, _ = io.Copy(downWriter, upReader)
+		_ = downWriter.Close()
+	}()
+	return &fakeStream{ReadWriteCloser: &halfCloser{Reader: downReader, WriteCloser: upWriter}, headers: headers}, nil
+}
+
+func (f *fakeStreamConnection) Close() error {
+	f.closeOnce.Do(func() { close(f.closed) })
+	return nil
+}
+
+func (f *fakeStreamConnection) CloseChan() <-chan bool                     { return f.closed }
+func (f *fakeStreamConnection) SetIdleTimeout(timeout time.Duration)       {}
+func (f *fakeStreamConnection) RemoveStreams(streams ...httpstream.Stream) {}
+
+type fakeDialer struct {
+	conn httpstream.Connection
+}
+
+func (f *fakeDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	return f.conn, PortForwardProtocolV1Name, nil
+}
+
+type fakeStream struct {
+	io.ReadWriteCloser
+	headers http.Header
+}
+
+func (f *fakeStream) Reset() error         { return f.Close() }
+func (f *fakeStream) Headers() http.Header { return f.headers }
+func (f *fakeStream) Identifier() uint32   { return 0 }
+
+// halfCloser only closes the writing direction, like a stream to the pod
+type halfCloser struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// emptyStream is an error stream without errors
+type emptyStream struct{}
+
+func (emptyStream) Read(p []byte) (int, error)  { return 0, io.EOF }
+func (emptyStream) Write(p []byte) (int, error) { return len(p), nil }
+func (emptyStream) Close() error                { return nil }
+
+func TestSharedRemotePort(t *testing.T) {
+	streamConn := &fakeStreamConnection{closed: make(chan bool)}
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	pf, err := NewOnAddresses(&fakeDialer{conn: streamConn}, []string{"127.0.0.1"}, []string{"0:8080", "0:8080"}, stopChan, readyChan, nil, nil, nil)
+	assert.NilError(t, err)
+
+	returned := make(chan error)
+	go func() {
+		returned <- pf.ForwardPorts(context.Background())
+	}()
+	<-readyChan
+	ports, err := pf.GetPorts()
+	assert.NilError(t, err)
+	assert.Equal(t, len(ports), 2)
+	assert.Assert(t, ports[0].Local != ports[1].Local, "both mappings have to listen on their own local port")
+
+	echo := func(conn net.Conn, message string) {
+		assert.NilError(t, conn.SetDeadline(time.Now().Add(time.Second*5)))
+		_, err := conn.Write([]byte(message))
+		assert.NilError(t, err)
+		buf := make([]byte, len(message))
+		_, err = io.ReadFull(conn, buf)
+		assert.NilError(t, err)
+		assert.Equal(t, string(buf), message)
+	}
+
+	// an open connection on the first local port doesn't block the second one
+	first, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", ports[0].Local))
+	assert.NilError(t, err)
+	defer first.Close()
+	echo(first, "first")
+	second, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", ports[1].Local))
+	assert.NilError(t, err)
+	defer second.Close()
+	echo(second, "second")
+	echo(first, "first again")
+	assert.NilError(t, first.Close())
+	assert.NilError(t, second.Close())
+
+	streamConn.m.Lock()
+	assert.DeepEqual(t, streamConn.remotePorts, []string{"8080", "8080"})
+	streamConn.m.Unlock()
+
+	close(stopChan)
+	pf.Close()
+	assert.NilError(t, <-returned)
+}
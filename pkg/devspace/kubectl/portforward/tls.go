@@ -0,0 +1,35 @@
+package portforward
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+)
+
+// SetTLSConfig originates TLS with the given config to the given remote port. The local
+// connections stay plaintext, e.g. for local tools that cannot present client certificates.
+func (pf *PortForwarder) SetTLSConfig(remotePort uint16, config *tls.Config) {
+	if pf.tlsConfigs == nil {
+		pf.tlsConfigs = map[uint16]*tls.Config{}
+	}
+
+	pf.tlsConfigs[remotePort] = config
+}
+
+// streamConn lets a stream to the pod be used as net.Conn by a TLS client. Streams don't support
+// deadlines, so setting them is a no-op.
+type streamConn struct {
+	io.ReadWriteCloser
+}
+
+func (s *streamConn) LocalAddr() net.Addr                { return streamAddr{} }
+func (s *streamConn) RemoteAddr() net.Addr               { return streamAddr{} }
+func (s *streamConn) SetDeadline(t time.Time) error      { return nil }
+func (s *streamConn) SetReadDeadline(t time.Time) error  { return nil }
+func (s *streamConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type streamAddr struct{}
+
+func (streamAddr) Network() string { return "portforward" }
+func (streamAddr) String() string  { return "portforward" }
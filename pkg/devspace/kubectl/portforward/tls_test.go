@@ -0,0 +1,79 @@
+package portforward
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// selfSignedCertificate returns a certificate for the given name and a pool that trusts it
+func selfSignedCertificate(t *testing.T, name string) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	assert.NilError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestStreamConnTLS(t *testing.T) {
+	serverCert, serverPool := selfSignedCertificate(t, "server.test")
+	clientCert, clientPool := selfSignedCertificate(t, "client.test")
+
+	local, remote := net.Pipe()
+	defer local.Close()
+	defer remote.Close()
+
+	// the remote service requires a client certificate and echoes what it receives
+	go func() {
+		server := tls.Server(remote, &tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    clientPool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})
+		_, _ = io.Copy(server, server)
+		_ = server.Close()
+	}()
+
+	// the stream only implements io.ReadWriteCloser
+	client := tls.Client(&streamConn{ReadWriteCloser: struct{ io.ReadWriteCloser }{local}}, &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      serverPool,
+		ServerName:   "server.test",
+	})
+	assert.NilError(t, client.Handshake())
+
+	_, err := client.Write([]byte("hello"))
+	assert.NilError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(client, buf)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "hello")
+}
@@ -2,9 +2,12 @@ package kubectl
 
 import (
 	"net/http"
+	"net/url"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/httpstream"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
 	clientspdy "k8s.io/client-go/transport/spdy"
 )
 
@@ -45,7 +48,11 @@ func (uw *upgraderWrapper) Close() error {
 
 // GetUpgraderWrapper returns an upgrade wrapper for the given config @Factory
 func GetUpgraderWrapper(client Client) (http.RoundTripper, UpgraderWrapper, error) {
-	wrapper, upgradeRoundTripper, err := clientspdy.RoundTripperFor(client.RestConfig())
+	// make sure the upgraded connections honor HTTPS_PROXY and NO_PROXY, the
+	// round tripper tunnels through http proxies via CONNECT
+	config := rest.CopyConfig(client.RestConfig())
+	config.Proxy = proxierFor(client.RestConfig())
+	wrapper, upgradeRoundTripper, err := clientspdy.RoundTripperFor(config)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -55,3 +62,33 @@ func GetUpgraderWrapper(client Client) (http.RoundTripper, UpgraderWrapper, erro
 		Connections: make([]httpstream.Connection, 0, 1),
 	}, nil
 }
+
+// proxierFor returns the proxy func of the config or, if not set, a proxy func that uses the
+// proxy environment variables. In contrast to http.ProxyFromEnvironment, NO_PROXY can also
+// contain CIDRs.
+func proxierFor(config *rest.Config) func(*http.Request) (*url.URL, error) {
+	if config.Proxy != nil {
+		return config.Proxy
+	}
+
+	return utilnet.NewProxierWithNoProxyCIDR(http.ProxyFromEnvironment)
+}
+
+// ProxyURL returns the url of the proxy that is used to connect to the kubernetes api server
+// of the client or nil if no proxy is used
+func ProxyURL(client Client) (*url.URL, error) {
+	config := client.RestConfig()
+	if config == nil || config.Host == "" {
+		return nil, nil
+	}
+
+	host, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	if host.Scheme == "" {
+		host.Scheme = "https"
+	}
+
+	return proxierFor(config)(&http.Request{URL: host, Header: http.Header{}})
+}
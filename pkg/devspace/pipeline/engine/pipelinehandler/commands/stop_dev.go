@@ -6,6 +6,7 @@ import (
 	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
 	"github.com/loft-sh/devspace/pkg/devspace/deploy"
 	"github.com/loft-sh/devspace/pkg/devspace/pipeline/types"
+	"github.com/loft-sh/devspace/pkg/util/log"
 	"github.com/loft-sh/devspace/pkg/util/stringutil"
 	"github.com/pkg/errors"
 	"strings"
@@ -44,7 +45,7 @@ func StopDev(ctx devspacecontext.Context, pipeline types.Pipeline, args []string
 				continue
 			}
 
-			ctx = ctx.WithLogger(ctx.Log().WithPrefix("dev:" + a + " "))
+			ctx = ctx.WithLogger(log.NewDefaultPrefixLoggerWithKey("dev:"+a+" ", a, ctx.Log()))
 			ctx.Log().Infof("Stopping dev %s", a)
 			err = devManager.Reset(ctx, a, &options.PurgeOptions)
 			if err != nil {
@@ -58,7 +59,7 @@ func StopDev(ctx devspacecontext.Context, pipeline types.Pipeline, args []string
 				continue
 			}
 
-			ctx = ctx.WithLogger(ctx.Log().WithPrefix("dev:" + a.Name + " "))
+			ctx = ctx.WithLogger(log.NewDefaultPrefixLoggerWithKey("dev:"+a.Name+" ", a.Name, ctx.Log()))
 			ctx.Log().Infof("Stopping dev %s", a.Name)
 			err = devManager.Reset(ctx, a.Name, &options.PurgeOptions)
 			if err != nil {
@@ -67,7 +68,7 @@ func StopDev(ctx devspacecontext.Context, pipeline types.Pipeline, args []string
 		}
 	} else if len(args) > 0 {
 		for _, a := range args {
-			ctx = ctx.WithLogger(ctx.Log().WithPrefix("dev:" + a + " "))
+			ctx = ctx.WithLogger(log.NewDefaultPrefixLoggerWithKey("dev:"+a+" ", a, ctx.Log()))
 			ctx.Log().Infof("Stopping dev %s", a)
 			err = devManager.Reset(ctx, a, &options.PurgeOptions)
 			if err != nil {
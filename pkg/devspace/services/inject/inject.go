@@ -77,50 +77,78 @@ func InjectDevSpaceHelper(ctx context.Context, client kubectl.Client, pod *v1.Po
 
 	// Check if sync is already in pod
 	localHelperName := "devspacehelper" + arch
+	homedir, err := homedir.Dir()
+	if err != nil {
+		return err
+	}
+
+	syncBinaryFolder := filepath.Join(homedir, constants.DefaultHomeDevSpaceFolder, DevSpaceHelperTempFolder, version)
 	stdout, _, err := client.ExecBuffered(ctx, pod, container, []string{DevSpaceHelperContainerPath, "version"}, nil)
-	if err != nil || version != string(stdout) {
-		log.Info("Inject devspacehelper...")
-		homedir, err := homedir.Dir()
-		if err != nil {
-			return err
+	if err == nil && version == string(stdout) {
+		if helperChecksumMatches(ctx, client, pod, container, localHelperName, syncBinaryFolder) {
+			log.Debugf("Reuse devspacehelper found in pod %s/%s", pod.Namespace, pod.Name)
+			return nil
 		}
 
-		syncBinaryFolder := filepath.Join(homedir, constants.DefaultHomeDevSpaceFolder, DevSpaceHelperTempFolder, version)
-		if env.GlobalGetEnv("DEVSPACE_INJECT_REMOTE") == "true" {
-			// Install devspacehelper inside container
-			log.Debugf("Trying to download devspacehelper into pod %s/%s", pod.Namespace, pod.Name)
-			err = installDevSpaceHelperInContainer(ctx, client, pod, container, version, localHelperName)
-			if err == nil {
-				log.Donef("Successfully injected devspacehelper into pod %s/%s", pod.Namespace, pod.Name)
-				return nil
-			}
-
-			log.Debugf("Couldn't download devspacehelper in container, error: %s", err)
-		}
+		log.Debugf("Checksum of devspacehelper in pod %s/%s differs, will upload it again", pod.Namespace, pod.Name)
+	}
 
-		// check if we can find it in the assets
-		helperBytes, err := assets.Asset("release/" + localHelperName)
+	log.Info("Inject devspacehelper...")
+	if env.GlobalGetEnv("DEVSPACE_INJECT_REMOTE") == "true" {
+		// Install devspacehelper inside container
+		log.Debugf("Trying to download devspacehelper into pod %s/%s", pod.Namespace, pod.Name)
+		err = installDevSpaceHelperInContainer(ctx, client, pod, container, version, localHelperName)
 		if err == nil {
-			return injectSyncHelperFromBytes(ctx, client, pod, container, helperFileInfo(helperBytes), bytes.NewReader(helperBytes))
+			log.Donef("Successfully injected devspacehelper into pod %s/%s", pod.Namespace, pod.Name)
+			return nil
 		}
 
-		// Download sync helper if necessary
-		err = downloadSyncHelper(ctx, localHelperName, syncBinaryFolder, version, log)
-		if err != nil {
-			return errors.Wrap(err, "download devspace helper")
-		}
+		log.Debugf("Couldn't download devspacehelper in container, error: %s", err)
+	}
+
+	// check if we can find it in the assets
+	helperBytes, err := assets.Asset("release/" + localHelperName)
+	if err == nil {
+		return injectSyncHelperFromBytes(ctx, client, pod, container, helperFileInfo(helperBytes), bytes.NewReader(helperBytes))
+	}
+
+	// Download sync helper if necessary
+	err = downloadSyncHelper(ctx, localHelperName, syncBinaryFolder, version, log)
+	if err != nil {
+		return errors.Wrap(err, "download devspace helper")
+	}
+
+	// Inject sync helper
+	err = injectSyncHelper(ctx, client, pod, container, filepath.Join(syncBinaryFolder, localHelperName))
+	if err != nil {
+		return errors.Wrap(err, "inject devspace helper")
+	}
 
-		// Inject sync helper
-		err = injectSyncHelper(ctx, client, pod, container, filepath.Join(syncBinaryFolder, localHelperName))
+	log.Donef("Successfully injected devspacehelper into pod %s/%s", pod.Namespace, pod.Name)
+	return nil
+}
+
+// helperChecksumMatches checks if the devspacehelper in the container has the same checksum as the
+// local one. If any of the checksums cannot be determined, the version check is trusted instead.
+func helperChecksumMatches(ctx context.Context, client kubectl.Client, pod *v1.Pod, container, helperName, syncBinaryFolder string) bool {
+	localChecksum := ""
+	helperBytes, err := assets.Asset("release/" + helperName)
+	if err == nil {
+		localChecksum = hash.String(string(helperBytes))
+	} else {
+		localChecksum, err = hash.File(filepath.Join(syncBinaryFolder, helperName))
 		if err != nil {
-			return errors.Wrap(err, "inject devspace helper")
+			return true
 		}
+	}
 
-		log.Donef("Successfully injected devspacehelper into pod %s/%s", pod.Namespace, pod.Name)
-		return nil
+	stdout, _, err := client.ExecBuffered(ctx, pod, container, []string{"sha256sum", DevSpaceHelperContainerPath}, nil)
+	if err != nil {
+		return true
 	}
 
-	return nil
+	fields := strings.Fields(string(stdout))
+	return len(fields) == 0 || fields[0] == localChecksum
 }
 
 func installDevSpaceHelperInContainer(ctx context.Context, client kubectl.Client, pod *v1.Pod, container, version, filename string) error {
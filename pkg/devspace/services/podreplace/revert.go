@@ -1,6 +1,7 @@
 package podreplace
 
 import (
+	"context"
 	"github.com/loft-sh/devspace/pkg/devspace/config/remotecache"
 	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
 	"github.com/loft-sh/devspace/pkg/devspace/context/values"
@@ -12,10 +13,15 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// defaultVerifyRevertTimeout is the default time to wait for the original workload to become available again
+const defaultVerifyRevertTimeout = 2 * time.Minute
+
 func (p *replacer) RevertReplacePod(ctx devspacecontext.Context, devPodCache *remotecache.DevPodCache, options *deploy.PurgeOptions) (bool, error) {
 	if options == nil {
 		options = &deploy.PurgeOptions{}
@@ -76,10 +82,73 @@ func (p *replacer) RevertReplacePod(ctx devspacecontext.Context, devPodCache *re
 		return false, err
 	}
 
+	// wait until the original workload is available again
+	if options.VerifyRevert {
+		err = waitForTargetAvailable(ctx, devPodCache.TargetKind, namespace, devPodCache.TargetName, time.Duration(options.VerifyRevertTimeout)*time.Second)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	ctx.Config().RemoteCache().DeleteDevPod(devPodCache.Name)
 	return deleted, ctx.Config().RemoteCache().Save(ctx.Context(), ctx.KubeClient())
 }
 
+// waitForTargetAvailable waits until all replicas of the given target are available
+func waitForTargetAvailable(ctx devspacecontext.Context, kind, namespace, name string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultVerifyRevertTimeout
+	}
+
+	ctx.Log().Infof("Waiting for %s %s to become available...", kind, name)
+	var lastErr error
+	err := wait.PollImmediateWithContext(ctx.Context(), time.Second, timeout, func(_ context.Context) (bool, error) {
+		target, err := findTargetByKindName(ctx, kind, namespace, name)
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+
+		lastErr = nil
+		return isTargetAvailable(target), nil
+	})
+	if err != nil {
+		if err == wait.ErrWaitTimeout {
+			if lastErr != nil {
+				return errors.Errorf("%s %s did not become available within %s after reverting the dev pod: %v", kind, name, timeout.String(), lastErr)
+			}
+
+			return errors.Errorf("%s %s did not become available within %s after reverting the dev pod, please check its pods with 'kubectl -n %s get pods'", kind, name, timeout.String(), namespace)
+		}
+
+		return err
+	}
+
+	ctx.Log().Donef("%s %s is available again", kind, name)
+	return nil
+}
+
+func isTargetAvailable(target runtime.Object) bool {
+	switch t := target.(type) {
+	case *appsv1.ReplicaSet:
+		return t.Status.ObservedGeneration >= t.Generation && t.Status.AvailableReplicas >= replicasOf(t.Spec.Replicas)
+	case *appsv1.Deployment:
+		return t.Status.ObservedGeneration >= t.Generation && t.Status.UpdatedReplicas >= replicasOf(t.Spec.Replicas) && t.Status.AvailableReplicas >= replicasOf(t.Spec.Replicas)
+	case *appsv1.StatefulSet:
+		return t.Status.ObservedGeneration >= t.Generation && t.Status.ReadyReplicas >= replicasOf(t.Spec.Replicas)
+	}
+
+	return true
+}
+
+func replicasOf(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
+
 func scaleUpTarget(ctx devspacecontext.Context, parent runtime.Object) error {
 	clonedParent := parent.DeepCopyObject()
 	metaParent, err := meta.Accessor(parent)
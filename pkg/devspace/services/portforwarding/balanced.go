@@ -0,0 +1,389 @@
+package portforwarding
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// balancedRefreshInterval is the interval in which the pods of a balanced port forwarding are refreshed
+var balancedRefreshInterval = 2 * time.Second
+
+// balancedForwarder listens on a local port and distributes the connections round-robin
+// across port forwardings to all running pods matching the label selector or, if a service
+// is set, to the pods of the ready endpoints of the service
+type balancedForwarder struct {
+	ctx           devspacecontext.Context
+	namespace     string
+	labelSelector string
+	service       string
+
+	listener   net.Listener
+	remotePort int
+
+	// traffic counts the bytes forwarded through the port forwardings to the pods if set
+	traffic *portforward.Traffic
+
+	m        sync.Mutex
+	backends map[string]*balancedBackend
+	order    []string
+	next     int
+}
+
+// balancedBackend is an established port forwarding to a single pod
+type balancedBackend struct {
+	pf       forwarder
+	stopChan chan struct{}
+	address  string
+}
+
+// startBalancedForwarding opens the local listeners of the given port mappings that are forwarded to all pods
+// matching the label selector or, if service is not empty, to the endpoints of the service
+func startBalancedForwarding(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, namespace, labelSelector, service string, options Options, parent *tomb.Tomb) error {
+	if ctx.IsDone() {
+		return nil
+	}
+
+	portMappings, err := resolveBindAddresses(portMappings)
+	if err != nil {
+		return err
+	}
+
+	forwarders := []*balancedForwarder{}
+	portsFormatted := []string{}
+	forwardedPorts := []portforward.ForwardedPort{}
+	for _, portMapping := range portMappings {
+		mappings, err := portforward.ParsePorts([]string{portMapping.Port})
+		if err != nil {
+			return fmt.Errorf("error parsing port %s: %v", portMapping.Port, err)
+		}
+
+		bindAddress := portMapping.BindAddress
+		if bindAddress == "" {
+			bindAddress = "localhost"
+		}
+
+		listener, err := options.listen()("tcp", net.JoinHostPort(bindAddress, strconv.Itoa(int(mappings[0].Local))))
+		if err != nil {
+			for _, b := range forwarders {
+				b.close()
+			}
+			return errors.Wrapf(err, "listen on port %d", int(mappings[0].Local))
+		}
+
+		b := &balancedForwarder{
+			ctx:           ctx,
+			namespace:     namespace,
+			labelSelector: labelSelector,
+			service:       service,
+			listener:      listener,
+			remotePort:    int(mappings[0].Remote),
+			traffic:       options.Traffic,
+			backends:      map[string]*balancedBackend{},
+		}
+		forwarders = append(forwarders, b)
+		portsFormatted = append(portsFormatted, formatListener(portMapping, listener, b.remotePort))
+		forwardedPorts = append(forwardedPorts, portforward.ForwardedPort{Local: uint16(listener.Addr().(*net.TCPAddr).Port), Remote: mappings[0].Remote})
+	}
+
+	for _, b := range forwarders {
+		err := b.refresh()
+		if err != nil {
+			ctx.Log().Warnf("Error retrieving pods for port forwarding: %v", err)
+		}
+		go b.acceptConnections()
+	}
+
+	started := parent.TryGoNamed("balanced "+strings.Join(portsFormatted, ", "), func() error {
+		ticker := time.NewTicker(balancedRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Context().Done():
+				for _, b := range forwarders {
+					b.close()
+				}
+				stopPortForwarding(ctx, name, portMappings, cancelReason(parent, options.owner), drainStats{}, options, parent)
+				return nil
+			case <-ticker.C:
+				for _, b := range forwarders {
+					err := b.refresh()
+					if err != nil {
+						ctx.Log().Debugf("Error retrieving pods for port forwarding: %v", err)
+					}
+				}
+			}
+		}
+	})
+	if !started {
+		ctx.Log().Debugf("Skipped port forwarding of dev %s to %s, because it is shutting down", name, forwarders[0].target())
+		for _, b := range forwarders {
+			b.close()
+		}
+		return nil
+	}
+
+	ctx.Log().Donef("Port forwarding to %s started on: %s", forwarders[0].target(), strings.Join(portsFormatted, ", "))
+	listenersReady(ctx, name, portMappings, forwardedPorts, options)
+	return nil
+}
+
+func (b *balancedForwarder) acceptConnections() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go b.handleConnection(conn)
+	}
+}
+
+func (b *balancedForwarder) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	pod, address, err := b.pick()
+	if err != nil {
+		b.ctx.Log().Errorf("Error forwarding connection to port %d: %v", b.remotePort, err)
+		return
+	}
+
+	remoteConn, err := net.Dial("tcp", address)
+	if err != nil {
+		b.ctx.Log().Errorf("Error connecting to port forwarding of pod %s for port %d: %v", pod, b.remotePort, err)
+		return
+	}
+	defer remoteConn.Close()
+
+	pipeConnections(conn, remoteConn)
+}
+
+// pick returns the pod name and local address of the next port forwarding
+func (b *balancedForwarder) pick() (string, string, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if len(b.order) == 0 {
+		if b.service != "" {
+			return "", "", errors.Errorf("no ready endpoint found for service %s", b.service)
+		}
+		return "", "", errors.Errorf("no running pod found for label selector %s", b.labelSelector)
+	}
+
+	pod := b.order[b.next%len(b.order)]
+	b.next = (b.next + 1) % len(b.order)
+	return pod, b.backends[pod].address, nil
+}
+
+// target describes the pods the connections are distributed across
+func (b *balancedForwarder) target() string {
+	if b.service != "" {
+		return "endpoints of service " + b.service
+	}
+
+	return "all pods matching " + b.labelSelector
+}
+
+// logChange logs an added or removed pod. Endpoints of services change with every rolling
+// deployment, so they are only logged at debug level.
+func (b *balancedForwarder) logChange(format string, args ...interface{}) {
+	if b.service != "" {
+		b.ctx.Log().Debugf(format, args...)
+		return
+	}
+
+	b.ctx.Log().Infof(format, args...)
+}
+
+// refresh establishes port forwardings to new running pods and closes the ones to pods
+// that are gone
+func (b *balancedForwarder) refresh() error {
+	if b.ctx.IsDone() {
+		return nil
+	}
+
+	running, err := b.runningPods()
+	if err != nil {
+		return err
+	}
+
+	b.m.Lock()
+	for name, backend := range b.backends {
+		if _, ok := running[name]; !ok {
+			b.logChange("Removed pod %s from port forwarding for port %d", name, b.remotePort)
+			b.removeBackend(name, backend)
+		}
+	}
+	toAdd := []*corev1.Pod{}
+	for name, pod := range running {
+		if _, ok := b.backends[name]; !ok {
+			toAdd = append(toAdd, pod)
+		}
+	}
+	b.m.Unlock()
+
+	for _, pod := range toAdd {
+		err := b.establish(pod)
+		if err != nil {
+			b.ctx.Log().Debugf("Error starting port forwarding to pod %s: %v", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// runningPods returns the running pods matching the label selector or, if a service is set, the
+// pods of the ready endpoints of the service by name
+func (b *balancedForwarder) runningPods() (map[string]*corev1.Pod, error) {
+	running := map[string]*corev1.Pod{}
+	if b.service != "" {
+		endpoints, err := b.ctx.KubeClient().KubeClient().CoreV1().Endpoints(b.namespace).Get(b.ctx.Context(), b.service, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "get endpoints of service %s", b.service)
+		}
+
+		for _, subset := range endpoints.Subsets {
+			for _, address := range subset.Addresses {
+				if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+					continue
+				}
+
+				namespace := address.TargetRef.Namespace
+				if namespace == "" {
+					namespace = b.namespace
+				}
+				running[address.TargetRef.Name] = &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      address.TargetRef.Name,
+						Namespace: namespace,
+					},
+				}
+			}
+		}
+
+		return running, nil
+	}
+
+	podList, err := b.ctx.KubeClient().KubeClient().CoreV1().Pods(b.namespace).List(b.ctx.Context(), metav1.ListOptions{LabelSelector: b.labelSelector})
+	if err != nil {
+		return nil, errors.Wrap(err, "list pods")
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == corev1.PodRunning {
+			running[pod.Name] = pod
+		}
+	}
+
+	return running, nil
+}
+
+// establish starts the port forwarding to the given pod and adds it as backend
+func (b *balancedForwarder) establish(pod *corev1.Pod) error {
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	errorChan := make(chan error, 1)
+	pf, err := newPortForwarder(b.ctx.KubeClient(), pod, []string{fmt.Sprintf("0:%d", b.remotePort)}, []string{"127.0.0.1"}, stopChan, readyChan, errorChan, b.ctx.Log())
+	if err != nil {
+		return errors.Errorf("Error starting port forwarding: %v", err)
+	}
+	if counter, ok := pf.(trafficCounter); ok && b.traffic != nil {
+		counter.SetTrafficCounter(b.traffic)
+	}
+
+	go func() {
+		err := pf.ForwardPorts(b.ctx.Context())
+		if err != nil {
+			errorChan <- err
+		}
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errorChan:
+		close(stopChan)
+		return errors.Wrap(err, "forward ports")
+	case <-time.After(readyTimeout):
+		close(stopChan)
+		pf.Close()
+		return errReadyTimeout
+	}
+
+	ports, err := pf.GetPorts()
+	if err != nil || len(ports) != 1 {
+		close(stopChan)
+		pf.Close()
+		return errors.Errorf("Error retrieving local port of port forwarding: %v", err)
+	}
+
+	backend := &balancedBackend{
+		pf:       pf,
+		stopChan: stopChan,
+		address:  net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local))),
+	}
+
+	b.m.Lock()
+	if b.ctx.IsDone() {
+		b.m.Unlock()
+		close(stopChan)
+		pf.Close()
+		return nil
+	}
+	b.backends[pod.Name] = backend
+	b.order = append(b.order, pod.Name)
+	b.m.Unlock()
+	b.logChange("Added pod %s to port forwarding for port %d", pod.Name, b.remotePort)
+
+	// remove the pod if its port forwarding fails, the next refresh will establish a new one
+	go func() {
+		select {
+		case <-b.ctx.Context().Done():
+		case <-stopChan:
+		case err := <-errorChan:
+			b.m.Lock()
+			defer b.m.Unlock()
+
+			if b.backends[pod.Name] == backend {
+				b.ctx.Log().Debugf("Port forwarding to pod %s for port %d stopped: %v", pod.Name, b.remotePort, err)
+				b.removeBackend(pod.Name, backend)
+			}
+		}
+	}()
+	return nil
+}
+
+// removeBackend stops the port forwarding to the given pod, the lock has to be held
+func (b *balancedForwarder) removeBackend(name string, backend *balancedBackend) {
+	close(backend.stopChan)
+	backend.pf.Close()
+	delete(b.backends, name)
+	for i, pod := range b.order {
+		if pod == name {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (b *balancedForwarder) close() {
+	_ = b.listener.Close()
+
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	for name, backend := range b.backends {
+		b.removeBackend(name, backend)
+	}
+}
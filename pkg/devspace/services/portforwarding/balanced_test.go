@@ -0,0 +1,205 @@
+package portforwarding
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// startPodServers simulates every pod by a local server that responds with the pod name
+// and returns the local ports by pod name
+func startPodServers(t *testing.T, names ...string) map[string]int {
+	podPorts := map[string]int{}
+	for _, name := range names {
+		name := name
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NilError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+
+				_, _ = conn.Write([]byte(name + "\n"))
+				_ = conn.Close()
+			}
+		}()
+		podPorts[name] = listener.Addr().(*net.TCPAddr).Port
+	}
+
+	return podPorts
+}
+
+func TestBalancedForwarding(t *testing.T) {
+	podPorts := startPodServers(t, "pod-a", "pod-b")
+
+	oldNewPortForwarder := newPortForwarder
+	oldRefreshInterval := balancedRefreshInterval
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		balancedRefreshInterval = oldRefreshInterval
+	}()
+	balancedRefreshInterval = 50 * time.Millisecond
+	forwarders := make(chan *echoForwarder, 10)
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		pf := &echoForwarder{readyChan: readyChan, port: podPorts[pod.Name]}
+		forwarders <- pf
+		return pf, nil
+	}
+
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: map[string]string{"app": "test"}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	clientset := fake.NewSimpleClientset(newPod("pod-a"), newPod("pod-b"))
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: clientset})
+	parent := &tomb.Tomb{}
+	traffic := &portforward.Traffic{}
+
+	// find a free local port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	localPort := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	<-parent.NotifyGo(func() error {
+		err = startBalancedForwarding(ctx, "test", []*latest.PortMapping{{Port: strconv.Itoa(localPort) + ":8080", BindAddress: "127.0.0.1", AllPods: true, LabelSelector: "app=test"}}, "test", "app=test", "", Options{Traffic: traffic}, parent)
+		return err
+	})
+	assert.NilError(t, err)
+
+	request := func() string {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)))
+		assert.NilError(t, err)
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		return line
+	}
+
+	responses := map[string]int{}
+	for i := 0; i < 4; i++ {
+		responses[request()]++
+	}
+	assert.DeepEqual(t, responses, map[string]int{"pod-a\n": 2, "pod-b\n": 2})
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, (<-forwarders).trafficCounter(), traffic, "traffic of the balanced port forwarding is not counted")
+	}
+
+	// a deleted pod is removed from the rotation on the next refresh
+	assert.NilError(t, clientset.CoreV1().Pods("test").Delete(context.Background(), "pod-a", metav1.DeleteOptions{}))
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && (request() != "pod-b\n" || request() != "pod-b\n") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, request(), "pod-b\n")
+	}
+
+	cancel()
+	_ = parent.Wait()
+}
+
+func TestServiceForwarding(t *testing.T) {
+	podPorts := startPodServers(t, "pod-a", "pod-b")
+
+	oldNewPortForwarder := newPortForwarder
+	oldRefreshInterval := balancedRefreshInterval
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		balancedRefreshInterval = oldRefreshInterval
+	}()
+	balancedRefreshInterval = 50 * time.Millisecond
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		return &echoForwarder{readyChan: readyChan, port: podPorts[pod.Name]}, nil
+	}
+
+	newEndpoints := func(pods ...string) *corev1.Endpoints {
+		addresses := []corev1.EndpointAddress{}
+		for _, pod := range pods {
+			addresses = append(addresses, corev1.EndpointAddress{IP: "10.0.0.1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: pod}})
+		}
+		return &corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "test"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: addresses}},
+		}
+	}
+	clientset := fake.NewSimpleClientset(newEndpoints("pod-a"))
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: clientset})
+	parent := &tomb.Tomb{}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	localPort := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	<-parent.NotifyGo(func() error {
+		err = startBalancedForwarding(ctx, "test", []*latest.PortMapping{{Port: strconv.Itoa(localPort) + ":8080", BindAddress: "127.0.0.1", AllPods: true, Service: "backend"}}, "test", "", "backend", Options{}, parent)
+		return err
+	})
+	assert.NilError(t, err)
+
+	request := func() string {
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)))
+		assert.NilError(t, err)
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		return line
+	}
+	assert.Equal(t, request(), "pod-a\n")
+
+	// a rolling deployment replaces the endpoint, the port forwarding follows without a restart
+	_, err = clientset.CoreV1().Endpoints("test").Update(context.Background(), newEndpoints("pod-b"), metav1.UpdateOptions{})
+	assert.NilError(t, err)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && (request() != "pod-b\n" || request() != "pod-b\n") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, request(), "pod-b\n")
+	}
+
+	cancel()
+	_ = parent.Wait()
+}
+
+func TestBalancedForwardingListen(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	parent := &tomb.Tomb{}
+
+	addresses := []string{}
+	listen := func(network, address string) (net.Listener, error) {
+		addresses = append(addresses, address)
+		return nil, errors.New("listen denied")
+	}
+	err := startBalancedForwarding(ctx, "test", []*latest.PortMapping{{Port: "9090:8080", BindAddress: "127.0.0.1", AllPods: true, LabelSelector: "app=test"}}, "test", "app=test", "", Options{Listen: listen}, parent)
+	assert.ErrorContains(t, err, "listen denied")
+	assert.DeepEqual(t, addresses, []string{"127.0.0.1:9090"})
+}
@@ -0,0 +1,42 @@
+package portforwarding
+
+import (
+	"fmt"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/env"
+	"github.com/loft-sh/devspace/pkg/util/vars"
+	"github.com/pkg/errors"
+)
+
+// resolveBindAddresses replaces the ${ENV_VAR} references in the bind addresses of the port mappings
+// with the values of the environment variables. They are resolved every time the port forwarding is
+// started, e.g. to bind to a loopback alias that is assigned per CI job. Port mappings with a bind
+// address that references a variable are copied.
+func resolveBindAddresses(portMappings []*latest.PortMapping) ([]*latest.PortMapping, error) {
+	resolved := make([]*latest.PortMapping, len(portMappings))
+	for index, portMapping := range portMappings {
+		resolved[index] = portMapping
+		if !vars.VarMatchRegex.MatchString(portMapping.BindAddress) {
+			continue
+		}
+
+		bindAddress, err := vars.ParseString(portMapping.BindAddress, func(name string) (interface{}, error) {
+			value := env.GlobalGetEnv(name)
+			if value == "" {
+				return nil, errors.Errorf("environment variable %s is not set", name)
+			}
+
+			return value, nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "bind address %s of port %s", portMapping.BindAddress, portMapping.Port)
+		}
+
+		copied := *portMapping
+		copied.BindAddress = fmt.Sprintf("%v", bindAddress)
+		resolved[index] = &copied
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,23 @@
+package portforwarding
+
+import (
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"gotest.tools/assert"
+)
+
+func TestResolveBindAddresses(t *testing.T) {
+	t.Setenv("DEVSPACE_TEST_BIND_ADDRESS", "127.0.0.2")
+	static := &latest.PortMapping{Port: "8080", BindAddress: "0.0.0.0"}
+	templated := &latest.PortMapping{Port: "9090", BindAddress: "${DEVSPACE_TEST_BIND_ADDRESS}"}
+
+	resolved, err := resolveBindAddresses([]*latest.PortMapping{static, templated})
+	assert.NilError(t, err)
+	assert.Equal(t, resolved[0], static)
+	assert.Equal(t, resolved[1].BindAddress, "127.0.0.2")
+	assert.Equal(t, templated.BindAddress, "${DEVSPACE_TEST_BIND_ADDRESS}", "port mapping of the config should not be changed")
+
+	_, err = resolveBindAddresses([]*latest.PortMapping{{Port: "9090", BindAddress: "${DEVSPACE_TEST_UNSET_BIND_ADDRESS}"}})
+	assert.ErrorContains(t, err, "environment variable DEVSPACE_TEST_UNSET_BIND_ADDRESS is not set")
+}
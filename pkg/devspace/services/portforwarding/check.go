@@ -0,0 +1,122 @@
+package portforwarding
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/pkg/errors"
+)
+
+// CheckResult is the result of a port forwarding check
+type CheckResult struct {
+	// Pod is the namespace and name of the pod the ports were forwarded to
+	Pod string
+
+	// Forward is the time it took to select the pod and establish the port forwarding
+	Forward time.Duration
+
+	// Connect is the time it took to connect through all forwarded ports, it is
+	// only set if the connections were checked
+	Connect time.Duration
+}
+
+// CheckPortForwarding checks if the given port mappings can be forwarded to the pod of the selector.
+// The ports are forwarded through free local ports and, if connect is true, a connection through each
+// of them is opened. Afterwards the port forwarding is stopped again. No hooks are executed and the
+// port forwarding is not restarted.
+func CheckPortForwarding(ctx devspacecontext.Context, portMappings []*latest.PortMapping, selector targetselector.PodSelector, connect bool) (*CheckResult, error) {
+	if ctx.KubeClient() == nil {
+		return nil, fmt.Errorf("kube client is not set, cannot check port forwarding")
+	}
+	err := checkProtocols(portMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	pod, err := selectPod(ctx, selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "error selecting pod")
+	} else if pod == nil {
+		return nil, errors.New("no pod found")
+	}
+	portMappings, err = resolvePortMappings(pod, portMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]string, len(portMappings))
+	addresses := make([]string, len(portMappings))
+	for index, value := range portMappings {
+		mappings, err := portforward.ParsePorts([]string{value.Port})
+		if err != nil {
+			return nil, fmt.Errorf("error parsing port %s: %v", value.Port, err)
+		}
+
+		ports[index] = fmt.Sprintf("0:%d", int(mappings[0].Remote))
+		addresses[index] = "127.0.0.1"
+	}
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	errorChan := make(chan error, len(portMappings)+1)
+	pf, err := newPortForwarder(ctx.KubeClient(), pod, ports, addresses, stopChan, readyChan, errorChan, ctx.Log())
+	if err != nil {
+		return nil, errors.Errorf("Error starting port forwarding: %v", err)
+	}
+	defer func() {
+		close(stopChan)
+		pf.Close()
+		drainErrors(ctx, errorChan)
+	}()
+
+	go func() {
+		err := pf.ForwardPorts(ctx.Context())
+		if err != nil {
+			select {
+			case errorChan <- err:
+			case <-stopChan:
+			}
+		}
+	}()
+
+	select {
+	case <-ctx.Context().Done():
+		return nil, ctx.Context().Err()
+	case err := <-errorChan:
+		return nil, errors.Wrap(err, "forward ports")
+	case <-time.After(readyTimeout):
+		return nil, errReadyTimeout
+	case <-readyChan:
+	}
+
+	result := &CheckResult{
+		Pod:     pod.Namespace + "/" + pod.Name,
+		Forward: time.Since(started),
+	}
+	if !connect {
+		return result, nil
+	}
+
+	forwardedPorts, err := pf.GetPorts()
+	if err != nil || len(forwardedPorts) != len(portMappings) {
+		return nil, errors.Errorf("Error retrieving local ports of port forwarding: %v", err)
+	}
+
+	started = time.Now()
+	for index, forwardedPort := range forwardedPorts {
+		err := probeConnectionOnce(ctx.Context(), net.JoinHostPort("127.0.0.1", strconv.Itoa(int(forwardedPort.Local))))
+		if err != nil {
+			return nil, errors.Wrapf(err, "connect to port %s", portMappings[index].Port)
+		}
+	}
+
+	result.Connect = time.Since(started)
+	return result, nil
+}
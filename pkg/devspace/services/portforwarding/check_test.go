@@ -0,0 +1,56 @@
+package portforwarding
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckPortForwarding(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	oldNewPortForwarder := newPortForwarder
+	defer func() { newPortForwarder = oldNewPortForwarder }()
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		assert.DeepEqual(t, ports, []string{"0:8080"})
+		return &echoForwarder{readyChan: readyChan, port: listener.Addr().(*net.TCPAddr).Port}, nil
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	result, err := CheckPortForwarding(ctx, []*latest.PortMapping{{Port: "8080"}}, selector, true)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Pod, "test/test")
+	assert.Assert(t, result.Connect > 0, "connection was not checked")
+
+	// a failing port forwarding is reported
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, err: errors.New("unable to forward"), closed: make(chan struct{})}, nil
+	}
+	_, err = CheckPortForwarding(ctx, []*latest.PortMapping{{Port: "8080"}}, selector, true)
+	assert.Error(t, err, "forward ports: unable to forward")
+}
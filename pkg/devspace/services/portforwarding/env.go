@@ -0,0 +1,124 @@
+package portforwarding
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+)
+
+// invalidEnvChars matches the characters that are not allowed in an environment variable name
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// portEnv exports the local ports of the forwarded ports of a dev pod as environment
+// variables and optionally writes them to a dotenv file
+type portEnv struct {
+	m sync.Mutex
+
+	devPod string
+	file   string
+	vars   map[string]string
+}
+
+func newPortEnv(devPod, file string) *portEnv {
+	return &portEnv{
+		devPod: devPod,
+		file:   file,
+		vars:   map[string]string{},
+	}
+}
+
+// portEnvName returns the environment variable name of the given remote port of a dev pod
+func portEnvName(devPod string, remotePort int) string {
+	return "DEVSPACE_PORT_" + invalidEnvChars.ReplaceAllString(strings.ToUpper(devPod), "_") + "_" + strconv.Itoa(remotePort)
+}
+
+// set exports the local ports of the given forwarded port mappings
+func (p *portEnv) set(ctx devspacecontext.Context, portMappings []*latest.PortMapping, forwardedPorts []portforward.ForwardedPort) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	// a remote port that is forwarded to multiple local ports exports the first one
+	exported := map[string]bool{}
+	for index, forwardedPort := range forwardedPorts {
+		if portMappings[index].Socket != "" {
+			continue
+		}
+
+		name := portEnvName(p.devPod, int(forwardedPort.Remote))
+		if exported[name] {
+			continue
+		}
+		exported[name] = true
+		value := strconv.Itoa(int(forwardedPort.Local))
+		p.vars[name] = value
+		_ = os.Setenv(name, value)
+	}
+
+	p.write(ctx)
+}
+
+// remove removes the exported local ports of the given port mappings
+func (p *portEnv) remove(ctx devspacecontext.Context, portMappings []*latest.PortMapping) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	for _, portMapping := range portMappings {
+		mappings, err := portforward.ParsePorts([]string{portMapping.Port})
+		if err != nil || portMapping.Socket != "" {
+			continue
+		}
+
+		name := portEnvName(p.devPod, int(mappings[0].Remote))
+		delete(p.vars, name)
+		_ = os.Unsetenv(name)
+	}
+
+	p.write(ctx)
+}
+
+// write writes the exported ports to the dotenv file. If there are no ports
+// exported anymore, the file is removed, the lock has to be held.
+func (p *portEnv) write(ctx devspacecontext.Context) {
+	if p.file == "" {
+		return
+	}
+
+	file := ctx.ResolvePath(p.file)
+	if len(p.vars) == 0 {
+		err := os.Remove(file)
+		if err != nil && !os.IsNotExist(err) {
+			ctx.Log().Warnf("Error removing ports env file %s: %v", file, err)
+		}
+		return
+	}
+
+	names := make([]string, 0, len(p.vars))
+	for name := range p.vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	content := ""
+	for _, name := range names {
+		content += name + "=" + p.vars[name] + "\n"
+	}
+
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err == nil {
+		err = os.WriteFile(file, []byte(content), 0644)
+	}
+	if err != nil {
+		ctx.Log().Warnf("Error writing ports env file %s: %v", file, err)
+		return
+	}
+
+	ctx.Log().Debugf("Wrote forwarded ports to %s", file)
+}
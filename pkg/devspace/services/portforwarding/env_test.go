@@ -0,0 +1,45 @@
+package portforwarding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+)
+
+func TestPortEnv(t *testing.T) {
+	assert.Equal(t, portEnvName("my-app", 8080), "DEVSPACE_PORT_MY_APP_8080")
+
+	file := filepath.Join(t.TempDir(), "env", "ports.env")
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard)
+	env := newPortEnv("my-app", file)
+	portMappings := []*latest.PortMapping{{Port: "0:8080"}, {Port: "9090"}, {Port: "3000", Socket: "/tmp/app.sock"}}
+	forwardedPorts, err := portforward.ParsePorts([]string{"54321:8080", "9090:9090", "54322:3000"})
+	assert.NilError(t, err)
+	defer env.remove(ctx, portMappings)
+
+	env.set(ctx, portMappings, forwardedPorts)
+	assert.Equal(t, os.Getenv("DEVSPACE_PORT_MY_APP_8080"), "54321")
+	assert.Equal(t, os.Getenv("DEVSPACE_PORT_MY_APP_9090"), "9090")
+	assert.Equal(t, os.Getenv("DEVSPACE_PORT_MY_APP_3000"), "")
+	content, err := os.ReadFile(file)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "DEVSPACE_PORT_MY_APP_8080=54321\nDEVSPACE_PORT_MY_APP_9090=9090\n")
+
+	env.remove(ctx, portMappings[:1])
+	_, ok := os.LookupEnv("DEVSPACE_PORT_MY_APP_8080")
+	assert.Assert(t, !ok, "environment variable was not removed")
+	content, err = os.ReadFile(file)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "DEVSPACE_PORT_MY_APP_9090=9090\n")
+
+	env.remove(ctx, portMappings[1:])
+	_, err = os.Stat(file)
+	assert.Assert(t, os.IsNotExist(err), "ports env file was not removed")
+}
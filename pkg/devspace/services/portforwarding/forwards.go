@@ -0,0 +1,375 @@
+package portforwarding
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/pkg/errors"
+)
+
+// Forwards holds the running port forwarding groups of a single dev pod. It allows
+// adding or removing port mappings while the dev pod is running.
+type Forwards struct {
+	m sync.Mutex
+
+	// updateM serializes starting and updating the groups, so that concurrent
+	// updates don't start the same port mappings twice. Unlike m it is held while
+	// groups are stopped or started, which can take until they are ready.
+	updateM sync.Mutex
+
+	ctx       devspacecontext.Context
+	name      string
+	namespace string
+	selector  targetselector.PodSelector
+	options   Options
+	parent    *tomb.Tomb
+
+	groups []*forwardGroup
+}
+
+// forwardGroup is a set of port mappings that share a single port forwarder.
+// A group can be stopped without affecting the other groups of the dev pod.
+type forwardGroup struct {
+	mappings []*latest.PortMapping
+
+	// noReconnect groups are not restarted and don't stop the dev pod if they stop on their own
+	noReconnect bool
+
+	t *tomb.Tomb
+
+	m       sync.Mutex
+	stopped bool
+}
+
+func newForwards(ctx devspacecontext.Context, name, namespace string, selector targetselector.PodSelector, options Options, parent *tomb.Tomb) *Forwards {
+	options.owner = parent
+	return &Forwards{
+		ctx:       ctx,
+		name:      name,
+		namespace: namespace,
+		selector:  selector,
+		options:   options,
+		parent:    parent,
+	}
+}
+
+// Mappings returns the port mappings that are currently forwarded
+func (f *Forwards) Mappings() []*latest.PortMapping {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	return f.currentMappings()
+}
+
+// Update changes the forwarded port mappings to the given ones. Mappings that have not
+// changed keep running, unless they share a port forwarder with a removed mapping, in which
+// case they are restarted together with the other added mappings.
+func (f *Forwards) Update(portMappings []*latest.PortMapping) error {
+	f.updateM.Lock()
+	defer f.updateM.Unlock()
+
+	if f.ctx.IsDone() {
+		return nil
+	}
+
+	portMappings = filterPortMappings(f.ctx, portMappings, f.options.OnlyPorts)
+	toStop, toStart := f.diff(portMappings)
+
+	// stopping and starting the groups can take a while, so it is done without holding
+	// f.m and Mappings and Tombs don't block until the groups are ready
+	for _, g := range toStop {
+		g.stop()
+	}
+	if len(toStart) == 0 {
+		return nil
+	}
+
+	for _, m := range toStart {
+		f.ctx.Log().Infof("Start port forwarding %s", m.Port)
+	}
+	return f.start(toStart)
+}
+
+// diff removes the groups with mappings that are not part of the given port mappings
+// anymore and returns them together with the port mappings that need to be started
+func (f *Forwards) diff(portMappings []*latest.PortMapping) ([]*forwardGroup, []*latest.PortMapping) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	toStart := []*latest.PortMapping{}
+	for _, m := range portMappings {
+		if !containsMapping(f.currentMappings(), m) {
+			toStart = append(toStart, m)
+		}
+	}
+
+	groups := []*forwardGroup{}
+	toStop := []*forwardGroup{}
+	for _, g := range f.groups {
+		removed := false
+		for _, m := range g.mappings {
+			if !containsMapping(portMappings, m) {
+				f.ctx.Log().Infof("Stop port forwarding %s", m.Port)
+				removed = true
+			}
+		}
+		if !removed {
+			groups = append(groups, g)
+			continue
+		}
+
+		// restart the mappings that are still needed
+		toStop = append(toStop, g)
+		for _, m := range g.mappings {
+			if containsMapping(portMappings, m) {
+				toStart = append(toStart, m)
+			}
+		}
+	}
+	f.groups = groups
+	return toStop, toStart
+}
+
+// Tombs returns the tombs of the running port forwarding groups
+func (f *Forwards) Tombs() []*tomb.Tomb {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	tombs := []*tomb.Tomb{}
+	for _, g := range f.groups {
+		tombs = append(tombs, g.t)
+	}
+	return tombs
+}
+
+func (f *Forwards) currentMappings() []*latest.PortMapping {
+	mappings := []*latest.PortMapping{}
+	for _, g := range f.groups {
+		mappings = append(mappings, g.mappings...)
+	}
+	return mappings
+}
+
+// start starts the given port mappings in a new group. Port mappings with noReconnect
+// are started in a group of their own, so that the other port mappings keep running
+// when they stop. f.m must not be held, because starting waits until the port forwarding
+// is ready.
+func (f *Forwards) start(portMappings []*latest.PortMapping) error {
+	err := checkProtocols(portMappings)
+	if err != nil {
+		return errors.Wrapf(err, "dev %s", f.name)
+	}
+
+	shared := []*latest.PortMapping{}
+	for _, m := range portMappings {
+		if !m.NoReconnect {
+			shared = append(shared, m)
+			continue
+		}
+
+		err := f.startGroup([]*latest.PortMapping{m}, true)
+		if err != nil {
+			return err
+		}
+	}
+	if len(shared) == 0 {
+		return nil
+	}
+
+	return f.startGroup(shared, false)
+}
+
+// startGroup starts the given port mappings in a new group. If the group stops on its
+// own, e.g. because the pod has a problem, the parent tomb is killed as well, unless
+// it is a noReconnect group, which is removed instead.
+func (f *Forwards) startGroup(portMappings []*latest.PortMapping, noReconnect bool) error {
+	g := &forwardGroup{
+		mappings:    portMappings,
+		noReconnect: noReconnect,
+		t:           &tomb.Tomb{},
+	}
+	ctx := f.ctx.WithContext(g.t.Context(f.ctx.Context()))
+	options := f.options
+	options.noReconnect = noReconnect
+
+	// port mappings with a label selector are forwarded to a different pod
+	// and therefore need their own port forwarder
+	targets := []podTarget{}
+	eager := map[podTarget][]*latest.PortMapping{}
+	lazy := map[podTarget][]*latest.PortMapping{}
+	balanced := map[podTarget][]*latest.PortMapping{}
+	services := []string{}
+	serviceMappings := map[string][]*latest.PortMapping{}
+	for _, m := range portMappings {
+		if m.Service != "" {
+			if _, ok := serviceMappings[m.Service]; !ok {
+				services = append(services, m.Service)
+			}
+			serviceMappings[m.Service] = append(serviceMappings[m.Service], m)
+			continue
+		}
+
+		target := podTarget{labelSelector: m.LabelSelector, annotationSelector: m.AnnotationSelector, allowUnready: m.AllowUnready}
+		_, isEager := eager[target]
+		_, isLazy := lazy[target]
+		_, isBalanced := balanced[target]
+		if !isEager && !isLazy && !isBalanced {
+			targets = append(targets, target)
+		}
+		if m.AllPods {
+			balanced[target] = append(balanced[target], m)
+		} else if m.Lazy {
+			lazy[target] = append(lazy[target], m)
+		} else {
+			eager[target] = append(eager[target], m)
+		}
+	}
+
+	var err error
+	<-g.t.NotifyGoNamed("start", func() error {
+		for _, target := range targets {
+			selector := f.selectorFor(target)
+			if len(lazy[target]) > 0 {
+				err = withStartHooks(ctx, f.name, lazy[target], func() error {
+					return startLazyForwarding(ctx, f.name, lazy[target], selector, options, g.t)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if len(balanced[target]) > 0 {
+				err = withStartHooks(ctx, f.name, balanced[target], func() error {
+					return startBalancedForwarding(ctx, f.name, balanced[target], f.namespace, target.labelSelector, "", options, g.t)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if len(eager[target]) > 0 {
+				err = startPortForwardingWithHooks(ctx, f.name, eager[target], selector, options, g.t)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		for _, service := range services {
+			err = withStartHooks(ctx, f.name, serviceMappings[service], func() error {
+				return startBalancedForwarding(ctx, f.name, serviceMappings[service], f.namespace, "", service, options, g.t)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the group is added before the supervisor starts, so that a group that stops right
+	// away is removed again
+	f.m.Lock()
+	f.groups = append(f.groups, g)
+	f.m.Unlock()
+	started := f.parent.TryGoNamed("forward supervisor", func() error {
+		<-g.t.Dead()
+		if g.isStopped() {
+			return nil
+		} else if g.noReconnect && g.t.Err() == nil {
+			f.remove(g)
+			return nil
+		}
+
+		f.parent.Kill(g.t.Err())
+		return nil
+	})
+	if !started {
+		f.ctx.Log().Debugf("Skipped port forwarding of dev %s, because it is shutting down", f.name)
+		f.remove(g)
+		g.stop()
+		return nil
+	}
+
+	return nil
+}
+
+// remove removes the group from the running groups, so that its port mappings can be started again
+func (f *Forwards) remove(g *forwardGroup) {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	groups := []*forwardGroup{}
+	for _, other := range f.groups {
+		if other != g {
+			groups = append(groups, other)
+		}
+	}
+	f.groups = groups
+}
+
+// podTarget describes the pod port mappings are forwarded to, port mappings with the same
+// target share a port forwarder
+type podTarget struct {
+	labelSelector      string
+	annotationSelector string
+	allowUnready       bool
+}
+
+// selectorFor returns the selector of the dev pod or, if a label selector is given, a selector for
+// the newest running pod matching it in the namespace of the dev pod. If an annotation selector is
+// given as well, only the pod with the annotation is selected. If unready pods are allowed, the
+// newest pod is selected in whatever state it is.
+func (f *Forwards) selectorFor(target podTarget) targetselector.PodSelector {
+	if target.labelSelector == "" {
+		return f.selector
+	}
+
+	options := targetselector.NewEmptyOptions().
+		WithLabelSelector(target.labelSelector).
+		WithNamespace(f.namespace).
+		WithWaitingStrategy(targetselector.NewUntilNewestRunningWaitingStrategy(time.Millisecond * 500)).
+		WithSkipInitContainers(true)
+	if key, value, ok := strings.Cut(target.annotationSelector, "="); ok {
+		options = options.WithAnnotation(key, value)
+	}
+	if target.allowUnready {
+		options = options.
+			WithContainerFilter(nil).
+			WithWaitingStrategy(targetselector.NewUntilAnyWaitingStrategy(0))
+	}
+
+	return targetselector.NewTargetSelector(options)
+}
+
+func (g *forwardGroup) isStopped() bool {
+	g.m.Lock()
+	defer g.m.Unlock()
+
+	return g.stopped
+}
+
+// stop stops the port forwarding of the group and waits until it is stopped
+func (g *forwardGroup) stop() {
+	g.m.Lock()
+	g.stopped = true
+	g.m.Unlock()
+
+	g.t.Kill(ErrStopRequested)
+	<-g.t.Dead()
+}
+
+func containsMapping(portMappings []*latest.PortMapping, m *latest.PortMapping) bool {
+	for _, p := range portMappings {
+		if reflect.DeepEqual(p, m) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,260 @@
+package portforwarding
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// recordingForwarders records the fake forwarders that were created by their first port
+type recordingForwarders struct {
+	m          sync.Mutex
+	forwarders map[string]*fakeForwarder
+}
+
+func (r *recordingForwarders) get(port string) *fakeForwarder {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return r.forwarders[port]
+}
+
+func (r *recordingForwarders) closed(port string) bool {
+	select {
+	case <-r.get(port).closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestForwardsUpdate(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	defer func() { newPortForwarder = oldNewPortForwarder }()
+
+	recorded := &recordingForwarders{forwarders: map[string]*fakeForwarder{}}
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		pf := &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, ready: true, closed: make(chan struct{})}
+		recorded.m.Lock()
+		recorded.forwarders[ports[0]] = pf
+		recorded.m.Unlock()
+		return pf, nil
+	}
+
+	out := &bytes.Buffer{}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat)).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	parent := &tomb.Tomb{}
+	parent.Go(func() error {
+		<-parent.Dying()
+		return nil
+	})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	forwards := newForwards(ctx, "test", "test", selector, Options{}, parent)
+
+	err := forwards.start([]*latest.PortMapping{{Port: "0:8080"}})
+	assert.NilError(t, err)
+	first := recorded.get("0:8080")
+
+	// adding a mapping keeps the running one
+	err = forwards.Update([]*latest.PortMapping{{Port: "0:8080"}, {Port: "0:9090"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, forwards.Mappings(), []*latest.PortMapping{{Port: "0:8080"}, {Port: "0:9090"}})
+	assert.Equal(t, recorded.get("0:8080"), first, "unchanged mapping was restarted")
+	assert.Assert(t, !recorded.closed("0:8080"), "unchanged mapping was stopped")
+	assert.Assert(t, recorded.get("0:9090") != nil, "added mapping was not started")
+
+	// removing a mapping stops only its port forwarding
+	err = forwards.Update([]*latest.PortMapping{{Port: "0:9090"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, forwards.Mappings(), []*latest.PortMapping{{Port: "0:9090"}})
+	assert.Assert(t, recorded.closed("0:8080"), "removed mapping is still forwarded")
+	assert.Assert(t, !recorded.closed("0:9090"), "remaining mapping was stopped")
+	assert.Assert(t, strings.Contains(out.String(), "(port mappings changed)"), out.String())
+	assert.Assert(t, !strings.Contains(out.String(), "shutdown requested"), out.String())
+
+	// changing a mapping restarts it with the new config
+	err = forwards.Update([]*latest.PortMapping{{Port: "0:9091"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, forwards.Mappings(), []*latest.PortMapping{{Port: "0:9091"}})
+	assert.Assert(t, recorded.closed("0:9090"), "changed mapping is still forwarded with the old config")
+	assert.Assert(t, recorded.get("0:9091") != nil, "changed mapping was not started")
+	assert.Equal(t, len(forwards.Tombs()), 1)
+	assert.Assert(t, parent.Alive(), "updating the mappings stopped the dev pod")
+
+	cancel()
+	parent.Kill(nil)
+	_ = parent.Wait()
+}
+
+func TestForwardsRestartRequested(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, ready: true, closed: make(chan struct{})}, nil
+	}
+	reasons := make(chan interface{}, 1)
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		if events[0] == "stop:portForwarding:*" {
+			reasons <- extraEnv["stop_reason"]
+		}
+	}
+
+	out := &bytes.Buffer{}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parent := &tomb.Tomb{}
+	parent.Go(func() error {
+		<-parent.Dying()
+		return nil
+	})
+	ctx := devspacecontext.NewContext(parent.Context(cancelCtx), nil, log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat)).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	forwards := newForwards(ctx, "test", "test", selector, Options{}, parent)
+	err := forwards.start([]*latest.PortMapping{{Port: "0:8080"}})
+	assert.NilError(t, err)
+
+	// the port forwardings of the dev pod are restarted by killing their tomb
+	parent.Kill(ErrRestartRequested)
+	_ = parent.Wait()
+	select {
+	case reason := <-reasons:
+		assert.Equal(t, reason, string(StopReasonRestart))
+	case <-time.After(time.Second * 5):
+		t.Fatal("stop:portForwarding hooks were not executed")
+	}
+	assert.Assert(t, strings.Contains(out.String(), "(restart requested)"), out.String())
+	assert.Assert(t, !strings.Contains(out.String(), "shutdown requested"), out.String())
+}
+
+func TestForwardsLazyHooks(t *testing.T) {
+	oldExecuteHooksFunc := executeHooksFunc
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		executeHooksFunc = oldExecuteHooksFunc
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	m := sync.Mutex{}
+	executed := map[string]map[string]interface{}{}
+	record := func(extraEnv map[string]interface{}, events []string) {
+		m.Lock()
+		defer m.Unlock()
+		executed[events[0]] = extraEnv
+	}
+	executeHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) error {
+		record(extraEnv, events)
+		return nil
+	}
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		record(extraEnv, events)
+	}
+
+	out := &bytes.Buffer{}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parent := &tomb.Tomb{}
+	parent.Go(func() error {
+		<-parent.Dying()
+		return nil
+	})
+	ctx := devspacecontext.NewContext(parent.Context(cancelCtx), nil, log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat)).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	forwards := newForwards(ctx, "lazy-hooks", "test", selector, Options{env: newPortEnv("lazy-hooks", "")}, parent)
+
+	// lazy port forwardings only listen, so they are ready right away
+	err := forwards.start([]*latest.PortMapping{{Port: "0:8080", BindAddress: "127.0.0.1", Lazy: true, PrintURL: true}})
+	assert.NilError(t, err)
+	localPort := os.Getenv("DEVSPACE_PORT_LAZY_HOOKS_8080")
+	assert.Assert(t, localPort != "" && localPort != "0", "local port was not exported")
+	assert.Assert(t, strings.Contains(out.String(), "http://127.0.0.1:"+localPort), out.String())
+	m.Lock()
+	assert.Assert(t, executed["start:portForwarding:*"] != nil, "start hooks were not executed")
+	ready := executed["ready:portForwarding:*"]
+	m.Unlock()
+	assert.Assert(t, ready != nil, "ready hooks were not executed")
+	assert.Equal(t, strconv.Itoa(ready["port_forwarding_ports"].([]resolvedPort)[0].LocalPort), localPort)
+
+	parent.Kill(nil)
+	_ = parent.Wait()
+	m.Lock()
+	stopped := executed["stop:portForwarding:*"]
+	m.Unlock()
+	assert.Assert(t, stopped != nil, "stop hooks were not executed")
+	assert.Equal(t, stopped["stop_reason"], string(StopReasonCancelled))
+	assert.Equal(t, os.Getenv("DEVSPACE_PORT_LAZY_HOOKS_8080"), "", "local port is still exported")
+}
+
+func TestForwardsUpdateDoesNotBlockMappings(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	oldReadyTimeout := readyTimeout
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		readyTimeout = oldReadyTimeout
+	}()
+	readyTimeout = time.Second * 5
+
+	created := make(chan struct{}, 1)
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		created <- struct{}{}
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, closed: make(chan struct{})}, nil
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	parent := &tomb.Tomb{}
+	parent.Go(func() error {
+		<-parent.Dying()
+		return nil
+	})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	forwards := newForwards(ctx, "test", "test", selector, Options{}, parent)
+
+	// the forwarder never becomes ready, so the update waits for the ready timeout
+	updated := make(chan struct{})
+	go func() {
+		defer close(updated)
+		_ = forwards.Update([]*latest.PortMapping{{Port: "0:8080"}})
+	}()
+	<-created
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		forwards.Mappings()
+		forwards.Tombs()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("mappings are blocked while a port forwarding is starting")
+	}
+
+	cancel()
+	<-updated
+	parent.Kill(nil)
+	_ = parent.Wait()
+}
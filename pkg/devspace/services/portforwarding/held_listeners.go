@@ -0,0 +1,262 @@
+package portforwarding
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/loft-sh/devspace/pkg/util/backoff"
+)
+
+// listenerHoldTimeout returns the maximum time a local listener is kept bound after its port
+// forwarder was closed. It covers the reconnect grace period, the longest delay before the next
+// restart attempt and the time that attempt has to become ready. Permission errors are not
+// covered, because they usually don't resolve themselves quickly. If the port forwarding wasn't
+// restarted until then, the listener is closed.
+func listenerHoldTimeout(options Options) time.Duration {
+	gracePeriod := options.ReconnectGracePeriod
+	if gracePeriod == 0 {
+		gracePeriod = defaultReconnectGracePeriod
+	} else if gracePeriod < 0 {
+		gracePeriod = 0
+	}
+
+	restartBackoff := backoff.New(defaultRestartDelay).Merge(options.RestartBackoff)
+	maxDelay := time.Duration(0)
+	for _, class := range []backoff.Class{backoff.ClassDefault, backoff.ClassConnectionLost, backoff.ClassSelector} {
+		b := restartBackoff.Get(class)
+		if b.Max > maxDelay {
+			maxDelay = b.Max
+		}
+		if b.Initial > maxDelay {
+			maxDelay = b.Initial
+		}
+	}
+
+	return gracePeriod + maxDelay + readyTimeout
+}
+
+// heldListeners keeps the local listeners of a port forwarding bound while it reconnects. Instead of
+// being refused, connections that are made in the meantime are queued by the listener and served
+// by the next port forwarder, which gets the same listener if it listens on the same address.
+type heldListeners struct {
+	m sync.Mutex
+
+	// listen creates the actual listeners, defaults to net.Listen
+	listen    portforward.ListenFunc
+	listeners map[string]*heldListener
+
+	// holdTimeout is the maximum time a listener is kept bound after it was closed
+	holdTimeout time.Duration
+}
+
+func newHeldListeners(listen portforward.ListenFunc, holdTimeout time.Duration) *heldListeners {
+	return &heldListeners{
+		listen:      listen,
+		listeners:   map[string]*heldListener{},
+		holdTimeout: holdTimeout,
+	}
+}
+
+// Listen returns the held listener of the address or creates a new one. Listeners on dynamic ports
+// and listeners without deadline support are not held, because they cannot be handed over.
+func (h *heldListeners) Listen(network, address string) (net.Listener, error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	key := network + " " + address
+	if l, ok := h.listeners[key]; ok && l.reattach() {
+		return l, nil
+	}
+
+	listen := h.listen
+	if listen == nil {
+		listen = net.Listen
+	}
+	listener, err := listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	deadliner, ok := listener.(deadlineListener)
+	_, port, _ := net.SplitHostPort(address)
+	if !ok || port == "0" {
+		return listener, nil
+	}
+
+	l := &heldListener{
+		Listener: listener,
+		deadline: deadliner,
+		owner:    h,
+		key:      key,
+	}
+	h.listeners[key] = l
+	return l, nil
+}
+
+// closeDetached closes the held listeners that were not taken over by a new port forwarder
+func (h *heldListeners) closeDetached() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for key, l := range h.listeners {
+		if l.isDetached() {
+			delete(h.listeners, key)
+			l.closeNow()
+		}
+	}
+}
+
+// close closes all held listeners, after that new listeners can still be created
+func (h *heldListeners) close() {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for key, l := range h.listeners {
+		delete(h.listeners, key)
+		l.closeNow()
+	}
+}
+
+// release closes the listener if it wasn't taken over in the meantime
+func (h *heldListeners) release(l *heldListener) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if !l.isDetached() {
+		return
+	} else if h.listeners[l.key] == l {
+		delete(h.listeners, l.key)
+	}
+	l.closeNow()
+}
+
+type deadlineListener interface {
+	SetDeadline(t time.Time) error
+}
+
+// heldListener is a listener that is only detached from its port forwarder on Close. Pending
+// accepts of the detached port forwarder are woken up through the deadline of the listener.
+type heldListener struct {
+	net.Listener
+
+	deadline deadlineListener
+	owner    *heldListeners
+	key      string
+
+	m          sync.Mutex
+	generation int
+	detached   bool
+	closed     bool
+	pending    []net.Conn
+	timer      *time.Timer
+}
+
+func (l *heldListener) Accept() (net.Conn, error) {
+	l.m.Lock()
+	generation := l.generation
+	l.m.Unlock()
+
+	for {
+		l.m.Lock()
+		if l.closed || l.detached || l.generation != generation {
+			l.m.Unlock()
+			return nil, net.ErrClosed
+		} else if len(l.pending) > 0 {
+			conn := l.pending[0]
+			l.pending = l.pending[1:]
+			l.m.Unlock()
+			return conn, nil
+		}
+		l.m.Unlock()
+
+		conn, err := l.Listener.Accept()
+		l.m.Lock()
+		if l.closed {
+			l.m.Unlock()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return nil, net.ErrClosed
+		} else if l.detached || l.generation != generation {
+			// the connection was made while reconnecting and belongs to the next port forwarder
+			if err == nil {
+				l.pending = append(l.pending, conn)
+				_ = l.deadline.SetDeadline(time.Now())
+			}
+			l.m.Unlock()
+			return nil, net.ErrClosed
+		} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			// woken up to pick up a pending connection
+			_ = l.deadline.SetDeadline(time.Time{})
+			l.m.Unlock()
+			continue
+		}
+		l.m.Unlock()
+
+		return conn, err
+	}
+}
+
+// Close detaches the listener from its port forwarder, but keeps it bound until it is
+// taken over by a new port forwarder or the hold timeout is reached
+func (l *heldListener) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.closed || l.detached {
+		return nil
+	}
+
+	l.detached = true
+	_ = l.deadline.SetDeadline(time.Now())
+	l.timer = time.AfterFunc(l.owner.holdTimeout, func() {
+		l.owner.release(l)
+	})
+	return nil
+}
+
+// reattach hands the listener over to a new port forwarder and returns false if it is closed already
+func (l *heldListener) reattach() bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.closed {
+		return false
+	} else if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+
+	l.detached = false
+	l.generation++
+	_ = l.deadline.SetDeadline(time.Time{})
+	return true
+}
+
+func (l *heldListener) isDetached() bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.detached
+}
+
+func (l *heldListener) closeNow() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.closed {
+		return
+	}
+
+	l.closed = true
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	for _, conn := range l.pending {
+		_ = conn.Close()
+	}
+	l.pending = nil
+	_ = l.Listener.Close()
+}
@@ -0,0 +1,101 @@
+package portforwarding
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/util/backoff"
+	"gotest.tools/assert"
+)
+
+func TestHeldListeners(t *testing.T) {
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	address := free.Addr().String()
+	assert.NilError(t, free.Close())
+
+	held := newHeldListeners(nil, time.Minute)
+	first, err := held.Listen("tcp", address)
+	assert.NilError(t, err)
+	accepted := make(chan error, 1)
+	go func() {
+		_, err := first.Accept()
+		accepted <- err
+	}()
+
+	// closing detaches the port forwarder, but the port stays bound
+	assert.NilError(t, first.Close())
+	assert.Assert(t, errors.Is(<-accepted, net.ErrClosed), "accept of the closed port forwarder should return")
+	conn, err := net.DialTimeout("tcp", address, time.Second)
+	assert.NilError(t, err, "connection while reconnecting should not be refused")
+	defer conn.Close()
+
+	// the next port forwarder gets the same listener and the queued connection
+	second, err := held.Listen("tcp", address)
+	assert.NilError(t, err)
+	assert.Equal(t, second, first)
+	served, err := second.Accept()
+	assert.NilError(t, err)
+	_, err = conn.Write([]byte("x"))
+	assert.NilError(t, err)
+	buf := make([]byte, 1)
+	_, err = served.Read(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "x")
+	served.Close()
+
+	// closing the held listeners frees the port
+	held.close()
+	_, err = net.DialTimeout("tcp", address, time.Second)
+	assert.Assert(t, err != nil, "port should not be bound anymore")
+
+	// dynamic local ports are not held
+	dynamic, err := held.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer dynamic.Close()
+	_, ok := dynamic.(*heldListener)
+	assert.Assert(t, !ok, "dynamic port should not be held")
+}
+
+func TestHeldListenerTimeout(t *testing.T) {
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	address := free.Addr().String()
+	assert.NilError(t, free.Close())
+
+	held := newHeldListeners(nil, time.Millisecond*50)
+	listener, err := held.Listen("tcp", address)
+	assert.NilError(t, err)
+	assert.NilError(t, listener.Close())
+
+	// the port is freed if the port forwarding isn't restarted in time
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		assert.Assert(t, time.Now().Before(deadline), "port is still bound after the hold timeout")
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	next, err := held.Listen("tcp", address)
+	assert.NilError(t, err)
+	assert.Assert(t, next != listener, "expected a new listener after the hold timeout")
+	held.close()
+}
+
+func TestListenerHoldTimeout(t *testing.T) {
+	assert.Equal(t, listenerHoldTimeout(Options{}), defaultReconnectGracePeriod+defaultRestartDelay+readyTimeout)
+	assert.Equal(t, listenerHoldTimeout(Options{ReconnectGracePeriod: -1}), defaultRestartDelay+readyTimeout)
+
+	// a longer backoff keeps the listeners bound until the next attempt
+	options := Options{
+		ReconnectGracePeriod: time.Second,
+		RestartBackoff:       backoff.Config{backoff.ClassSelector: {Initial: time.Second * 5, Max: time.Minute, Factor: 2}},
+	}
+	assert.Equal(t, listenerHoldTimeout(options), time.Second+time.Minute+readyTimeout)
+}
@@ -0,0 +1,105 @@
+package portforwarding
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/hook"
+)
+
+// stopHookGracePeriod is how long stop hooks may run after the port forwarding was cancelled
+var stopHookGracePeriod = time.Second * 5
+
+var (
+	executeHooksFunc    = hook.ExecuteHooks
+	logExecuteHooksFunc = hook.LogExecuteHooks
+)
+
+// executeHooks executes the hooks of the given events of the dev pod unless the context is done already,
+// because the port forwarding is shutting down in that case and the hooks would race with it
+func executeHooks(ctx devspacecontext.Context, name string, extraEnv map[string]interface{}, events ...string) error {
+	if ctx.IsDone() {
+		ctx.Log().Debugf("Skip hooks %v, because port forwarding is stopping", events)
+		return nil
+	}
+
+	return executeHooksFunc(ctx, withDevPodLabels(ctx, name, extraEnv), events...)
+}
+
+// logExecuteHooks is like executeHooks, but prints errors to the log
+func logExecuteHooks(ctx devspacecontext.Context, name string, extraEnv map[string]interface{}, events ...string) {
+	if ctx.IsDone() {
+		ctx.Log().Debugf("Skip hooks %v, because port forwarding is stopping", events)
+		return
+	}
+
+	logExecuteHooksFunc(ctx, withDevPodLabels(ctx, name, extraEnv), events...)
+}
+
+// withDevPodLabels returns a copy of the hook data with the configured labels of the dev pod,
+// so that hooks can tell dev pods apart, e.g. frontend and backend ones, without knowing their names
+func withDevPodLabels(ctx devspacecontext.Context, name string, extraEnv map[string]interface{}) map[string]interface{} {
+	if ctx.Config() == nil || ctx.Config().Config() == nil || ctx.Config().Config().Dev[name] == nil {
+		return extraEnv
+	}
+
+	labels := ctx.Config().Config().Dev[name].Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range extraEnv {
+		data[k] = v
+	}
+	data["dev_pod_labels"] = labels
+	return data
+}
+
+// hookSummary returns a human readable summary of a port forwarding event that hooks can show
+// without reconstructing it, e.g. as desktop notification
+func hookSummary(kind, name string, portMappings []*latest.PortMapping, event string) string {
+	ports := []string{}
+	for _, portMapping := range portMappings {
+		ports = append(ports, portMapping.Port)
+	}
+
+	return kind + " " + strings.Join(ports, ", ") + " of dev " + name + " " + event
+}
+
+// reverseHookSummary is like hookSummary for a reverse port forwarding
+func reverseHookSummary(name string, portMappings []*latest.ReversePortMapping, event string) string {
+	ports := []string{}
+	for _, portMapping := range portMappings {
+		ports = append(ports, portMapping.Port)
+	}
+
+	return "Reverse port forwarding " + strings.Join(ports, ", ") + " of dev " + name + " " + event
+}
+
+// logExecuteStopHooks executes the stop hooks of the given events. Stop hooks always run,
+// if the context is done already they get a short grace period to finish.
+func logExecuteStopHooks(ctx devspacecontext.Context, name string, extraEnv map[string]interface{}, events ...string) {
+	if ctx.IsDone() {
+		graceCtx, cancel := context.WithTimeout(detachedContext{ctx.Context()}, stopHookGracePeriod)
+		defer cancel()
+
+		ctx = ctx.WithContext(graceCtx)
+	}
+
+	logExecuteHooksFunc(ctx, withDevPodLabels(ctx, name, extraEnv), events...)
+}
+
+// detachedContext keeps the values of the wrapped context, but is never cancelled
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
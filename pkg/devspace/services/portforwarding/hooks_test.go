@@ -0,0 +1,92 @@
+package portforwarding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config"
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+)
+
+type contextKey string
+
+type executedHook struct {
+	done        bool
+	hasDeadline bool
+	value       interface{}
+}
+
+func recordHook(ctx devspacecontext.Context) executedHook {
+	_, hasDeadline := ctx.Context().Deadline()
+	return executedHook{
+		done:        ctx.IsDone(),
+		hasDeadline: hasDeadline,
+		value:       ctx.Context().Value(contextKey("key")),
+	}
+}
+
+func TestHooksWithCancelledContext(t *testing.T) {
+	oldExecuteHooksFunc := executeHooksFunc
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		executeHooksFunc = oldExecuteHooksFunc
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	executed := []executedHook{}
+	executeHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) error {
+		executed = append(executed, recordHook(ctx))
+		return nil
+	}
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		executed = append(executed, recordHook(ctx))
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey("key"), "value"))
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard)
+	assert.NilError(t, executeHooks(ctx, "test", nil, "start:portForwarding"))
+	logExecuteStopHooks(ctx, "test", nil, "stop:portForwarding")
+	assert.Equal(t, len(executed), 2)
+	assert.Assert(t, !executed[1].hasDeadline, "stop hooks should use the original context while it is not done")
+
+	// hooks are skipped after the context was cancelled
+	cancel()
+	executed = executed[:0]
+	assert.NilError(t, executeHooks(ctx, "test", nil, "error:portForwarding"))
+	logExecuteHooks(ctx, "test", nil, "restart:portForwarding")
+	assert.Equal(t, len(executed), 0)
+
+	// stop hooks still run with a grace context that keeps the values
+	logExecuteStopHooks(ctx, "test", nil, "stop:portForwarding")
+	assert.Equal(t, len(executed), 1)
+	assert.Assert(t, !executed[0].done, "stop hooks should run with a context that is not done")
+	assert.Assert(t, executed[0].hasDeadline, "grace context should have a deadline")
+	assert.Equal(t, executed[0].value, "value")
+}
+
+func TestHooksDevPodLabels(t *testing.T) {
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() { logExecuteHooksFunc = oldLogExecuteHooksFunc }()
+
+	payloads := []map[string]interface{}{}
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		payloads = append(payloads, extraEnv)
+	}
+
+	conf := config.NewConfig(nil, nil, &latest.Config{Dev: map[string]*latest.DevPod{
+		"frontend": {Name: "frontend", Labels: map[string]string{"tier": "frontend"}},
+		"backend":  {Name: "backend"},
+	}}, nil, nil, nil, "")
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithConfig(conf)
+
+	extraEnv := map[string]interface{}{"port_forwarding_config": "config"}
+	logExecuteHooks(ctx, "frontend", extraEnv, "ready:portForwarding")
+	logExecuteStopHooks(ctx, "backend", extraEnv, "stop:portForwarding")
+	assert.Equal(t, len(payloads), 2)
+	assert.DeepEqual(t, payloads[0], map[string]interface{}{"port_forwarding_config": "config", "dev_pod_labels": map[string]string{"tier": "frontend"}})
+	assert.DeepEqual(t, payloads[1], map[string]interface{}{"port_forwarding_config": "config", "dev_pod_labels": map[string]string{}})
+	assert.Equal(t, len(extraEnv), 1, "the hook data of the caller should not be changed")
+}
@@ -0,0 +1,144 @@
+package portforwarding
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/mgutz/ansi"
+	"github.com/pkg/errors"
+)
+
+const hostsMarker = "# added by devspace"
+
+var (
+	// hostsFile is the path to the hosts file of the local machine
+	hostsFile = defaultHostsFile()
+
+	hostsMutex sync.Mutex
+)
+
+func defaultHostsFile() string {
+	if runtime.GOOS == "windows" {
+		return os.Getenv("SystemRoot") + `\System32\drivers\etc\hosts`
+	}
+
+	return "/etc/hosts"
+}
+
+// addHostsEntries adds the hostnames of the port mappings to the local hosts file
+func addHostsEntries(ctx devspacecontext.Context, portMappings []*latest.PortMapping) {
+	for _, portMapping := range portMappings {
+		if portMapping.Hostname == "" {
+			continue
+		}
+
+		ctx.Log().Infof("Add hostname %s to hosts file %s", ansi.Color(portMapping.Hostname, "white+b"), hostsFile)
+		err := addHostsEntry(portMapping.Hostname, hostIP(portMapping))
+		if err != nil {
+			ctx.Log().Warnf("Error adding hostname %s: %v", portMapping.Hostname, err)
+		}
+	}
+}
+
+// removeHostsEntries removes the hostnames of the port mappings from the local hosts file
+func removeHostsEntries(ctx devspacecontext.Context, portMappings []*latest.PortMapping) {
+	for _, portMapping := range portMappings {
+		if portMapping.Hostname == "" {
+			continue
+		}
+
+		err := removeHostsEntry(portMapping.Hostname)
+		if err != nil {
+			ctx.Log().Warnf("Error removing hostname %s from hosts file: %v", portMapping.Hostname, err)
+		} else {
+			ctx.Log().Infof("Removed hostname %s from hosts file %s", portMapping.Hostname, hostsFile)
+		}
+	}
+}
+
+// addHostsEntry maps the hostname to the given ip in the local hosts file. Existing
+// entries that were added by DevSpace for the same hostname are replaced.
+func addHostsEntry(hostname, ip string) error {
+	hostsMutex.Lock()
+	defer hostsMutex.Unlock()
+
+	lines, newline, err := readHostsFile()
+	if err != nil {
+		return err
+	}
+
+	lines = append(removeHostsLines(lines, hostname), ip+" "+hostname+" "+hostsMarker)
+	return writeHostsFile(lines, newline)
+}
+
+// removeHostsEntry removes the entries that were added by DevSpace for the hostname
+// from the local hosts file
+func removeHostsEntry(hostname string) error {
+	hostsMutex.Lock()
+	defer hostsMutex.Unlock()
+
+	lines, newline, err := readHostsFile()
+	if err != nil {
+		return err
+	}
+
+	newLines := removeHostsLines(lines, hostname)
+	if len(newLines) == len(lines) {
+		return nil
+	}
+
+	return writeHostsFile(newLines, newline)
+}
+
+func removeHostsLines(lines []string, hostname string) []string {
+	newLines := []string{}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if strings.HasSuffix(strings.TrimRight(line, "\r"), hostsMarker) && len(fields) > 1 && fields[1] == hostname {
+			continue
+		}
+
+		newLines = append(newLines, line)
+	}
+
+	return newLines
+}
+
+// readHostsFile returns the lines of the hosts file without their line endings and the
+// line ending of the file, which is \r\n for hosts files edited on windows
+func readHostsFile() ([]string, string, error) {
+	out, err := os.ReadFile(hostsFile)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "read hosts file")
+	}
+
+	newline := "\n"
+	if strings.Contains(string(out), "\r\n") {
+		newline = "\r\n"
+	}
+
+	lines := strings.Split(strings.TrimRight(string(out), "\r\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+
+	return lines, newline, nil
+}
+
+func writeHostsFile(lines []string, newline string) error {
+	stat, err := os.Stat(hostsFile)
+	if err != nil {
+		return errors.Wrap(err, "stat hosts file")
+	}
+
+	err = os.WriteFile(hostsFile, []byte(strings.Join(lines, newline)+newline), stat.Mode())
+	if err != nil {
+		return errors.Wrapf(err, "write hosts file %s, please make sure DevSpace has permission to edit it", hostsFile)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package portforwarding
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHostsEntry(t *testing.T) {
+	oldHostsFile := hostsFile
+	defer func() { hostsFile = oldHostsFile }()
+
+	hostsFile = filepath.Join(t.TempDir(), "hosts")
+	original := "127.0.0.1 localhost\n::1 localhost\n"
+	err := os.WriteFile(hostsFile, []byte(original), 0644)
+	assert.NilError(t, err)
+
+	assert.NilError(t, addHostsEntry("api.dev.local", "127.0.0.1"))
+	assert.NilError(t, addHostsEntry("api.dev.local", "127.0.0.2"))
+	out, err := os.ReadFile(hostsFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), original+"127.0.0.2 api.dev.local "+hostsMarker+"\n")
+
+	assert.NilError(t, removeHostsEntry("api.dev.local"))
+	out, err = os.ReadFile(hostsFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), original)
+}
+
+func TestHostsEntryCRLF(t *testing.T) {
+	oldHostsFile := hostsFile
+	defer func() { hostsFile = oldHostsFile }()
+
+	hostsFile = filepath.Join(t.TempDir(), "hosts")
+	original := "127.0.0.1 localhost\r\n::1 localhost\r\n"
+	err := os.WriteFile(hostsFile, []byte(original), 0644)
+	assert.NilError(t, err)
+
+	assert.NilError(t, addHostsEntry("api.dev.local", "127.0.0.1"))
+	out, err := os.ReadFile(hostsFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), original+"127.0.0.1 api.dev.local "+hostsMarker+"\r\n")
+
+	assert.NilError(t, removeHostsEntry("api.dev.local"))
+	out, err = os.ReadFile(hostsFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), original)
+
+	// entries of an earlier run that were written with \r\n are removed as well
+	assert.DeepEqual(t, removeHostsLines([]string{"127.0.0.1 localhost\r", "127.0.0.1 api.dev.local " + hostsMarker + "\r"}, "api.dev.local"), []string{"127.0.0.1 localhost\r"})
+}
+
+func TestHostsEntryNotRewrittenOnReconnect(t *testing.T) {
+	oldHostsFile := hostsFile
+	oldNewPortForwarder := newPortForwarder
+	oldExecuteHooksFunc := executeHooksFunc
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		hostsFile = oldHostsFile
+		newPortForwarder = oldNewPortForwarder
+		executeHooksFunc = oldExecuteHooksFunc
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	hostsFile = filepath.Join(t.TempDir(), "hosts")
+	original := "127.0.0.1 localhost\n"
+	assert.NilError(t, os.WriteFile(hostsFile, []byte(original), 0644))
+
+	errorChans := make(chan chan error, 2)
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		errorChans <- errorChan
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, ready: true, closed: make(chan struct{})}, nil
+	}
+	executeHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) error { return nil }
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset(pod)})
+	parent := &tomb.Tomb{}
+	reconnected := make(chan struct{}, 1)
+	options := Options{
+		ReconnectGracePeriod: -1,
+		OnReconnect:          func() { reconnected <- struct{}{} },
+	}
+
+	var err error
+	<-parent.NotifyGo(func() error {
+		err = startPortForwardingWithHooks(ctx, "test", []*latest.PortMapping{{Port: "0:8080", Hostname: "api.dev.local"}}, &fakePodSelector{pod: pod}, options, parent)
+		return nil
+	})
+	assert.NilError(t, err)
+	out, err := os.ReadFile(hostsFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), original+"127.0.0.1 api.dev.local "+hostsMarker+"\n")
+
+	// the entry is not added again when the port forwarding reconnects
+	assert.NilError(t, os.WriteFile(hostsFile, []byte(original), 0644))
+	(<-errorChans) <- errors.New("lost connection")
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second * 5):
+		t.Fatal("port forwarding wasn't restarted")
+	}
+	out, err = os.ReadFile(hostsFile)
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), original)
+
+	cancel()
+	_ = parent.Wait()
+}
@@ -0,0 +1,282 @@
+package portforwarding
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/pkg/errors"
+)
+
+// defaultLazyIdleTimeout is the time after which an unused lazy port forwarding is closed
+const defaultLazyIdleTimeout = 5 * time.Minute
+
+// lazyForwarder listens on a local port and only establishes the port forwarding
+// to the pod when the first client connects. The port forwarding is closed again
+// after it was not used for the idle timeout.
+type lazyForwarder struct {
+	ctx      devspacecontext.Context
+	selector targetselector.PodSelector
+
+	listener    net.Listener
+	remotePort  int
+	idleTimeout time.Duration
+
+	// traffic counts the bytes forwarded through the established port forwardings if set
+	traffic *portforward.Traffic
+
+	m         sync.Mutex
+	pf        forwarder
+	stopChan  chan struct{}
+	address   string
+	active    int
+	idleTimer *time.Timer
+}
+
+// startLazyForwarding opens the local listeners of the given lazy port mappings
+func startLazyForwarding(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, selector targetselector.PodSelector, options Options, parent *tomb.Tomb) error {
+	if ctx.IsDone() {
+		return nil
+	}
+
+	portMappings, err := resolveBindAddresses(portMappings)
+	if err != nil {
+		return err
+	}
+
+	forwarders := []*lazyForwarder{}
+	portsFormatted := []string{}
+	forwardedPorts := []portforward.ForwardedPort{}
+	for _, portMapping := range portMappings {
+		mappings, err := portforward.ParsePorts([]string{portMapping.Port})
+		if err != nil {
+			return fmt.Errorf("error parsing port %s: %v", portMapping.Port, err)
+		}
+
+		bindAddress := portMapping.BindAddress
+		if bindAddress == "" {
+			bindAddress = "localhost"
+		}
+
+		listener, err := options.listen()("tcp", net.JoinHostPort(bindAddress, strconv.Itoa(int(mappings[0].Local))))
+		if err != nil {
+			for _, l := range forwarders {
+				l.close()
+			}
+			return errors.Wrapf(err, "listen on port %d", int(mappings[0].Local))
+		}
+
+		idleTimeout := defaultLazyIdleTimeout
+		if portMapping.LazyIdleTimeout > 0 {
+			idleTimeout = time.Duration(portMapping.LazyIdleTimeout) * time.Second
+		}
+
+		l := &lazyForwarder{
+			ctx:         ctx,
+			selector:    selector,
+			listener:    listener,
+			remotePort:  int(mappings[0].Remote),
+			idleTimeout: idleTimeout,
+			traffic:     options.Traffic,
+		}
+		forwarders = append(forwarders, l)
+		portsFormatted = append(portsFormatted, formatListener(portMapping, listener, l.remotePort))
+		forwardedPorts = append(forwardedPorts, portforward.ForwardedPort{Local: uint16(listener.Addr().(*net.TCPAddr).Port), Remote: mappings[0].Remote})
+		go l.acceptConnections()
+	}
+
+	started := parent.TryGoNamed("lazy "+strings.Join(portsFormatted, ", "), func() error {
+		<-ctx.Context().Done()
+		for _, l := range forwarders {
+			l.close()
+		}
+		stopPortForwarding(ctx, name, portMappings, cancelReason(parent, options.owner), drainStats{}, options, parent)
+		return nil
+	})
+	if !started {
+		ctx.Log().Debugf("Skipped lazy port forwarding of dev %s, because it is shutting down", name)
+		for _, l := range forwarders {
+			l.close()
+		}
+		return nil
+	}
+
+	ctx.Log().Donef("Lazy port forwarding started on: %s", strings.Join(portsFormatted, ", "))
+	listenersReady(ctx, name, portMappings, forwardedPorts, options)
+	return nil
+}
+
+func (l *lazyForwarder) acceptConnections() {
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go l.handleConnection(conn)
+	}
+}
+
+func (l *lazyForwarder) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	address, err := l.acquire()
+	if err != nil {
+		l.ctx.Log().Errorf("Error starting lazy port forwarding for port %d: %v", l.remotePort, err)
+		return
+	}
+	defer l.release()
+
+	remoteConn, err := net.Dial("tcp", address)
+	if err != nil {
+		l.ctx.Log().Errorf("Error connecting to port forwarding for port %d: %v", l.remotePort, err)
+		return
+	}
+	defer remoteConn.Close()
+
+	pipeConnections(conn, remoteConn)
+}
+
+// acquire returns the local address of the established port forwarding and establishes
+// it if necessary. Concurrent callers wait for the same port forwarding to be established.
+func (l *lazyForwarder) acquire() (string, error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.idleTimer != nil {
+		l.idleTimer.Stop()
+		l.idleTimer = nil
+	}
+	if l.pf == nil {
+		err := l.establish()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	l.active++
+	return l.address, nil
+}
+
+// release marks a connection as done and closes the port forwarding after the
+// idle timeout if there are no other connections
+func (l *lazyForwarder) release() {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	l.active--
+	if l.active > 0 || l.pf == nil {
+		return
+	}
+
+	l.idleTimer = time.AfterFunc(l.idleTimeout, func() {
+		l.m.Lock()
+		defer l.m.Unlock()
+
+		if l.active == 0 && l.pf != nil {
+			l.ctx.Log().Debugf("Close idle lazy port forwarding for port %d", l.remotePort)
+			l.closeForwarder()
+		}
+	})
+}
+
+func (l *lazyForwarder) establish() error {
+	if l.ctx.IsDone() {
+		return errors.New("port forwarding was stopped")
+	}
+
+	pod, err := selectPod(l.ctx, l.selector)
+	if err != nil {
+		return errors.Wrap(err, "error selecting pod")
+	} else if pod == nil {
+		return errors.New("no pod found")
+	}
+
+	stopChan := make(chan struct{})
+	readyChan := make(chan struct{})
+	errorChan := make(chan error, 1)
+	pf, err := newPortForwarder(l.ctx.KubeClient(), pod, []string{fmt.Sprintf("0:%d", l.remotePort)}, []string{"127.0.0.1"}, stopChan, readyChan, errorChan, l.ctx.Log())
+	if err != nil {
+		return errors.Errorf("Error starting port forwarding: %v", err)
+	}
+	if counter, ok := pf.(trafficCounter); ok && l.traffic != nil {
+		counter.SetTrafficCounter(l.traffic)
+	}
+
+	go func() {
+		err := pf.ForwardPorts(l.ctx.Context())
+		if err != nil {
+			errorChan <- err
+		}
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errorChan:
+		close(stopChan)
+		return errors.Wrap(err, "forward ports")
+	case <-time.After(readyTimeout):
+		close(stopChan)
+		pf.Close()
+		return errReadyTimeout
+	}
+
+	ports, err := pf.GetPorts()
+	if err != nil || len(ports) != 1 {
+		close(stopChan)
+		pf.Close()
+		return errors.Errorf("Error retrieving local port of port forwarding: %v", err)
+	}
+
+	l.ctx.Log().Debugf("Established lazy port forwarding for port %d", l.remotePort)
+	l.pf = pf
+	l.stopChan = stopChan
+	l.address = net.JoinHostPort("127.0.0.1", strconv.Itoa(int(ports[0].Local)))
+
+	// reset the port forwarding if it fails, the next connection will establish a new one
+	go func() {
+		select {
+		case <-l.ctx.Context().Done():
+		case <-stopChan:
+		case err := <-errorChan:
+			l.m.Lock()
+			defer l.m.Unlock()
+
+			if l.pf == pf {
+				l.ctx.Log().Debugf("Lazy port forwarding for port %d stopped: %v", l.remotePort, err)
+				l.closeForwarder()
+			}
+		}
+	}()
+	return nil
+}
+
+func (l *lazyForwarder) close() {
+	_ = l.listener.Close()
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if l.idleTimer != nil {
+		l.idleTimer.Stop()
+	}
+	if l.pf != nil {
+		l.closeForwarder()
+	}
+}
+
+// closeForwarder stops the established port forwarding, the lock has to be held
+func (l *lazyForwarder) closeForwarder() {
+	close(l.stopChan)
+	l.pf.Close()
+	l.pf = nil
+	l.stopChan = nil
+}
@@ -0,0 +1,179 @@
+package portforwarding
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// echoForwarder pretends to forward to the pod by pointing to a local echo server
+type echoForwarder struct {
+	readyChan chan struct{}
+	port      int
+
+	m       sync.Mutex
+	traffic *portforward.Traffic
+}
+
+func (e *echoForwarder) SetTrafficCounter(traffic *portforward.Traffic) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	e.traffic = traffic
+}
+
+func (e *echoForwarder) trafficCounter() *portforward.Traffic {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	return e.traffic
+}
+
+func (e *echoForwarder) ForwardPorts(ctx context.Context) error {
+	close(e.readyChan)
+	<-ctx.Done()
+	return nil
+}
+
+func (e *echoForwarder) GetPorts() ([]portforward.ForwardedPort, error) {
+	return []portforward.ForwardedPort{{Local: uint16(e.port), Remote: 8080}}, nil
+}
+
+func (e *echoForwarder) Close() {}
+
+func TestLazyForwarding(t *testing.T) {
+	echoListener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer echoListener.Close()
+	go func() {
+		for {
+			conn, err := echoListener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				_, _ = conn.Write([]byte(line))
+			}()
+		}
+	}()
+
+	var created int32
+	forwarders := make(chan *echoForwarder, 1)
+	oldNewPortForwarder := newPortForwarder
+	defer func() { newPortForwarder = oldNewPortForwarder }()
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		atomic.AddInt32(&created, 1)
+		pf := &echoForwarder{readyChan: readyChan, port: echoListener.Addr().(*net.TCPAddr).Port}
+		forwarders <- pf
+		return pf, nil
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	parent := &tomb.Tomb{}
+	traffic := &portforward.Traffic{}
+
+	// find a free local port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	localPort := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	<-parent.NotifyGo(func() error {
+		err = startLazyForwarding(ctx, "test", []*latest.PortMapping{{Port: strconv.Itoa(localPort) + ":8080", BindAddress: "127.0.0.1", Lazy: true}}, selector, Options{Traffic: traffic}, parent)
+		return err
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, atomic.LoadInt32(&created), int32(0), "forwarder created before first connection")
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer conn.Close()
+
+			_, _ = conn.Write([]byte("hello\n"))
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			if line != "hello\n" {
+				t.Errorf("unexpected response %q", line)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, atomic.LoadInt32(&created), int32(1), "forwarder should be created exactly once")
+	assert.Equal(t, (<-forwarders).trafficCounter(), traffic, "traffic of the lazy port forwarding is not counted")
+
+	cancel()
+	_ = parent.Wait()
+}
+
+func TestLazyForwardingWhileShuttingDown(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+
+	// the parent is dying, but still has a running goroutine
+	parent := &tomb.Tomb{}
+	release := make(chan struct{})
+	parent.Go(func() error {
+		<-release
+		return nil
+	})
+	parent.Kill(nil)
+	defer close(release)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	localPort := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	err = startLazyForwarding(ctx, "test", []*latest.PortMapping{{Port: strconv.Itoa(localPort) + ":8080", BindAddress: "127.0.0.1", Lazy: true}}, selector, Options{}, parent)
+	assert.NilError(t, err)
+
+	// the local port is not listened on
+	_, err = net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)))
+	assert.Assert(t, err != nil, "lazy port forwarding was started")
+}
+
+func TestLazyForwardingListen(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	parent := &tomb.Tomb{}
+
+	addresses := []string{}
+	listen := func(network, address string) (net.Listener, error) {
+		addresses = append(addresses, address)
+		return nil, errors.New("listen denied")
+	}
+	err := startLazyForwarding(ctx, "test", []*latest.PortMapping{{Port: "9090:8080", BindAddress: "127.0.0.1", Lazy: true}}, selector, Options{Listen: listen}, parent)
+	assert.ErrorContains(t, err, "listen denied")
+	assert.DeepEqual(t, addresses, []string{"127.0.0.1:9090"})
+}
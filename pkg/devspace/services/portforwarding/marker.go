@@ -0,0 +1,76 @@
+package portforwarding
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// portMarkerDir is the folder where the markers of the local ports forwarded by devspace are stored
+var portMarkerDir = filepath.Join(os.TempDir(), "devspace-ports")
+
+// markPort records that the local port is forwarded by this devspace process, so that other
+// devspace processes can tell that they conflict with it. The returned func removes the marker again.
+func markPort(port int) func() {
+	err := os.MkdirAll(portMarkerDir, 0755)
+	if err != nil {
+		return func() {}
+	}
+
+	path := portMarkerPath(port)
+	err = os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644)
+	if err != nil {
+		return func() {}
+	}
+
+	return func() {
+		// only remove the marker if it wasn't taken over by another process in the meantime
+		pid, err := readPortMarker(path)
+		if err == nil && pid == os.Getpid() {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// portOwner returns the pid of another running devspace process that forwards the local port
+func portOwner(port int) (int, bool) {
+	path := portMarkerPath(port)
+	pid, err := readPortMarker(path)
+	if err != nil || pid == os.Getpid() {
+		return 0, false
+	} else if !processAlive(pid) {
+		// the marker was left over by a devspace process that didn't exit cleanly
+		_ = os.Remove(path)
+		return 0, false
+	}
+
+	return pid, true
+}
+
+func portMarkerPath(port int) string {
+	return filepath.Join(portMarkerDir, strconv.Itoa(port)+".pid")
+}
+
+func readPortMarker(path string) (int, error) {
+	out, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	} else if runtime.GOOS == "windows" {
+		// on windows FindProcess already fails if the process doesn't exist
+		return true
+	}
+
+	return process.Signal(syscall.Signal(0)) == nil
+}
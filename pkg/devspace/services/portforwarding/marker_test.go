@@ -0,0 +1,38 @@
+package portforwarding
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestPortOwner(t *testing.T) {
+	oldPortMarkerDir := portMarkerDir
+	defer func() { portMarkerDir = oldPortMarkerDir }()
+	portMarkerDir = t.TempDir()
+
+	// ports forwarded by this process are no conflict
+	release := markPort(8080)
+	_, ok := portOwner(8080)
+	assert.Equal(t, ok, false)
+
+	// ports forwarded by another running process are reported
+	assert.NilError(t, os.WriteFile(portMarkerPath(8080), []byte(strconv.Itoa(os.Getppid())), 0644))
+	pid, ok := portOwner(8080)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, pid, os.Getppid())
+
+	// the marker of another process is not removed
+	release()
+	_, err := os.Stat(portMarkerPath(8080))
+	assert.NilError(t, err)
+
+	// markers of processes that are gone are removed
+	assert.NilError(t, os.WriteFile(portMarkerPath(8081), []byte("999999999"), 0644))
+	_, ok = portOwner(8081)
+	assert.Equal(t, ok, false)
+	_, err = os.Stat(portMarkerPath(8081))
+	assert.Assert(t, os.IsNotExist(err))
+}
@@ -0,0 +1,83 @@
+package portforwarding
+
+import (
+	"fmt"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// resolvePortMappings checks that the containers of the port mappings exist in the pod and resolves
+// named remote ports, e.g. 8080:http, against the ports of the container or, if no container is
+// specified, all containers of the pod. Port mappings that are resolved are copied.
+func resolvePortMappings(pod *corev1.Pod, portMappings []*latest.PortMapping) ([]*latest.PortMapping, error) {
+	resolved := make([]*latest.PortMapping, len(portMappings))
+	for index, portMapping := range portMappings {
+		resolved[index] = portMapping
+
+		var ports []corev1.ContainerPort
+		if portMapping.Container != "" {
+			var found bool
+			ports, found = podContainerPorts(pod, portMapping.Container)
+			if !found {
+				return nil, errors.Errorf("container %s of port %s not found in pod %s/%s", portMapping.Container, portMapping.Port, pod.Namespace, pod.Name)
+			}
+		} else {
+			ports = allContainerPorts(pod)
+		}
+
+		localPort, name, ok := portforward.ParseNamedPort(portMapping.Port)
+		if !ok {
+			continue
+		}
+
+		remotePort := int32(0)
+		for _, port := range ports {
+			if port.Name == name && (port.Protocol == "" || port.Protocol == corev1.ProtocolTCP) {
+				remotePort = port.ContainerPort
+				break
+			}
+		}
+		if remotePort == 0 {
+			if portMapping.Container != "" {
+				return nil, errors.Errorf("named port %s not found in container %s of pod %s/%s", name, portMapping.Container, pod.Namespace, pod.Name)
+			}
+			return nil, errors.Errorf("named port %s not found in pod %s/%s", name, pod.Namespace, pod.Name)
+		}
+
+		copied := *portMapping
+		copied.Port = fmt.Sprintf("%d:%d", int(localPort), int(remotePort))
+		resolved[index] = &copied
+	}
+
+	return resolved, nil
+}
+
+// podContainerPorts returns the ports of the container with the given name, which can also be an
+// init or ephemeral container, and if the container was found
+func podContainerPorts(pod *corev1.Pod, container string) ([]corev1.ContainerPort, bool) {
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.Containers...), pod.Spec.InitContainers...) {
+		if c.Name == container {
+			return c.Ports, true
+		}
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		if c.Name == container {
+			return c.Ports, true
+		}
+	}
+
+	return nil, false
+}
+
+// allContainerPorts returns the ports of all containers and init containers of the pod
+func allContainerPorts(pod *corev1.Pod) []corev1.ContainerPort {
+	ports := []corev1.ContainerPort{}
+	for _, c := range append(append([]corev1.Container{}, pod.Spec.Containers...), pod.Spec.InitContainers...) {
+		ports = append(ports, c.Ports...)
+	}
+
+	return ports
+}
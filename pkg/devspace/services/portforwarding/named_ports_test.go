@@ -0,0 +1,82 @@
+package portforwarding
+
+import (
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type resolvePortMappingsTestCase struct {
+	name string
+
+	portMapping *latest.PortMapping
+
+	expectedPort string
+	expectedErr  string
+}
+
+func TestResolvePortMappings(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec: corev1.PodSpec{
+			Containers:     []corev1.Container{{Name: "app", Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}}},
+			InitContainers: []corev1.Container{{Name: "init", Ports: []corev1.ContainerPort{{Name: "setup", ContainerPort: 9000}}}},
+			EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+				Name:  "debugger",
+				Ports: []corev1.ContainerPort{{Name: "debug", ContainerPort: 2345}},
+			}}},
+		},
+	}
+
+	testCases := []resolvePortMappingsTestCase{
+		{
+			name:         "Numeric port",
+			portMapping:  &latest.PortMapping{Port: "3000:80"},
+			expectedPort: "3000:80",
+		},
+		{
+			name:         "Named port of any container",
+			portMapping:  &latest.PortMapping{Port: "3000:http"},
+			expectedPort: "3000:8080",
+		},
+		{
+			name:         "Named port of init container",
+			portMapping:  &latest.PortMapping{Port: ":setup", Container: "init"},
+			expectedPort: "0:9000",
+		},
+		{
+			name:         "Named port of ephemeral container",
+			portMapping:  &latest.PortMapping{Port: "2345:debug", Container: "debugger"},
+			expectedPort: "2345:2345",
+		},
+		{
+			name:        "Named port of other container",
+			portMapping: &latest.PortMapping{Port: "3000:http", Container: "debugger"},
+			expectedErr: "named port http not found in container debugger of pod test/test",
+		},
+		{
+			name:        "Unknown named port",
+			portMapping: &latest.PortMapping{Port: "3000:grpc"},
+			expectedErr: "named port grpc not found in pod test/test",
+		},
+		{
+			name:        "Missing container",
+			portMapping: &latest.PortMapping{Port: "3000:80", Container: "missing"},
+			expectedErr: "container missing of port 3000:80 not found in pod test/test",
+		},
+	}
+
+	for _, testCase := range testCases {
+		resolved, err := resolvePortMappings(pod, []*latest.PortMapping{testCase.portMapping})
+		if testCase.expectedErr != "" {
+			assert.Error(t, err, testCase.expectedErr, "Wrong or no error in testCase %s", testCase.name)
+			continue
+		}
+
+		assert.NilError(t, err, "Error in testCase %s", testCase.name)
+		assert.Equal(t, resolved[0].Port, testCase.expectedPort, "Unexpected port in testCase %s", testCase.name)
+	}
+}
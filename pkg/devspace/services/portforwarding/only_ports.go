@@ -0,0 +1,80 @@
+package portforwarding
+
+import (
+	"strings"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/env"
+	"github.com/loft-sh/devspace/pkg/util/stringutil"
+)
+
+// DevSpaceOnlyPorts is a comma separated list of port mapping names, if set only the
+// port mappings with one of these names are started
+const DevSpaceOnlyPorts = "DEVSPACE_ONLY_PORTS"
+
+// onlyPorts returns the names of the port mappings that should be started, or nil if all
+// port mappings should be started
+func onlyPorts(options Options) []string {
+	if len(options.OnlyPorts) > 0 {
+		return options.OnlyPorts
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(env.GlobalGetEnv(DevSpaceOnlyPorts), ",") {
+		if strings.TrimSpace(name) != "" {
+			names = append(names, strings.TrimSpace(name))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	return names
+}
+
+// filterPortMappings returns the port mappings that have one of the given names, the
+// skipped ones are logged. If no names are given, all port mappings are returned.
+func filterPortMappings(ctx devspacecontext.Context, portMappings []*latest.PortMapping, names []string) []*latest.PortMapping {
+	if len(names) == 0 {
+		return portMappings
+	}
+
+	filtered := []*latest.PortMapping{}
+	for _, portMapping := range portMappings {
+		if includePort(ctx, portMapping.Port, portMapping.Name, names) {
+			filtered = append(filtered, portMapping)
+		}
+	}
+
+	return filtered
+}
+
+// filterReversePortMappings is like filterPortMappings for reverse port mappings
+func filterReversePortMappings(ctx devspacecontext.Context, portMappings []*latest.ReversePortMapping, names []string) []*latest.ReversePortMapping {
+	if len(names) == 0 {
+		return portMappings
+	}
+
+	filtered := []*latest.ReversePortMapping{}
+	for _, portMapping := range portMappings {
+		if includePort(ctx, portMapping.Port, portMapping.Name, names) {
+			filtered = append(filtered, portMapping)
+		}
+	}
+
+	return filtered
+}
+
+// includePort returns true if the port with the given name should be started, the skipped ports are logged
+func includePort(ctx devspacecontext.Context, port, name string, names []string) bool {
+	if name == "" {
+		ctx.Log().Infof("Skip port %s without name, because only the ports %s are forwarded", port, strings.Join(names, ", "))
+		return false
+	} else if !stringutil.Contains(names, name) {
+		ctx.Log().Infof("Skip port %s (%s), because only the ports %s are forwarded", port, name, strings.Join(names, ", "))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,26 @@
+package portforwarding
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+)
+
+func TestFilterPortMappings(t *testing.T) {
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard)
+	portMappings := []*latest.PortMapping{{Port: "8080", Name: "web"}, {Port: "9229", Name: "debugger"}, {Port: "5432"}}
+
+	assert.DeepEqual(t, filterPortMappings(ctx, portMappings, nil), portMappings)
+	assert.DeepEqual(t, filterPortMappings(ctx, portMappings, []string{"debugger", "missing"}), []*latest.PortMapping{portMappings[1]})
+
+	// the names can also be specified via environment variable
+	defer os.Unsetenv(DevSpaceOnlyPorts)
+	assert.NilError(t, os.Setenv(DevSpaceOnlyPorts, "web, debugger"))
+	assert.DeepEqual(t, onlyPorts(Options{}), []string{"web", "debugger"})
+	assert.DeepEqual(t, onlyPorts(Options{OnlyPorts: []string{"web"}}), []string{"web"})
+}
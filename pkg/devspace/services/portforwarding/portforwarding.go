@@ -1,7 +1,11 @@
 package portforwarding
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -9,8 +13,12 @@ import (
 	"github.com/loft-sh/devspace/pkg/devspace/config/loader"
 	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
 	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	"github.com/loft-sh/devspace/pkg/util/backoff"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/stringutil"
 	"github.com/loft-sh/devspace/pkg/util/tomb"
 	"github.com/mgutz/ansi"
+	corev1 "k8s.io/api/core/v1"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
 	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
@@ -18,41 +26,425 @@ import (
 	"github.com/loft-sh/devspace/pkg/devspace/services/sync"
 	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
-// StartPortForwarding starts the port forwarding functionality
-func StartPortForwarding(ctx devspacecontext.Context, devPod *latest.DevPod, selector targetselector.TargetSelector, parent *tomb.Tomb) (retErr error) {
+var (
+	// readyTimeout is the time to wait for the port forwarding to become ready
+	readyTimeout = 20 * time.Second
+
+	// podRetryInterval is the time to wait before selecting a pod again if the selected pod is terminating
+	podRetryInterval = time.Second
+
+	// newPortForwarder creates the forwarder used for port forwarding. It is a variable
+	// so that tests can replace it with a fake forwarder.
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		pf, err := kubectl.NewPortForwarder(client, pod, ports, addresses, stopChan, readyChan, errorChan)
+		if err != nil {
+			return nil, err
+		}
+
+		pf.SetConnectionLogger(log)
+		return pf, nil
+	}
+)
+
+// forwarder forwards local ports to a pod
+type forwarder interface {
+	ForwardPorts(ctx context.Context) error
+	GetPorts() ([]portforward.ForwardedPort, error)
+	Close()
+}
+
+// Readiness defines when the port forwarding of a dev pod is considered ready
+type Readiness string
+
+const (
+	// ReadinessListening considers the port forwarding ready as soon as the local ports are bound
+	ReadinessListening Readiness = "listening"
+	// ReadinessProbes additionally waits for the probes of the port mappings, e.g. h2cProbe, to succeed.
+	// This is the default.
+	ReadinessProbes Readiness = "probes"
+	// ReadinessConnected additionally waits until a connection through each forwarded port to
+	// the pod succeeds
+	ReadinessConnected Readiness = "connected"
+)
+
+// Options holds the options for starting the port forwarding of a dev pod
+type Options struct {
+	// Readiness defines when StartPortForwarding returns. Reverse port forwarding is
+	// considered ready as soon as the tunnel to the container was started.
+	Readiness Readiness
+
+	// PodSelector overrides how the pod to forward the ports to is selected.
+	// If not set, the target selector is used.
+	PodSelector targetselector.PodSelector
+
+	// QuietReconnect prints repeated reconnect errors at debug level
+	QuietReconnect bool
+
+	// ReconnectGracePeriod is the time to wait before the first attempt to restart the port forwarding
+	// after an error, so that a restarting pod has a moment to come back. Defaults to
+	// defaultReconnectGracePeriod, a negative value restarts immediately.
+	ReconnectGracePeriod time.Duration
+
+	// InitTimeout is the maximum time StartPortForwarding waits for all port forwardings to start.
+	// Defaults to defaultInitTimeout, or no timeout if RetryReadyTimeout is set. A negative value
+	// waits indefinitely.
+	InitTimeout time.Duration
+
+	// OnlyPorts are the names of the port mappings that should be started, all other port mappings
+	// are skipped. If empty, DevSpaceOnlyPorts is used and if that is not set either, all are started.
+	OnlyPorts []string
+
+	// RetryReadyTimeout retries to start the port forwarding if it didn't become ready in time,
+	// instead of returning an error. Useful for pods that take a long time to start.
+	RetryReadyTimeout bool
+
+	// Traffic counts the forwarded bytes if set
+	Traffic *portforward.Traffic
+
+	// StopSummary logs the served connections, the forwarded bytes, the uptime and the reconnects
+	// of a port forwarding when it is stopped. Without it, the summary is only logged at debug level.
+	StopSummary bool
+
+	// SelectedPods records the pod each port mapping is forwarded to if set
+	SelectedPods *SelectedPods
+
+	// Listen creates the local listeners of the port forwarding if set, otherwise net.Listen is used
+	Listen portforward.ListenFunc
+
+	// RestartBackoff overrides the delay between failed restart attempts per error class. Classes
+	// that are not set inherit the backoff of defaultRestartDelay.
+	RestartBackoff backoff.Config
+
+	// OnError is called with every error the port forwarding is restarted because of and every
+	// error of a failed restart attempt, if set
+	OnError func(err error)
+
+	// OnReconnect is called every time the port forwarding was restarted successfully after an error, if set
+	OnReconnect func()
+
+	// env exports the local ports of the forwarded ports
+	env *portEnv
+
+	// noReconnect stops the port forwarding instead of restarting it after an error
+	noReconnect bool
+
+	// held keeps the local listeners bound while the port forwarding is restarted
+	held *heldListeners
+
+	// session collects the statistics of the port forwarding across restarts
+	session *session
+
+	// owner is the tomb of all port forwardings of the dev pod, the reason it was killed
+	// for applies to the port forwardings that were cancelled by it
+	owner *tomb.Tomb
+}
+
+// listen returns the function the local listeners are created with
+func (o Options) listen() portforward.ListenFunc {
+	if o.Listen != nil {
+		return o.Listen
+	}
+
+	return net.Listen
+}
+
+// trafficCounter is implemented by forwarders that can count the forwarded bytes
+type trafficCounter interface {
+	SetTrafficCounter(traffic *portforward.Traffic)
+}
+
+// connectionCounter is implemented by forwarders that can count their open connections
+type connectionCounter interface {
+	ActiveConnections() int
+}
+
+// defaultInitTimeout is the default time StartPortForwarding waits for all port forwardings to start
+const defaultInitTimeout = time.Minute * 5
+
+// defaultRestartDelay is the default delay between failed restart attempts of a port forwarding
+const defaultRestartDelay = time.Second * 15
+
+// defaultReconnectGracePeriod is the default time to wait before the first restart attempt after an error
+const defaultReconnectGracePeriod = time.Millisecond * 500
+
+// drainTimeout is the maximum time to wait for open connections to close when a port forwarding is stopped
+var drainTimeout = time.Second * 5
+
+// drainStats describes the connections that were still open when a port forwarding was stopped
+type drainStats struct {
+	connections int
+	duration    time.Duration
+}
+
+// httpLoggerSetter is implemented by forwarders that can log the forwarded HTTP requests
+type httpLoggerSetter interface {
+	SetHTTPLogger(remotePort uint16, logger log.Logger)
+}
+
+// connectionLimitSetter is implemented by forwarders that can limit the concurrent connections of a port
+type connectionLimitSetter interface {
+	SetConnectionLimit(remotePort uint16, max int)
+}
+
+// sourceAllowlistSetter is implemented by forwarders that can restrict the sources local connections of a port are accepted from
+type sourceAllowlistSetter interface {
+	SetSourceAllowlist(remotePort uint16, allowlist portforward.SourceAllowlist)
+}
+
+// bufferSizesSetter is implemented by forwarders that can change the buffer sizes of the connections of a port
+type bufferSizesSetter interface {
+	SetBufferSizes(remotePort uint16, sizes portforward.BufferSizes)
+}
+
+// listenFuncSetter is implemented by forwarders that can create their local listeners with a custom function
+type listenFuncSetter interface {
+	SetListenFunc(listen portforward.ListenFunc)
+}
+
+// errReadyTimeout is returned if the port forwarding didn't become ready in time
+var errReadyTimeout = errors.New("Timeout waiting for port forwarding to start")
+
+// StartPortForwarding starts the port forwarding functionality. The returned Forwards can be used
+// to change the forwarded ports of the dev pod afterwards.
+func StartPortForwarding(ctx devspacecontext.Context, devPod *latest.DevPod, selector targetselector.TargetSelector, options Options, parent *tomb.Tomb) (_ *Forwards, retErr error) {
 	if ctx == nil || ctx.Config() == nil || ctx.Config().Config() == nil {
-		return fmt.Errorf("DevSpace config is not set")
+		return nil, fmt.Errorf("DevSpace config is not set")
 	}
 
-	// forward
-	initDoneArray := []chan struct{}{}
+	err := validateLocalPorts(devPod)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(devPod.Ports) > 0 && ctx.KubeClient() != nil && ctx.KubeClient().RestConfig() != nil {
+		proxyURL, err := kubectl.ProxyURL(ctx.KubeClient())
+		if err != nil {
+			ctx.Log().Debugf("Error determining proxy: %v", err)
+		} else if proxyURL != nil {
+			ctx.Log().Infof("Using proxy %s for port forwarding", proxyURL.Redacted())
+		}
+	}
+
+	var podSelector targetselector.PodSelector = selector
+	if options.PodSelector != nil {
+		podSelector = options.PodSelector
+	}
 	if len(devPod.Ports) > 0 {
-		initDoneArray = append(initDoneArray, parent.NotifyGo(func() error {
-			return startPortForwardingWithHooks(ctx, devPod.Name, devPod.Ports, selector, parent)
-		}))
+		options.env = newPortEnv(devPod.Name, devPod.PortsEnvFile)
+	}
+	options.OnlyPorts = onlyPorts(options)
+	portMappings := filterPortMappings(ctx, devPod.Ports, options.OnlyPorts)
+
+	// forward
+	forwards := newForwards(ctx, devPod.Name, devPod.Namespace, podSelector, options, parent)
+	if !parent.Alive() {
+		ctx.Log().Debugf("Skipped port forwarding of dev %s, because it is shutting down", devPod.Name)
+		return forwards, nil
+	}
+	startForward := func() []initTask {
+		if len(portMappings) == 0 {
+			return nil
+		}
+
+		ports := []string{}
+		for _, portMapping := range portMappings {
+			ports = append(ports, portMapping.Port)
+		}
+		name := "port forwarding of " + strings.Join(ports, ", ")
+		return []initTask{{name: name, done: parent.NotifyGoNamed(name, func() error {
+			forwards.updateM.Lock()
+			defer forwards.updateM.Unlock()
+
+			return forwards.start(portMappings)
+		})}}
 	}
 
 	// reverse
+	startReverse := func() []initTask {
+		tasks := []initTask{}
+		loader.EachDevContainer(devPod, func(devContainer *latest.DevContainer) bool {
+			for _, container := range reverseContainers(devContainer) {
+				portMappings := filterReversePortMappings(ctx, reversePortsForContainer(devContainer, container), options.OnlyPorts)
+				if len(portMappings) == 0 {
+					continue
+				}
+
+				container := container
+				name := "reverse port forwarding to container " + container
+				tasks = append(tasks, initTask{name: name, done: parent.NotifyGoNamed(name, func() error {
+					return startReversePortForwardingWithHooks(ctx, devPod.Name, string(devContainer.Arch), devContainer.ReversePortsHelper, portMappings, selector.WithContainer(container), parent)
+				})})
+			}
+			return true
+		})
+		return tasks
+	}
+
+	initTimeout := options.InitTimeout
+	if initTimeout == 0 && !options.RetryReadyTimeout {
+		initTimeout = defaultInitTimeout
+	}
+	var deadline <-chan time.Time
+	if initTimeout > 0 {
+		timer := time.NewTimer(initTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	wait := func(tasks []initTask) error {
+		err := waitInitialized(ctx, tasks, deadline, initTimeout)
+		if err != nil {
+			// stop the port forwardings that are still starting
+			parent.Kill(err)
+			return errors.Wrapf(err, "dev %s", devPod.Name)
+		}
+
+		return nil
+	}
+
+	// start both at the same time or wait until the first one is initialized
+	// before starting the other one
+	switch devPod.PortsOrder {
+	case latest.PortsOrderReverseFirst:
+		err = wait(startReverse())
+		if err == nil && parent.Alive() {
+			err = wait(startForward())
+		}
+	case latest.PortsOrderForwardFirst:
+		err = wait(startForward())
+		if err == nil && parent.Alive() {
+			err = wait(startReverse())
+		}
+	default:
+		err = wait(append(startForward(), startReverse()...))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return forwards, nil
+}
+
+// initTask is a port forwarding that is being started, done is closed once it was started
+type initTask struct {
+	name string
+	done chan struct{}
+}
+
+// waitInitialized waits until all given tasks are done, the context is cancelled or the deadline
+// is reached. In the latter case an error with the pending tasks is returned.
+func waitInitialized(ctx devspacecontext.Context, tasks []initTask, deadline <-chan time.Time, timeout time.Duration) error {
+	for index, task := range tasks {
+		select {
+		case <-task.done:
+		case <-ctx.Context().Done():
+			return nil
+		case <-deadline:
+			pending := []string{}
+			for _, task := range tasks[index:] {
+				select {
+				case <-task.done:
+				default:
+					pending = append(pending, task.name)
+				}
+			}
+			if len(pending) == 0 {
+				return nil
+			}
+
+			return errors.Errorf("timed out after %s waiting for %s to start", timeout.Round(time.Second), strings.Join(pending, " and "))
+		}
+	}
+
+	return nil
+}
+
+// validateLocalPorts returns an error if a port of the dev pod is forwarded to the same
+// local address a reverse forwarded port of the dev pod connects to
+func validateLocalPorts(devPod *latest.DevPod) error {
+	forwarded := map[uint16][]string{}
+	for _, portMapping := range devPod.Ports {
+		mappings, err := portforward.ParsePorts([]string{portMapping.Port})
+		if err != nil || portMapping.Socket != "" || mappings[0].Local == 0 {
+			continue
+		}
+
+		forwarded[mappings[0].Local] = append(forwarded[mappings[0].Local], portMapping.BindAddress)
+	}
+
+	var err error
 	loader.EachDevContainer(devPod, func(devContainer *latest.DevContainer) bool {
-		if len(devContainer.ReversePorts) > 0 {
-			initDoneArray = append(initDoneArray, parent.NotifyGo(func() error {
-				return startReversePortForwardingWithHooks(ctx, devPod.Name, string(devContainer.Arch), devContainer.ReversePorts, selector.WithContainer(devContainer.Container), parent)
-			}))
+		for _, portMapping := range devContainer.ReversePorts {
+			mappings, parseErr := portforward.ParsePorts([]string{portMapping.Port})
+			if parseErr != nil {
+				continue
+			}
+
+			for _, bindAddress := range forwarded[mappings[0].Local] {
+				if addressesOverlap(bindAddress, portMapping.TargetHost) {
+					err = errors.Errorf("dev %s: local port %d is used by a port and a reverse port, please use a different local port for one of them", devPod.Name, mappings[0].Local)
+					return false
+				}
+			}
 		}
 		return true
 	})
 
-	// wait until everything is initialized
-	for _, initDone := range initDoneArray {
-		<-initDone
+	return err
+}
+
+// addressesOverlap returns true if both local addresses are the same or one of them listens on all addresses
+func addressesOverlap(a, b string) bool {
+	normalize := func(address string) string {
+		if address == "" || address == "localhost" {
+			return "127.0.0.1"
+		}
+		return address
+	}
+
+	a, b = normalize(a), normalize(b)
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if (ipA != nil && ipA.IsUnspecified()) || (ipB != nil && ipB.IsUnspecified()) {
+		return true
+	} else if ipA != nil && ipB != nil {
+		return ipA.Equal(ipB)
 	}
-	return nil
+
+	return a == b
 }
 
-func startReversePortForwardingWithHooks(ctx devspacecontext.Context, name, arch string, portMappings []*latest.PortMapping, selector targetselector.TargetSelector, parent *tomb.Tomb) error {
-	pluginErr := hook.ExecuteHooks(ctx, map[string]interface{}{
+// reverseContainers returns the containers the reverse ports of the dev container are forwarded to
+func reverseContainers(devContainer *latest.DevContainer) []string {
+	containers := []string{}
+	for _, portMapping := range devContainer.ReversePorts {
+		container := portMapping.Container
+		if container == "" {
+			container = devContainer.Container
+		}
+		if !stringutil.Contains(containers, container) {
+			containers = append(containers, container)
+		}
+	}
+
+	return containers
+}
+
+// reversePortsForContainer returns the reverse ports of the dev container that are forwarded to the container
+func reversePortsForContainer(devContainer *latest.DevContainer, container string) []*latest.ReversePortMapping {
+	portMappings := []*latest.ReversePortMapping{}
+	for _, portMapping := range devContainer.ReversePorts {
+		if portMapping.Container == container || (portMapping.Container == "" && devContainer.Container == container) {
+			portMappings = append(portMappings, portMapping)
+		}
+	}
+
+	return portMappings
+}
+
+func startReversePortForwardingWithHooks(ctx devspacecontext.Context, name, arch, helperPath string, portMappings []*latest.ReversePortMapping, selector targetselector.TargetSelector, parent *tomb.Tomb) error {
+	pluginErr := executeHooks(ctx, name, map[string]interface{}{
 		"reverse_port_forwarding_config": portMappings,
 	}, hook.EventsForSingle("start:reversePortForwarding", name).With("reversePortForwarding.start")...)
 	if pluginErr != nil {
@@ -60,9 +452,9 @@ func startReversePortForwardingWithHooks(ctx devspacecontext.Context, name, arch
 	}
 
 	// start reverse port forwarding
-	err := StartReversePortForwarding(ctx, name, arch, portMappings, selector, parent)
+	err := startReversePortForwarding(ctx, name, arch, helperPath, portMappings, selector, parent)
 	if err != nil {
-		pluginErr := hook.ExecuteHooks(ctx, map[string]interface{}{
+		pluginErr := executeHooks(ctx, name, map[string]interface{}{
 			"reverse_port_forwarding_config": portMappings,
 			"error":                          err,
 		}, hook.EventsForSingle("error:reversePortForwarding", name).With("reversePortForwarding.error")...)
@@ -76,18 +468,33 @@ func startReversePortForwardingWithHooks(ctx devspacecontext.Context, name, arch
 	return nil
 }
 
-func startPortForwardingWithHooks(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, selector targetselector.TargetSelector, parent *tomb.Tomb) error {
-	pluginErr := hook.ExecuteHooks(ctx, map[string]interface{}{
+func startPortForwardingWithHooks(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, selector targetselector.PodSelector, options Options, parent *tomb.Tomb) error {
+	return withStartHooks(ctx, name, portMappings, func() error {
+		return startForwarding(ctx, name, portMappings, selector, options, parent)
+	})
+}
+
+// withStartHooks executes the start hooks and adds the hostnames of the port mappings before
+// the port forwarding is started with start and executes the error hooks if it fails
+func withStartHooks(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, start func() error) error {
+	pluginErr := executeHooks(ctx, name, map[string]interface{}{
 		"port_forwarding_config": portMappings,
 	}, hook.EventsForSingle("start:portForwarding", name).With("portForwarding.start")...)
 	if pluginErr != nil {
 		return pluginErr
 	}
 
+	// the hostnames are added once and not on every reconnect, they are removed again
+	// when the port forwarding is stopped
+	addHostsEntries(ctx, portMappings)
+
 	// start port forwarding
-	err := StartForwarding(ctx, name, portMappings, selector, parent)
+	err := start()
+	if err != nil || ctx.IsDone() {
+		removeHostsEntries(ctx, portMappings)
+	}
 	if err != nil {
-		pluginErr := hook.ExecuteHooks(ctx, map[string]interface{}{
+		pluginErr := executeHooks(ctx, name, map[string]interface{}{
 			"port_forwarding_config": portMappings,
 			"error":                  err,
 		}, hook.EventsForSingle("error:portForwarding", name).With("portForwarding.error")...)
@@ -101,22 +508,77 @@ func startPortForwardingWithHooks(ctx devspacecontext.Context, name string, port
 	return nil
 }
 
-func StartForwarding(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, selector targetselector.TargetSelector, parent *tomb.Tomb) error {
+func StartForwarding(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, selector targetselector.PodSelector, parent *tomb.Tomb) error {
+	return startForwarding(ctx, name, portMappings, selector, Options{}, parent)
+}
+
+func startForwarding(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, selector targetselector.PodSelector, options Options, parent *tomb.Tomb) error {
+	// restarts keep the listeners of the first start bound, if the first start
+	// fails they are closed right away
+	first := options.held == nil
+	if first {
+		options.held = newHeldListeners(options.Listen, listenerHoldTimeout(options))
+		options.session = newSession(options.Traffic)
+		options.Traffic = options.session.traffic
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := startForwardingOnce(ctx, name, portMappings, selector, options, parent)
+		if err != errReadyTimeout || !options.RetryReadyTimeout || ctx.IsDone() {
+			if err != nil && first {
+				options.held.close()
+			}
+			return err
+		}
+
+		level := log.RepeatedLevel(logrus.WarnLevel, attempt, options.QuietReconnect)
+		ctx.Log().Printf(level, "Port forwarding didn't become ready in time, will try again...")
+	}
+}
+
+func startForwardingOnce(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, selector targetselector.PodSelector, options Options, parent *tomb.Tomb) error {
 	if ctx.IsDone() {
 		return nil
+	} else if ctx.KubeClient() == nil {
+		return fmt.Errorf("kube client is not set, cannot start port forwarding")
 	}
 
-	// start port forwarding
-	pod, err := selector.SelectSinglePod(ctx.Context(), ctx.KubeClient(), ctx.Log())
+	// start port forwarding, terminating pods are only skipped if the ports have to be forwarded to a ready pod
+	var pod *corev1.Pod
+	var err error
+	if allowsUnready(portMappings) {
+		pod, err = selector.SelectSinglePod(ctx.Context(), ctx.KubeClient(), ctx.Log())
+	} else {
+		pod, err = selectPod(ctx, selector)
+	}
 	if err != nil {
-		return errors.Wrap(err, "error selecting pod")
+		return backoff.Selector(errors.Wrap(err, "error selecting pod"))
 	} else if pod == nil {
 		return nil
 	}
+	if allowsUnready(portMappings) {
+		warnUnready(ctx, pod)
+	}
+	if options.SelectedPods != nil {
+		options.SelectedPods.set(ctx, portMappings, pod)
+	}
+
+	// named ports are resolved again for every pod, because they might point
+	// to a different port after a restart
+	configPortMappings := portMappings
+	portMappings, err = resolvePortMappings(pod, portMappings)
+	if err != nil {
+		return err
+	}
+	portMappings, err = resolveBindAddresses(portMappings)
+	if err != nil {
+		return err
+	}
 
 	ports := make([]string, len(portMappings))
 	portsFormatted := make([]string, len(portMappings))
 	addresses := make([]string, len(portMappings))
+	resolved := make([]resolvedPort, len(portMappings))
 	for index, value := range portMappings {
 		if value.Port == "" {
 			return errors.Errorf("port is not defined in portmapping %d", index)
@@ -129,111 +591,571 @@ func StartForwarding(ctx devspacecontext.Context, name string, portMappings []*l
 
 		localPort := mappings[0].Local
 		remotePort := mappings[0].Remote
+
+		// sockets are forwarded through a free local port
+		if value.Socket != "" {
+			ports[index] = fmt.Sprintf("0:%d", int(remotePort))
+			portsFormatted[index] = formatSocket(value.Socket, int(remotePort), value.Name)
+			addresses[index] = "127.0.0.1"
+			resolved[index] = resolvedPort{RemotePort: int(remotePort), BindAddress: addresses[index], Socket: value.Socket}
+			continue
+		}
+
 		available, err := port.IsAvailable(fmt.Sprintf(":%d", int(localPort)))
 		if err != nil {
-			ctx.Log().Debugf("Seems like port %d is already in use: %v", err)
+			ctx.Log().Debugf("Seems like port %d is already in use: %v", localPort, err)
+		} else if pid, ok := portOwner(int(localPort)); !available && ok {
+			ctx.Log().Warnf("Port %d is already forwarded by another devspace process (pid %d). Please stop the other process or change the local port of %s", localPort, pid, value.Port)
 		} else if !available {
 			ctx.Log().Debugf("Seems like port %d is already in use. Is another application using that port?", localPort)
 		}
 
 		ports[index] = fmt.Sprintf("%d:%d", int(localPort), int(remotePort))
-		portsFormatted[index] = ansi.Color(fmt.Sprintf("%d -> %d", int(localPort), int(remotePort)), "white+b")
+		portsFormatted[index] = formatPort(int(localPort), int(remotePort), value.Name)
 		if value.BindAddress == "" {
 			addresses[index] = "localhost"
 		} else {
 			addresses[index] = value.BindAddress
 		}
+		resolved[index] = resolvedPort{LocalPort: int(localPort), RemotePort: int(remotePort), BindAddress: addresses[index]}
+	}
+
+	tlsConfigs := make([]*tls.Config, len(portMappings))
+	allowlists := make([]portforward.SourceAllowlist, len(portMappings))
+	for index, portMapping := range portMappings {
+		allowlists[index], err = portforward.ParseSourceAllowlist(portMapping.AllowedSources)
+		if err != nil {
+			return errors.Wrapf(err, "allowed sources of port %s", portMapping.Port)
+		}
+		if portMapping.TLS == nil {
+			continue
+		}
+
+		tlsConfigs[index], err = loadTLSConfig(ctx, portMapping.TLS)
+		if err != nil {
+			return errors.Wrapf(err, "tls of port %s", portMapping.Port)
+		}
 	}
 
+	// the error channel is buffered, because besides ForwardPorts itself each
+	// forwarded port can raise an error while the port forwarding is stopped
+	stopChan := make(chan struct{})
 	readyChan := make(chan struct{})
-	errorChan := make(chan error, 1)
-	pf, err := kubectl.NewPortForwarder(ctx.KubeClient(), pod, ports, addresses, make(chan struct{}), readyChan, errorChan)
+	errorChan := make(chan error, len(portMappings)+1)
+	pf, err := newPortForwarder(ctx.KubeClient(), pod, ports, addresses, stopChan, readyChan, errorChan, ctx.Log())
 	if err != nil {
 		return errors.Errorf("Error starting port forwarding: %v", err)
 	}
+	sockets := []*socketForwarder{}
+	markers := []func(){}
+	drain := drainStats{}
+	stop := func(reason StopReason) {
+		if options.session != nil && !options.session.stop() {
+			return
+		}
+		if options.held != nil {
+			options.held.close()
+		}
+		stopPortForwarding(ctx, name, portMappings, reason, drain, options, parent)
+	}
+	stopForwarder := func() {
+		started := time.Now()
+		counter, ok := pf.(connectionCounter)
+		if ok {
+			drain.connections = counter.ActiveConnections()
+		}
+
+		close(stopChan)
+		pf.Close()
+		for _, s := range sockets {
+			s.close()
+		}
+		for _, release := range markers {
+			release()
+		}
+		if drain.connections > 0 {
+			waitForConnections(counter, drainTimeout)
+		}
+		drain.duration = time.Since(started)
+		drainErrors(ctx, errorChan)
+	}
+	if counter, ok := pf.(trafficCounter); ok && options.Traffic != nil {
+		counter.SetTrafficCounter(options.Traffic)
+	}
+	if setter, ok := pf.(listenFuncSetter); ok && options.held != nil {
+		setter.SetListenFunc(options.held.Listen)
+	} else if ok && options.Listen != nil {
+		setter.SetListenFunc(options.Listen)
+	}
+	for index, portMapping := range portMappings {
+		mappings, _ := portforward.ParsePorts([]string{portMapping.Port})
+		if setter, ok := pf.(httpLoggerSetter); ok && portMapping.HTTPLog {
+			setter.SetHTTPLogger(mappings[0].Remote, ctx.Log())
+		}
+		if setter, ok := pf.(connectionLimitSetter); ok && portMapping.MaxConnections > 0 {
+			setter.SetConnectionLimit(mappings[0].Remote, portMapping.MaxConnections)
+		}
+		if setter, ok := pf.(bufferSizesSetter); ok && (portMapping.ReadBufferSize > 0 || portMapping.WriteBufferSize > 0) {
+			setter.SetBufferSizes(mappings[0].Remote, portforward.BufferSizes{Read: portMapping.ReadBufferSize, Write: portMapping.WriteBufferSize})
+		}
+		if setter, ok := pf.(tlsConfigSetter); ok && tlsConfigs[index] != nil {
+			setter.SetTLSConfig(mappings[0].Remote, tlsConfigs[index])
+		}
+		if setter, ok := pf.(sourceAllowlistSetter); ok && len(allowlists[index]) > 0 {
+			setter.SetSourceAllowlist(mappings[0].Remote, allowlists[index])
+		}
+	}
 
+	// every return path below that doesn't hand the port forwarder over calls stopForwarder, which
+	// closes the port forwarder so that ForwardPorts returns and closes stopChan so that the error
+	// cannot block this goroutine, even if the error channel is full
 	go func() {
 		err := pf.ForwardPorts(ctx.Context())
 		if err != nil {
-			errorChan <- err
+			select {
+			case errorChan <- err:
+			case <-stopChan:
+			}
 		}
 	}()
 
 	// Wait till forwarding is ready
 	select {
 	case <-ctx.Context().Done():
+		stopForwarder()
 		return nil
 	case <-readyChan:
+		// local ports of 0 are only known after the forwarding has started
+		forwardedPorts, err := pf.GetPorts()
+		if (err != nil || len(forwardedPorts) != len(portMappings)) && hasSocket(portMappings) {
+			stopForwarder()
+			return errors.Errorf("Error retrieving local ports of port forwarding: %v", err)
+		}
+		if err == nil && len(forwardedPorts) == len(portMappings) {
+			for index, forwardedPort := range forwardedPorts {
+				resolved[index].LocalPort = int(forwardedPort.Local)
+				if portMappings[index].Socket != "" {
+					socket, err := startSocketForwarder(ctx, portMappings[index].Socket, net.JoinHostPort("127.0.0.1", strconv.Itoa(int(forwardedPort.Local))))
+					if err != nil {
+						stopForwarder()
+						return err
+					}
+
+					sockets = append(sockets, socket)
+					continue
+				}
+
+				markers = append(markers, markPort(int(forwardedPort.Local)))
+				portsFormatted[index] = formatPort(int(forwardedPort.Local), int(forwardedPort.Remote), portMappings[index].Name)
+				if portMappings[index].H2CProbe && options.Readiness != ReadinessListening {
+					err = probeH2C(ctx.Context(), localAddress(portMappings[index], int(forwardedPort.Local)))
+					if err != nil {
+						stopForwarder()
+						return errors.Wrapf(err, "h2c probe of port %d", int(forwardedPort.Remote))
+					}
+
+					ctx.Log().Debugf("Port %d negotiated protocol h2c", int(forwardedPort.Remote))
+					portsFormatted[index] += " (h2c)"
+				}
+				if portMappings[index].GRPCHealthCheck != nil && options.Readiness != ReadinessListening {
+					err = probeGRPCHealth(ctx.Context(), localAddress(portMappings[index], int(forwardedPort.Local)), portMappings[index].GRPCHealthCheck.Service)
+					if err != nil {
+						stopForwarder()
+						return errors.Wrapf(err, "grpc health check of port %d", int(forwardedPort.Remote))
+					}
+
+					ctx.Log().Debugf("Port %d is serving", int(forwardedPort.Remote))
+				}
+				if options.Readiness == ReadinessConnected {
+					err = probeConnection(ctx.Context(), localAddress(portMappings[index], int(forwardedPort.Local)))
+					if err != nil {
+						stopForwarder()
+						return errors.Wrapf(err, "connect to port %d", int(forwardedPort.Remote))
+					}
+				}
+				if portMappings[index].PrintURL {
+					portsFormatted[index] += " at " + ansi.Color(portMappingURL(portMappings[index], int(forwardedPort.Local)), "cyan+b")
+				}
+			}
+			if options.env != nil {
+				options.env.set(ctx, portMappings, forwardedPorts)
+			}
+		}
+
+		if options.held != nil {
+			// listeners of ports that are not forwarded anymore after a restart
+			options.held.closeDetached()
+		}
+
 		ctx.Log().Donef("Port forwarding started on: %s", strings.Join(portsFormatted, ", "))
+		logExecuteHooks(ctx, name, map[string]interface{}{
+			"port_forwarding_config": portMappings,
+			"port_forwarding_ports":  resolved,
+		}, hook.EventsForSingle("ready:portForwarding", name).With("portForwarding.ready")...)
 	case err := <-errorChan:
+		stopForwarder()
 		if ctx.IsDone() {
 			return nil
 		}
 
+		var permissionErr *portforward.BindPermissionError
+		if errors.As(err, &permissionErr) {
+			return permissionErr
+		}
+
 		return errors.Wrap(err, "forward ports")
-	case <-time.After(20 * time.Second):
-		return errors.Errorf("Timeout waiting for port forwarding to start")
+	case <-time.After(readyTimeout):
+		stopForwarder()
+		return errReadyTimeout
 	}
 
-	parent.Go(func() error {
+	// closing is logged once before the port forwarding is stopped on purpose, so that it can be told
+	// apart from the errors of the connections that are closed by it and from an error restart
+	closing := func() StopReason {
+		reason := cancelReason(parent, options.owner)
+		ctx.Log().Infof("Closing port forwarding %s (%s)", strings.Join(portsFormatted, ", "), closingMessage(reason))
+		return reason
+	}
+	started := parent.TryGoNamed("restart "+strings.Join(portsFormatted, ", "), func() error {
 		select {
 		case <-ctx.Context().Done():
-			pf.Close()
-			stopPortForwarding(ctx, name, portMappings, parent)
+			reason := closing()
+			stopForwarder()
+			stop(reason)
 		case err := <-errorChan:
 			if ctx.IsDone() {
-				pf.Close()
-				stopPortForwarding(ctx, name, portMappings, parent)
+				reason := closing()
+				stopForwarder()
+				stop(reason)
 				return nil
 			}
-			if err != nil {
+			if err != nil && options.noReconnect {
+				ctx.Log().Warnf("Port forwarding %s stopped: %v. It won't be restarted, because noReconnect is set", strings.Join(portsFormatted, ", "), err)
+				stopForwarder()
+				stop(StopReasonNoReconnect)
+				return nil
+			} else if err != nil {
 				ctx.Log().Errorf("Restarting because: %v", err)
+				if options.OnError != nil {
+					options.OnError(err)
+				}
+				if options.session != nil {
+					options.session.reconnected()
+				}
 				shouldExit := sync.PrintPodError(ctx.Context(), ctx.KubeClient(), pod, ctx.Log())
-				pf.Close()
-				hook.LogExecuteHooks(ctx, map[string]interface{}{
+				stopForwarder()
+				logExecuteHooks(ctx, name, map[string]interface{}{
 					"port_forwarding_config": portMappings,
 					"error":                  err,
+					"summary":                hookSummary("Port forwarding", name, portMappings, fmt.Sprintf("is restarting because: %v", err)),
 				}, hook.EventsForSingle("restart:portForwarding", name).With("portForwarding.restart")...)
 				if shouldExit {
-					stopPortForwarding(ctx, name, portMappings, parent)
+					stop(StopReasonPodFailed)
 					return nil
 				}
 
-				for {
-					err = StartForwarding(ctx, name, portMappings, selector, parent)
-					if err != nil {
-						hook.LogExecuteHooks(ctx, map[string]interface{}{
+				gracePeriod := options.ReconnectGracePeriod
+				if gracePeriod == 0 {
+					gracePeriod = defaultReconnectGracePeriod
+				}
+				if gracePeriod > 0 {
+					select {
+					case <-time.After(gracePeriod):
+					case <-ctx.Context().Done():
+						stop(closing())
+						return nil
+					}
+				}
+
+				restartBackoff := backoff.New(defaultRestartDelay).Merge(options.RestartBackoff)
+				for attempt := 0; ; attempt++ {
+					err = startForwarding(ctx, name, configPortMappings, selector, options, parent)
+					if err == nil && ctx.IsDone() {
+						// the restart was cancelled before it started anything
+						stop(closing())
+						return nil
+					} else if err != nil {
+						var permissionErr *portforward.BindPermissionError
+						if errors.As(err, &permissionErr) {
+							ctx.Log().Errorf("Stop restarting port-forwarding: %v", err)
+							stop(StopReasonPermissionDenied)
+							return nil
+						}
+
+						if options.OnError != nil {
+							options.OnError(err)
+						}
+						logExecuteHooks(ctx, name, map[string]interface{}{
 							"port_forwarding_config": portMappings,
 							"error":                  err,
+							"summary":                hookSummary("Port forwarding", name, portMappings, fmt.Sprintf("failed to restart: %v", err)),
 						}, hook.EventsForSingle("restart:portForwarding", name).With("portForwarding.restart")...)
-						ctx.Log().Errorf("Error restarting port-forwarding: %v", err)
-						ctx.Log().Errorf("Will try again in 15 seconds")
+						class := backoff.Classify(err)
+						delay := restartBackoff.Get(class).Delay(attempt)
+						level := log.RepeatedLevel(logrus.ErrorLevel, attempt, options.QuietReconnect)
+						ctx.Log().Printf(level, "Error restarting port-forwarding: %v", err)
+						ctx.Log().Printf(level, "Will try again in %s (%s error)", delay, class)
 
 						select {
-						case <-time.After(time.Second * 15):
+						case <-time.After(delay):
 							continue
 						case <-ctx.Context().Done():
-							stopPortForwarding(ctx, name, portMappings, parent)
+							stop(closing())
 							return nil
 						}
 					}
 
+					if options.OnReconnect != nil {
+						options.OnReconnect()
+					}
 					break
 				}
 			}
 		}
 		return nil
 	})
+	if !started {
+		ctx.Log().Debugf("Skipped port forwarding of dev %s, because it is shutting down", name)
+		stopForwarder()
+		stop(cancelReason(parent, options.owner))
+	}
 
 	return nil
 }
 
-func stopPortForwarding(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, parent *tomb.Tomb) {
-	hook.LogExecuteHooks(ctx, map[string]interface{}{
+// formatListener formats the local port of a port forwarding that keeps its listener for as long as it
+// runs, like a lazy or allPods port forwarding, including the url of the port mapping if it should be printed
+func formatListener(portMapping *latest.PortMapping, listener net.Listener, remotePort int) string {
+	localPort := listener.Addr().(*net.TCPAddr).Port
+	formatted := formatPort(localPort, remotePort, portMapping.Name)
+	if portMapping.PrintURL {
+		formatted += " at " + ansi.Color(portMappingURL(portMapping, localPort), "cyan+b")
+	}
+
+	return formatted
+}
+
+// listenersReady exports the local ports of port forwardings that keep their listeners for as long as
+// they run, like lazy and allPods port forwardings, and executes the ready hooks with them
+func listenersReady(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, forwardedPorts []portforward.ForwardedPort, options Options) {
+	if options.env != nil {
+		options.env.set(ctx, portMappings, forwardedPorts)
+	}
+
+	resolved := make([]resolvedPort, len(forwardedPorts))
+	for index, forwardedPort := range forwardedPorts {
+		resolved[index] = resolvedPort{LocalPort: int(forwardedPort.Local), RemotePort: int(forwardedPort.Remote), BindAddress: portMappings[index].BindAddress}
+		if resolved[index].BindAddress == "" {
+			resolved[index].BindAddress = "localhost"
+		}
+	}
+	logExecuteHooks(ctx, name, map[string]interface{}{
+		"port_forwarding_config": portMappings,
+		"port_forwarding_ports":  resolved,
+	}, hook.EventsForSingle("ready:portForwarding", name).With("portForwarding.ready")...)
+}
+
+// resolvedPort is a port mapping with the local port it was actually forwarded on, which
+// differs from the configured one for dynamic local ports and sockets
+type resolvedPort struct {
+	LocalPort   int    `json:"localPort"`
+	RemotePort  int    `json:"remotePort"`
+	BindAddress string `json:"bindAddress,omitempty"`
+	Socket      string `json:"socket,omitempty"`
+}
+
+// hasSocket returns true if one of the port mappings forwards a socket
+func hasSocket(portMappings []*latest.PortMapping) bool {
+	for _, portMapping := range portMappings {
+		if portMapping.Socket != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitForConnections waits until the counter has no open connections anymore or the timeout is reached
+func waitForConnections(counter connectionCounter, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for counter.ActiveConnections() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// drainErrors logs the errors that were raised while the port forwarding was stopped,
+// so that they are not lost and no sender is blocked
+func drainErrors(ctx devspacecontext.Context, errorChan <-chan error) {
+	for {
+		select {
+		case err := <-errorChan:
+			if err != nil {
+				ctx.Log().Debugf("Error while stopping port forwarding: %v", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// selectPod selects the pod to forward to. Pods that are terminating are skipped, because
+// the port forwarding would only work until the pod is gone.
+func selectPod(ctx devspacecontext.Context, selector targetselector.PodSelector) (*corev1.Pod, error) {
+	deadline := time.Now().Add(readyTimeout)
+	skipped := ""
+	for {
+		pod, err := selector.SelectSinglePod(ctx.Context(), ctx.KubeClient(), ctx.Log())
+		if err != nil || pod == nil || pod.DeletionTimestamp == nil {
+			return pod, err
+		}
+
+		if skipped != pod.Namespace+"/"+pod.Name {
+			skipped = pod.Namespace + "/" + pod.Name
+			ctx.Log().Infof("Skip pod %s because it is terminating, waiting for a new pod...", ansi.Color(skipped, "yellow+b"))
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("selected pod %s is terminating", skipped)
+		}
+
+		select {
+		case <-ctx.Context().Done():
+			return nil, nil
+		case <-time.After(podRetryInterval):
+		}
+	}
+}
+
+// formatPort formats a forwarded port for the logs
+func formatPort(localPort, remotePort int, name string) string {
+	formatted := ansi.Color(fmt.Sprintf("%d -> %d", localPort, remotePort), "white+b")
+	if name != "" {
+		formatted += " (" + name + ")"
+	}
+
+	return formatted
+}
+
+// portMappingURL returns the url the local port of the port mapping is reachable at
+func portMappingURL(portMapping *latest.PortMapping, localPort int) string {
+	scheme := portMapping.URLScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	path := portMapping.URLPath
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return scheme + "://" + localAddress(portMapping, localPort) + path
+}
+
+// localAddress returns the address the local port of the port mapping can be connected to
+func localAddress(portMapping *latest.PortMapping, localPort int) string {
+	host := portMapping.Hostname
+	if host == "" {
+		host = portMapping.BindAddress
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(localPort))
+}
+
+// hostIP returns the ip the hostname of the port mapping should resolve to
+func hostIP(portMapping *latest.PortMapping) string {
+	ip := net.ParseIP(portMapping.BindAddress)
+	if ip == nil || ip.IsUnspecified() {
+		return "127.0.0.1"
+	}
+
+	return ip.String()
+}
+
+// StopReason describes why a port forwarding was stopped
+type StopReason string
+
+const (
+	// StopReasonUnknown is used if it is not known why the port forwarding was stopped
+	StopReasonUnknown StopReason = "unknown"
+	// StopReasonRequested is used if the port forwarding was stopped on request, e.g.
+	// because the port mappings of the dev pod changed
+	StopReasonRequested StopReason = "requested"
+	// StopReasonRestart is used if the port forwarding was stopped to be started again on request
+	StopReasonRestart StopReason = "restart"
+	// StopReasonCancelled is used if the dev pod or DevSpace itself was stopped
+	StopReasonCancelled StopReason = "cancelled"
+	// StopReasonPodFailed is used if the pod failed and restarting the port forwarding wouldn't help
+	StopReasonPodFailed StopReason = "pod-failed"
+	// StopReasonPermissionDenied is used if DevSpace is not permitted to listen on a local port
+	StopReasonPermissionDenied StopReason = "permission-denied"
+	// StopReasonNoReconnect is used if the connection of a port with noReconnect was lost
+	StopReasonNoReconnect StopReason = "no-reconnect"
+)
+
+// ErrStopRequested is the reason a port forwarding tomb is killed with if it was stopped on request.
+// The stop hooks receive the stop reason requested in that case.
+var ErrStopRequested = errors.New("port forwarding stop requested")
+
+// ErrRestartRequested is the reason a port forwarding tomb is killed with if it is stopped to be
+// started again. The stop hooks receive the stop reason restart in that case.
+var ErrRestartRequested = errors.New("port forwarding restart requested")
+
+// cancelReason returns the stop reason of a port forwarding whose context was cancelled
+func cancelReason(parent, owner *tomb.Tomb) StopReason {
+	if parent == nil {
+		return StopReasonUnknown
+	} else if parent.Err() == ErrStopRequested {
+		return StopReasonRequested
+	} else if parent.Err() == ErrRestartRequested {
+		return StopReasonRestart
+	} else if owner != nil && owner != parent {
+		return cancelReason(owner, nil)
+	}
+
+	return StopReasonCancelled
+}
+
+// closingMessage returns why a port forwarding that is closing was stopped
+func closingMessage(reason StopReason) string {
+	switch reason {
+	case StopReasonRequested:
+		return "port mappings changed"
+	case StopReasonRestart:
+		return "restart requested"
+	}
+
+	return "shutdown requested"
+}
+
+func stopPortForwarding(ctx devspacecontext.Context, name string, portMappings []*latest.PortMapping, reason StopReason, drain drainStats, options Options, parent *tomb.Tomb) {
+	if options.env != nil {
+		options.env.remove(ctx, portMappings)
+	}
+	if options.session != nil {
+		level := logrus.DebugLevel
+		if options.StopSummary {
+			level = logrus.InfoLevel
+		}
+		ctx.Log().Printf(level, "%s", options.session.summary(portMappings))
+	}
+	if drain.connections > 0 {
+		ctx.Log().Infof("Port forwarding had %d open connection(s) when it was stopped, closing them took %s", drain.connections, drain.duration.Round(time.Millisecond))
+	} else {
+		ctx.Log().Debugf("Port forwarding had no open connections when it was stopped, stopping took %s", drain.duration.Round(time.Millisecond))
+	}
+
+	logExecuteStopHooks(ctx, name, map[string]interface{}{
 		"port_forwarding_config": portMappings,
+		"stop_reason":            string(reason),
+		"open_connections":       drain.connections,
+		"drain_duration":         drain.duration.String(),
+		"summary":                hookSummary("Port forwarding", name, portMappings, "stopped ("+string(reason)+")"),
 	}, hook.EventsForSingle("stop:portForwarding", name).With("portForwarding.stop")...)
 	parent.Kill(nil)
+	removeHostsEntries(ctx, portMappings)
 	for _, m := range portMappings {
-		ctx.Log().Debugf("Stopped port forwarding %v", m.Port)
+		ctx.Log().Debugf("Stopped port forwarding %v (%s)", m.Port, reason)
 	}
 }
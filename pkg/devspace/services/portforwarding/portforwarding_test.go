@@ -0,0 +1,750 @@
+package portforwarding
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+	fakekube "github.com/loft-sh/devspace/pkg/devspace/kubectl/testing"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/loft-sh/devspace/pkg/util/tomb"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakePodSelector struct {
+	pod *corev1.Pod
+}
+
+func (f *fakePodSelector) SelectSinglePod(ctx context.Context, client kubectl.Client, log log.Logger) (*corev1.Pod, error) {
+	return f.pod, nil
+}
+
+type fakeForwarder struct {
+	readyChan chan struct{}
+	errorChan chan error
+
+	ports []string
+	ready bool
+	err   error
+
+	closed chan struct{}
+}
+
+func (f *fakeForwarder) ForwardPorts(ctx context.Context) error {
+	if f.ready {
+		close(f.readyChan)
+	}
+	if f.err != nil {
+		return f.err
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func (f *fakeForwarder) GetPorts() ([]portforward.ForwardedPort, error) {
+	return portforward.ParsePorts(f.ports)
+}
+
+func (f *fakeForwarder) Close() {
+	select {
+	case <-f.closed:
+	default:
+		close(f.closed)
+	}
+}
+
+// teardownForwarder raises errors while the port forwarding is stopped
+type teardownForwarder struct {
+	stopChan  chan struct{}
+	readyChan chan struct{}
+	errorChan chan error
+
+	returned chan struct{}
+}
+
+func (f *teardownForwarder) ForwardPorts(ctx context.Context) error {
+	defer close(f.returned)
+
+	close(f.readyChan)
+	<-ctx.Done()
+
+	// raise more errors than the error channel can hold, the sender
+	// must not block once the port forwarding was stopped
+	for i := 0; i < 10; i++ {
+		select {
+		case f.errorChan <- errors.New("connection error"):
+		case <-f.stopChan:
+			return errors.New("stopped")
+		}
+	}
+	return nil
+}
+
+func (f *teardownForwarder) GetPorts() ([]portforward.ForwardedPort, error) {
+	return portforward.ParsePorts([]string{"8080:8080"})
+}
+
+func (f *teardownForwarder) Close() {
+	select {
+	case f.errorChan <- errors.New("teardown error"):
+	default:
+	}
+}
+
+func TestStopForwardingDrainsErrors(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	defer func() { newPortForwarder = oldNewPortForwarder }()
+
+	var pf *teardownForwarder
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		pf = &teardownForwarder{
+			stopChan:  stopChan,
+			readyChan: readyChan,
+			errorChan: errorChan,
+			returned:  make(chan struct{}),
+		}
+		return pf, nil
+	}
+
+	out := &bytes.Buffer{}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.NewStreamLoggerWithFormat(out, out, logrus.DebugLevel, log.RawFormat)).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	parent := &tomb.Tomb{}
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+
+	var err error
+	<-parent.NotifyGo(func() error {
+		err = StartForwarding(ctx, "test", []*latest.PortMapping{{Port: "8080"}}, selector, parent)
+		return nil
+	})
+	assert.NilError(t, err)
+
+	cancel()
+	_ = parent.Wait()
+	select {
+	case <-pf.returned:
+	case <-time.After(time.Second * 5):
+		t.Fatal("port forwarder is still blocked after the port forwarding was stopped")
+	}
+	assert.Assert(t, strings.Contains(out.String(), "Error while stopping port forwarding: "), "errors while stopping were not logged: %s", out.String())
+	assert.Equal(t, strings.Count(out.String(), "(shutdown requested)"), 1, out.String())
+	assert.Assert(t, strings.Index(out.String(), "Closing port forwarding") < strings.Index(out.String(), "Error while stopping port forwarding: "), "shutdown should be logged before the errors of the closed connections: %s", out.String())
+	assert.Assert(t, !strings.Contains(out.String(), "Restarting because"), "shutdown should not restart the port forwarding: %s", out.String())
+}
+
+// unreadyForwarder never becomes ready and, like the real port forwarder, only returns
+// from ForwardPorts with an error after it was closed
+type unreadyForwarder struct {
+	errorChan chan error
+	started   chan struct{}
+	closed    chan struct{}
+}
+
+func (f *unreadyForwarder) ForwardPorts(ctx context.Context) error {
+	close(f.started)
+	<-f.closed
+
+	// fill the error channel, so that the error of ForwardPorts cannot be buffered anymore
+	for {
+		select {
+		case f.errorChan <- errors.New("port error"):
+		default:
+			return errors.New("port forwarder closed")
+		}
+	}
+}
+
+func (f *unreadyForwarder) GetPorts() ([]portforward.ForwardedPort, error) {
+	return nil, nil
+}
+
+func (f *unreadyForwarder) Close() {
+	select {
+	case <-f.closed:
+	default:
+		close(f.closed)
+	}
+}
+
+func TestReadyWaitCancelled(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	defer func() { newPortForwarder = oldNewPortForwarder }()
+
+	created := make(chan *unreadyForwarder, 1)
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		pf := &unreadyForwarder{errorChan: errorChan, started: make(chan struct{}), closed: make(chan struct{})}
+		created <- pf
+		return pf, nil
+	}
+
+	baseline := runtime.NumGoroutine()
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	parent := &tomb.Tomb{}
+
+	returned := make(chan error, 1)
+	go func() {
+		returned <- startForwardingOnce(ctx, "test", []*latest.PortMapping{{Port: "8080"}}, selector, Options{}, parent)
+	}()
+	<-(<-created).started
+
+	// cancel while waiting for the port forwarding to become ready
+	cancel()
+	select {
+	case err := <-returned:
+		assert.NilError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("ready wait was not cancelled")
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutine(s) leaked after the ready wait was cancelled:\n%s", runtime.NumGoroutine()-baseline, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+type startForwardingTestCase struct {
+	name string
+
+	ready       bool
+	readyAfter  int
+	err         error
+	terminating bool
+	options     Options
+
+	expectedErr string
+}
+
+func TestStartForwarding(t *testing.T) {
+	testCases := []startForwardingTestCase{
+		{
+			name:  "Forwarding becomes ready",
+			ready: true,
+		},
+		{
+			name:        "Forwarding fails",
+			err:         errors.New("test error"),
+			expectedErr: "forward ports: test error",
+		},
+		{
+			name:        "Forwarding times out",
+			expectedErr: "Timeout waiting for port forwarding to start",
+		},
+		{
+			name:       "Forwarding becomes ready after ready timeout",
+			readyAfter: 2,
+			options:    Options{RetryReadyTimeout: true},
+		},
+		{
+			name:        "Pod is terminating",
+			ready:       true,
+			terminating: true,
+			expectedErr: "error selecting pod: selected pod test/test is terminating",
+		},
+	}
+
+	oldReadyTimeout := readyTimeout
+	oldPodRetryInterval := podRetryInterval
+	oldNewPortForwarder := newPortForwarder
+	defer func() {
+		readyTimeout = oldReadyTimeout
+		podRetryInterval = oldPodRetryInterval
+		newPortForwarder = oldNewPortForwarder
+	}()
+	readyTimeout = time.Millisecond * 100
+	podRetryInterval = time.Millisecond * 10
+
+	for _, testCase := range testCases {
+		var pf *fakeForwarder
+		attempts := 0
+		newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+			attempts++
+			pf = &fakeForwarder{
+				readyChan: readyChan,
+				errorChan: errorChan,
+				ports:     ports,
+				ready:     testCase.ready || (testCase.readyAfter > 0 && attempts >= testCase.readyAfter),
+				err:       testCase.err,
+				closed:    make(chan struct{}),
+			}
+			return pf, nil
+		}
+
+		cancelCtx, cancel := context.WithCancel(context.Background())
+		ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+		parent := &tomb.Tomb{}
+		selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+		if testCase.terminating {
+			selector.pod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+		}
+
+		var err error
+		<-parent.NotifyGo(func() error {
+			err = startForwarding(ctx, "test", []*latest.PortMapping{{Port: "0:8080"}}, selector, testCase.options, parent)
+			return nil
+		})
+		if testCase.expectedErr == "" {
+			assert.NilError(t, err, "Error in testCase %s", testCase.name)
+		} else {
+			assert.Error(t, err, testCase.expectedErr, "Wrong or no error in testCase %s", testCase.name)
+		}
+
+		cancel()
+		_ = parent.Wait()
+		if (testCase.ready || testCase.readyAfter > 0) && !testCase.terminating {
+			<-pf.closed
+		}
+		if testCase.readyAfter > 0 {
+			assert.Equal(t, attempts, testCase.readyAfter, "Unexpected attempts in testCase %s", testCase.name)
+		}
+	}
+}
+
+type portMappingURLTestCase struct {
+	name string
+
+	portMapping *latest.PortMapping
+	localPort   int
+
+	expectedURL string
+}
+
+func TestPortMappingURL(t *testing.T) {
+	testCases := []portMappingURLTestCase{
+		{
+			name:        "Defaults",
+			portMapping: &latest.PortMapping{Port: "0:8080", PrintURL: true},
+			localPort:   54321,
+			expectedURL: "http://localhost:54321",
+		},
+		{
+			name:        "Https with path",
+			portMapping: &latest.PortMapping{Port: "8443", PrintURL: true, URLScheme: "https", URLPath: "app/"},
+			localPort:   8443,
+			expectedURL: "https://localhost:8443/app/",
+		},
+		{
+			name:        "Bind address",
+			portMapping: &latest.PortMapping{Port: "8080", BindAddress: "127.0.0.2", PrintURL: true, URLPath: "/app"},
+			localPort:   8080,
+			expectedURL: "http://127.0.0.2:8080/app",
+		},
+		{
+			name:        "Any address",
+			portMapping: &latest.PortMapping{Port: "8080", BindAddress: "0.0.0.0", PrintURL: true},
+			localPort:   8080,
+			expectedURL: "http://localhost:8080",
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, portMappingURL(testCase.portMapping, testCase.localPort), testCase.expectedURL, "Unexpected url in testCase %s", testCase.name)
+	}
+}
+
+func TestCancelReason(t *testing.T) {
+	assert.Equal(t, cancelReason(nil, nil), StopReasonUnknown)
+
+	cancelled := &tomb.Tomb{}
+	assert.Equal(t, cancelReason(cancelled, nil), StopReasonCancelled)
+	cancelled.Kill(nil)
+	assert.Equal(t, cancelReason(cancelled, nil), StopReasonCancelled)
+
+	requested := &tomb.Tomb{}
+	requested.Kill(ErrStopRequested)
+	assert.Equal(t, cancelReason(requested, nil), StopReasonRequested)
+
+	restart := &tomb.Tomb{}
+	restart.Kill(ErrRestartRequested)
+	assert.Equal(t, cancelReason(restart, nil), StopReasonRestart)
+
+	// the group of a port forwarding is cancelled by the tomb of the dev pod
+	group := &tomb.Tomb{}
+	assert.Equal(t, cancelReason(group, restart), StopReasonRestart)
+	assert.Equal(t, cancelReason(group, cancelled), StopReasonCancelled)
+	assert.Equal(t, cancelReason(requested, restart), StopReasonRequested)
+}
+
+func TestClosingMessage(t *testing.T) {
+	assert.Equal(t, closingMessage(StopReasonCancelled), "shutdown requested")
+	assert.Equal(t, closingMessage(StopReasonUnknown), "shutdown requested")
+	assert.Equal(t, closingMessage(StopReasonRequested), "port mappings changed")
+	assert.Equal(t, closingMessage(StopReasonRestart), "restart requested")
+}
+
+func TestSelectedPods(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLoggerWithFormat(out, out, logrus.DebugLevel, log.RawFormat))
+	portMappings := []*latest.PortMapping{{Port: "8080"}}
+	pods := &SelectedPods{}
+	assert.Equal(t, pods.Get("8080"), "")
+
+	pods.set(ctx, portMappings, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "test"}})
+	assert.Equal(t, pods.Get("8080"), "test/first")
+	assert.Assert(t, !strings.Contains(out.String(), "switched"), "unexpected transition: %s", out.String())
+
+	pods.set(ctx, portMappings, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "test"}})
+	assert.Equal(t, pods.Get("8080"), "test/second")
+	assert.Assert(t, strings.Contains(out.String(), "switched from pod"), "transition was not logged: %s", out.String())
+}
+
+type validateLocalPortsTestCase struct {
+	name string
+
+	ports        []*latest.PortMapping
+	reversePorts []*latest.ReversePortMapping
+
+	expectedErr string
+}
+
+func TestValidateLocalPorts(t *testing.T) {
+	testCases := []validateLocalPortsTestCase{
+		{
+			name:         "Different local ports",
+			ports:        []*latest.PortMapping{{Port: "8080"}},
+			reversePorts: []*latest.ReversePortMapping{{Port: "9090"}},
+		},
+		{
+			name:         "Same local port",
+			ports:        []*latest.PortMapping{{Port: "8080:3000"}},
+			reversePorts: []*latest.ReversePortMapping{{Port: "8080:4000"}},
+			expectedErr:  "dev test: local port 8080 is used by a port and a reverse port, please use a different local port for one of them",
+		},
+		{
+			name:         "Same local port on all addresses",
+			ports:        []*latest.PortMapping{{Port: "8080", BindAddress: "0.0.0.0"}},
+			reversePorts: []*latest.ReversePortMapping{{Port: "8080", TargetHost: "127.0.0.2"}},
+			expectedErr:  "dev test: local port 8080 is used by a port and a reverse port, please use a different local port for one of them",
+		},
+		{
+			name:         "Same local port on different addresses",
+			ports:        []*latest.PortMapping{{Port: "8080", BindAddress: "127.0.0.2"}},
+			reversePorts: []*latest.ReversePortMapping{{Port: "8080"}},
+		},
+		{
+			name:         "Random local port",
+			ports:        []*latest.PortMapping{{Port: "0:8080"}, {Port: "8080", Socket: "/tmp/app.sock"}},
+			reversePorts: []*latest.ReversePortMapping{{Port: "8080"}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		devPod := &latest.DevPod{
+			Name:         "test",
+			Ports:        testCase.ports,
+			DevContainer: latest.DevContainer{ReversePorts: testCase.reversePorts},
+		}
+
+		err := validateLocalPorts(devPod)
+		if testCase.expectedErr == "" {
+			assert.NilError(t, err, "Error in testCase %s", testCase.name)
+		} else {
+			assert.Error(t, err, testCase.expectedErr, "Wrong or no error in testCase %s", testCase.name)
+		}
+	}
+}
+
+// connectionForwarder has an open connection until it is closed
+type connectionForwarder struct {
+	*fakeForwarder
+
+	active int64
+}
+
+func (f *connectionForwarder) ActiveConnections() int {
+	return int(atomic.LoadInt64(&f.active))
+}
+
+func (f *connectionForwarder) Close() {
+	f.fakeForwarder.Close()
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		atomic.StoreInt64(&f.active, 0)
+	}()
+}
+
+func TestStopForwardingDrainsConnections(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		return &connectionForwarder{
+			fakeForwarder: &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, ready: true, closed: make(chan struct{})},
+			active:        1,
+		}, nil
+	}
+	stopPayload := make(chan map[string]interface{}, 1)
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		if events[0] == "stop:portForwarding:*" {
+			stopPayload <- extraEnv
+		}
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	parent := &tomb.Tomb{}
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+
+	var err error
+	<-parent.NotifyGo(func() error {
+		err = StartForwarding(ctx, "test", []*latest.PortMapping{{Port: "0:8080"}}, selector, parent)
+		return nil
+	})
+	assert.NilError(t, err)
+
+	cancel()
+	_ = parent.Wait()
+	payload := <-stopPayload
+	assert.Equal(t, payload["open_connections"], 1)
+	assert.Equal(t, payload["summary"], "Port forwarding 0:8080 of dev test stopped (cancelled)")
+	duration, err := time.ParseDuration(payload["drain_duration"].(string))
+	assert.NilError(t, err)
+	assert.Assert(t, duration >= time.Millisecond*50, "unexpected drain duration %s", duration)
+}
+
+func TestReadyHookResolvedPorts(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	// the port forwarder picks local port 34567 for the dynamic local port
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: []string{"34567:8080"}, ready: true, closed: make(chan struct{})}, nil
+	}
+	readyPayload := make(chan map[string]interface{}, 1)
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		if events[0] == "ready:portForwarding:*" {
+			readyPayload <- extraEnv
+		}
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	parent := &tomb.Tomb{}
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+
+	var err error
+	<-parent.NotifyGo(func() error {
+		err = StartForwarding(ctx, "test", []*latest.PortMapping{{Port: "0:8080"}}, selector, parent)
+		return nil
+	})
+	assert.NilError(t, err)
+
+	payload := <-readyPayload
+	assert.DeepEqual(t, payload["port_forwarding_ports"], []resolvedPort{{LocalPort: 34567, RemotePort: 8080, BindAddress: "localhost"}})
+
+	cancel()
+	_ = parent.Wait()
+}
+
+func TestNoReconnect(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	errorChans := make(chan chan error, 2)
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		if ports[0] == "9090:9090" {
+			errorChans <- errorChan
+		}
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, ready: true, closed: make(chan struct{})}, nil
+	}
+	stopPayload := make(chan map[string]interface{}, 2)
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		if events[0] == "stop:portForwarding:*" {
+			stopPayload <- extraEnv
+		}
+	}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset()})
+	parent := &tomb.Tomb{}
+	selector := &fakePodSelector{pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}}}
+	job := &latest.PortMapping{Port: "9090", NoReconnect: true}
+	forwards := newForwards(ctx, "test", "test", selector, Options{}, parent)
+
+	var err error
+	<-parent.NotifyGo(func() error {
+		err = forwards.start([]*latest.PortMapping{{Port: "8080"}, job})
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(forwards.Mappings()), 2)
+
+	// the lost connection of the job port stops only its own port forwarding
+	(<-errorChans) <- errors.New("pod is gone")
+	payload := <-stopPayload
+	assert.Equal(t, payload["stop_reason"], string(StopReasonNoReconnect))
+	assert.DeepEqual(t, payload["port_forwarding_config"], []*latest.PortMapping{job})
+
+	deadline := time.Now().Add(time.Second * 5)
+	for len(forwards.Mappings()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.DeepEqual(t, forwards.Mappings(), []*latest.PortMapping{{Port: "8080"}})
+	assert.Assert(t, parent.Alive(), "dev pod was stopped")
+	assert.Equal(t, len(errorChans), 0, "job port was restarted")
+
+	// wait until the other port forwarding is stopped as well
+	cancel()
+	<-stopPayload
+}
+
+func TestOnReconnect(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	errorChans := make(chan chan error, 2)
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		errorChans <- errorChan
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, ready: true, closed: make(chan struct{})}, nil
+	}
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {}
+
+	// the pod still exists, so the port forwarding is restarted
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset(pod)})
+	parent := &tomb.Tomb{}
+	selector := &fakePodSelector{pod: pod}
+	reconnected := make(chan struct{}, 1)
+	options := Options{
+		ReconnectGracePeriod: -1,
+		OnReconnect:          func() { reconnected <- struct{}{} },
+	}
+
+	var err error
+	<-parent.NotifyGo(func() error {
+		err = startForwarding(ctx, "test", []*latest.PortMapping{{Port: "8080"}}, selector, options, parent)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(reconnected), 0, "the first start is not a reconnect")
+
+	(<-errorChans) <- errors.New("lost connection")
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second * 5):
+		t.Fatal("OnReconnect wasn't called after the port forwarding was restarted")
+	}
+	assert.Equal(t, len(errorChans), 1, "port forwarding wasn't restarted once")
+
+	cancel()
+	_ = parent.Wait()
+}
+
+// restartCancelledPodSelector selects the pod once and blocks selecting it again until the
+// context is done, like a restart that waits for a new pod
+type restartCancelledPodSelector struct {
+	pod       *corev1.Pod
+	selecting chan struct{}
+	calls     int32
+}
+
+func (r *restartCancelledPodSelector) SelectSinglePod(ctx context.Context, client kubectl.Client, log log.Logger) (*corev1.Pod, error) {
+	if atomic.AddInt32(&r.calls, 1) == 1 {
+		return r.pod, nil
+	}
+
+	r.selecting <- struct{}{}
+	<-ctx.Done()
+	return nil, nil
+}
+
+func TestRestartCancelled(t *testing.T) {
+	oldNewPortForwarder := newPortForwarder
+	oldLogExecuteHooksFunc := logExecuteHooksFunc
+	defer func() {
+		newPortForwarder = oldNewPortForwarder
+		logExecuteHooksFunc = oldLogExecuteHooksFunc
+	}()
+
+	errorChans := make(chan chan error, 1)
+	newPortForwarder = func(client kubectl.Client, pod *corev1.Pod, ports []string, addresses []string, stopChan chan struct{}, readyChan chan struct{}, errorChan chan error, log log.Logger) (forwarder, error) {
+		errorChans <- errorChan
+		return &fakeForwarder{readyChan: readyChan, errorChan: errorChan, ports: ports, ready: true, closed: make(chan struct{})}, nil
+	}
+	stopped := make(chan map[string]interface{}, 2)
+	logExecuteHooksFunc = func(ctx devspacecontext.Context, extraEnv map[string]interface{}, events ...string) {
+		if events[0] == "stop:portForwarding:*" {
+			stopped <- extraEnv
+		}
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard).WithKubeClient(&fakekube.Client{Client: fake.NewSimpleClientset(pod)})
+	parent := &tomb.Tomb{}
+	selector := &restartCancelledPodSelector{pod: pod, selecting: make(chan struct{}, 1)}
+	reconnected := make(chan struct{}, 1)
+	options := Options{
+		ReconnectGracePeriod: -1,
+		OnReconnect:          func() { reconnected <- struct{}{} },
+	}
+
+	var err error
+	<-parent.NotifyGo(func() error {
+		err = startForwarding(ctx, "test", []*latest.PortMapping{{Port: "8080"}}, selector, options, parent)
+		return nil
+	})
+	assert.NilError(t, err)
+
+	// the restart is cancelled while it waits for the pod
+	(<-errorChans) <- errors.New("lost connection")
+	<-selector.selecting
+	cancel()
+	_ = parent.Wait()
+
+	assert.Equal(t, len(reconnected), 0, "a cancelled restart is not a reconnect")
+	assert.Equal(t, len(stopped), 1, "port forwarding wasn't stopped once")
+	assert.Equal(t, (<-stopped)["stop_reason"], string(StopReasonCancelled))
+}
+
+func TestWaitInitialized(t *testing.T) {
+	done := make(chan struct{})
+	close(done)
+	tasks := []initTask{{name: "port forwarding of 8080", done: done}, {name: "reverse port forwarding to container app", done: make(chan struct{})}}
+
+	cancelCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx := devspacecontext.NewContext(cancelCtx, nil, log.Discard)
+	err := waitInitialized(ctx, tasks, time.After(time.Millisecond*50), time.Minute)
+	assert.Error(t, err, "timed out after 1m0s waiting for reverse port forwarding to container app to start")
+
+	// a cancelled context stops waiting without an error
+	cancel()
+	assert.NilError(t, waitInitialized(ctx, tasks, nil, 0))
+}
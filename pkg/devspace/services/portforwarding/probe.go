@@ -0,0 +1,154 @@
+package portforwarding
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// probeTimeout is the time to wait for a probe to succeed
+	probeTimeout = 5 * time.Second
+
+	// probeRetryInterval is the time to wait between connection probes
+	probeRetryInterval = 250 * time.Millisecond
+
+	// probeConnectionWait is the time a probed connection has to stay open to be considered successful
+	probeConnectionWait = 500 * time.Millisecond
+)
+
+// probeConnection checks if a connection through the forwarded port at the given address reaches
+// the pod. Connections that cannot be established in the pod are closed right away by the
+// port forwarder, so the connection is considered successful if it stays open for a short time
+// or the server sends data.
+func probeConnection(ctx context.Context, address string) error {
+	deadline := time.Now().Add(probeTimeout)
+	for {
+		err := probeConnectionOnce(ctx, address)
+		if err == nil {
+			return nil
+		} else if time.Now().After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(probeRetryInterval):
+		}
+	}
+}
+
+func probeConnectionOnce(ctx context.Context, address string) error {
+	dialer := &net.Dialer{Timeout: probeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "dial")
+	}
+	defer conn.Close()
+
+	err = conn.SetReadDeadline(time.Now().Add(probeConnectionWait))
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Read(make([]byte, 1))
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "connection was closed")
+	}
+
+	return nil
+}
+
+// probeGRPCHealth checks the health of the service through the gRPC health checking protocol
+// at the given address until it is serving or the ready timeout is reached.
+func probeGRPCHealth(ctx context.Context, address, service string) error {
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return errors.Wrap(err, "dial")
+	}
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	deadline := time.Now().Add(readyTimeout)
+	for {
+		err := probeGRPCHealthOnce(ctx, client, service)
+		if err == nil {
+			return nil
+		} else if status.Code(err) == codes.Unimplemented || status.Code(err) == codes.NotFound || time.Now().After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(probeRetryInterval):
+		}
+	}
+}
+
+func probeGRPCHealthOnce(ctx context.Context, client healthpb.HealthClient, service string) error {
+	checkCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	response, err := client.Check(checkCtx, &healthpb.HealthCheckRequest{Service: service})
+	if status.Code(err) == codes.Unimplemented {
+		return errors.Wrap(err, "server does not implement the grpc health checking protocol")
+	} else if status.Code(err) == codes.NotFound {
+		return errors.Wrapf(err, "service %q is unknown to the health server", service)
+	} else if err != nil {
+		return errors.Wrap(err, "health check")
+	} else if response.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("service %q is %s", service, response.Status.String())
+	}
+
+	return nil
+}
+
+// probeH2C checks if the server reachable at the given address speaks HTTP/2 cleartext
+// with prior knowledge. It sends the client connection preface and waits for the
+// settings frame the server has to answer with.
+func probeH2C(ctx context.Context, address string) error {
+	dialer := &net.Dialer{Timeout: probeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return errors.Wrap(err, "dial")
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(probeTimeout))
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write([]byte(http2.ClientPreface))
+	if err != nil {
+		return errors.Wrap(err, "write client preface")
+	}
+
+	framer := http2.NewFramer(conn, conn)
+	err = framer.WriteSettings()
+	if err != nil {
+		return errors.Wrap(err, "write settings")
+	}
+
+	frame, err := framer.ReadFrame()
+	if err != nil {
+		return errors.Wrap(err, "read server preface")
+	} else if _, ok := frame.(*http2.SettingsFrame); !ok {
+		return fmt.Errorf("expected settings frame from server, got %s", frame.Header().Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package portforwarding
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"gotest.tools/assert"
+)
+
+func TestProbeH2C(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go (&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: http.NotFoundHandler()})
+		}
+	}()
+
+	err = probeH2C(context.Background(), listener.Addr().String())
+	assert.NilError(t, err, "h2c server")
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	err = probeH2C(context.Background(), server.Listener.Addr().String())
+	assert.Assert(t, err != nil, "expected error for http/1 server")
+}
+
+func TestProbeConnection(t *testing.T) {
+	oldProbeTimeout := probeTimeout
+	defer func() { probeTimeout = oldProbeTimeout }()
+	probeTimeout = time.Millisecond * 100
+
+	// a server that keeps the connection open
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	err := probeConnection(context.Background(), server.Listener.Addr().String())
+	assert.NilError(t, err, "open connection")
+
+	// a server that closes the connection right away, like the port forwarder does if the pod refuses it
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	err = probeConnection(context.Background(), listener.Addr().String())
+	assert.Assert(t, err != nil, "expected error for closed connection")
+}
+
+func TestProbeGRPCHealth(t *testing.T) {
+	oldReadyTimeout := readyTimeout
+	defer func() { readyTimeout = oldReadyTimeout }()
+	readyTimeout = time.Millisecond * 300
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("up", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("down", healthpb.HealthCheckResponse_NOT_SERVING)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	err = probeGRPCHealth(context.Background(), listener.Addr().String(), "up")
+	assert.NilError(t, err, "serving service")
+
+	err = probeGRPCHealth(context.Background(), listener.Addr().String(), "down")
+	assert.ErrorContains(t, err, "NOT_SERVING")
+
+	err = probeGRPCHealth(context.Background(), listener.Addr().String(), "unknown")
+	assert.ErrorContains(t, err, "unknown to the health server")
+
+	// the service becomes serving while the probe retries
+	healthServer.SetServingStatus("later", healthpb.HealthCheckResponse_NOT_SERVING)
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		healthServer.SetServingStatus("later", healthpb.HealthCheckResponse_SERVING)
+	}()
+	err = probeGRPCHealth(context.Background(), listener.Addr().String(), "later")
+	assert.NilError(t, err, "service that becomes serving")
+}
@@ -0,0 +1,19 @@
+package portforwarding
+
+import (
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/pkg/errors"
+)
+
+// checkProtocols returns an error if the protocol of one of the port mappings cannot be forwarded,
+// instead of silently forwarding it as TCP. The port forward subresource of the kubelet only
+// forwards TCP connections.
+func checkProtocols(portMappings []*latest.PortMapping) error {
+	for _, portMapping := range portMappings {
+		if portMapping.Protocol != "" && portMapping.Protocol != latest.PortProtocolTCP {
+			return errors.Errorf("cannot forward port %s: protocol %s is not supported, the port forwarding of kubernetes only forwards TCP", portMapping.Port, portMapping.Protocol)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package portforwarding
+
+import (
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"gotest.tools/assert"
+)
+
+func TestCheckProtocols(t *testing.T) {
+	err := checkProtocols([]*latest.PortMapping{{Port: "8080"}, {Port: "9090", Protocol: latest.PortProtocolTCP}})
+	assert.NilError(t, err)
+
+	err = checkProtocols([]*latest.PortMapping{{Port: "8080"}, {Port: "9090", Protocol: "sctp"}})
+	assert.Error(t, err, "cannot forward port 9090: protocol sctp is not supported, the port forwarding of kubernetes only forwards TCP")
+}
@@ -1,10 +1,12 @@
 package portforwarding
 
 import (
+	"fmt"
 	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
 	"github.com/loft-sh/devspace/pkg/devspace/services/sync"
 	"github.com/loft-sh/devspace/pkg/util/tomb"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/loft-sh/devspace/pkg/devspace/hook"
@@ -12,13 +14,20 @@ import (
 	"github.com/loft-sh/devspace/pkg/devspace/tunnel"
 
 	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
 	"github.com/loft-sh/devspace/pkg/devspace/services/targetselector"
 	"github.com/pkg/errors"
 )
 
-func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string, portForwarding []*latest.PortMapping, selector targetselector.TargetSelector, parent *tomb.Tomb) error {
+func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string, portForwarding []*latest.ReversePortMapping, selector targetselector.TargetSelector, parent *tomb.Tomb) error {
+	return startReversePortForwarding(ctx, name, arch, "", portForwarding, selector, parent)
+}
+
+func startReversePortForwarding(ctx devspacecontext.Context, name, arch, helperPath string, portForwarding []*latest.ReversePortMapping, selector targetselector.TargetSelector, parent *tomb.Tomb) error {
 	if ctx.IsDone() {
 		return nil
+	} else if ctx.KubeClient() == nil {
+		return fmt.Errorf("kube client is not set, cannot start reverse port forwarding")
 	}
 
 	container, err := selector.SelectSingleContainer(ctx.Context(), ctx.KubeClient(), ctx.Log())
@@ -26,8 +35,8 @@ func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string,
 		return errors.Wrap(err, "error selecting container")
 	}
 
-	// make sure the DevSpace helper binary is injected
-	err = inject.InjectDevSpaceHelper(ctx.Context(), ctx.KubeClient(), container.Pod, container.Container.Name, arch, ctx.Log())
+	// find a helper that can run the tunnel in the container
+	tunnelCommand, err := reverseTunnelCommand(ctx, container, arch, helperPath)
 	if err != nil {
 		return err
 	}
@@ -38,7 +47,7 @@ func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string,
 	stdinReader, stdinWriter := io.Pipe()
 	stdoutReader, stdoutWriter := io.Pipe()
 	go func() {
-		err := sync.StartStream(ctx.Context(), ctx.KubeClient(), container.Pod, container.Container.Name, []string{inject.DevSpaceHelperContainerPath, "tunnel"}, stdinReader, stdoutWriter, false, ctx.Log())
+		err := sync.StartStream(ctx.Context(), ctx.KubeClient(), container.Pod, container.Container.Name, tunnelCommand, stdinReader, stdoutWriter, false, ctx.Log())
 		if err != nil {
 			errorChan <- errors.Errorf("connection lost to pod %s/%s: %v", container.Pod.Namespace, container.Pod.Name, err)
 		}
@@ -51,7 +60,7 @@ func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string,
 		}
 	}()
 
-	parent.Go(func() error {
+	started := parent.TryGoNamed("reverse "+container.Pod.Namespace+"/"+container.Pod.Name, func() error {
 		select {
 		case <-ctx.Context().Done():
 			close(closeChan)
@@ -72,9 +81,10 @@ func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string,
 				close(closeChan)
 				_ = stdinWriter.Close()
 				_ = stdoutWriter.Close()
-				hook.LogExecuteHooks(ctx, map[string]interface{}{
+				logExecuteHooks(ctx, name, map[string]interface{}{
 					"reverse_port_forwarding_config": portForwarding,
 					"error":                          err,
+					"summary":                        reverseHookSummary(name, portForwarding, fmt.Sprintf("is restarting because: %v", err)),
 				}, hook.EventsForSingle("restart:reversePortForwarding", name).With("reversePortForwarding.restart")...)
 				if shouldExit {
 					doneReverseForwarding(ctx, name, portForwarding, parent)
@@ -82,11 +92,12 @@ func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string,
 				}
 
 				for {
-					err = StartReversePortForwarding(ctx, name, arch, portForwarding, selector, parent)
+					err = startReversePortForwarding(ctx, name, arch, helperPath, portForwarding, selector, parent)
 					if err != nil {
-						hook.LogExecuteHooks(ctx, map[string]interface{}{
+						logExecuteHooks(ctx, name, map[string]interface{}{
 							"reverse_port_forwarding_config": portForwarding,
 							"error":                          err,
+							"summary":                        reverseHookSummary(name, portForwarding, fmt.Sprintf("failed to restart: %v", err)),
 						}, hook.EventsForSingle("restart:reversePortForwarding", name).With("reversePortForwarding.restart")...)
 						ctx.Log().Errorf("Error restarting reverse port-forwarding: %v", err)
 						ctx.Log().Errorf("Will try again in 15 seconds")
@@ -106,12 +117,49 @@ func StartReversePortForwarding(ctx devspacecontext.Context, name, arch string,
 		}
 		return nil
 	})
+	if !started {
+		ctx.Log().Debugf("Skipped reverse port forwarding of dev %s, because it is shutting down", name)
+		close(closeChan)
+		_ = stdinWriter.Close()
+		_ = stdoutWriter.Close()
+		doneReverseForwarding(ctx, name, portForwarding, parent)
+	}
 
 	return nil
 }
 
-func doneReverseForwarding(ctx devspacecontext.Context, name string, portForwarding []*latest.PortMapping, parent *tomb.Tomb) {
-	hook.LogExecuteHooks(ctx, map[string]interface{}{
+// reverseTunnelCommand returns the command that starts the reverse port forwarding tunnel in the
+// container. A helper that is already part of the image is preferred if configured, otherwise
+// the DevSpace helper is injected into the container.
+func reverseTunnelCommand(ctx devspacecontext.Context, container *selector.SelectedPodContainer, arch, helperPath string) ([]string, error) {
+	if helperPath == "" {
+		err := inject.InjectDevSpaceHelper(ctx.Context(), ctx.KubeClient(), container.Pod, container.Container.Name, arch, ctx.Log())
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{inject.DevSpaceHelperContainerPath, "tunnel"}, nil
+	}
+
+	_, stderr, err := ctx.KubeClient().ExecBuffered(ctx.Context(), container.Pod, container.Container.Name, []string{helperPath, "version"}, nil)
+	if err == nil {
+		ctx.Log().Infof("Use helper %s of the image for reverse port forwarding", helperPath)
+		return []string{helperPath, "tunnel"}, nil
+	}
+
+	helperErr := fmt.Errorf("%v %s", err, strings.TrimSpace(string(stderr)))
+	ctx.Log().Warnf("Cannot run helper %s of the image, falling back to the injected DevSpace helper: %v", helperPath, helperErr)
+	err = inject.InjectDevSpaceHelper(ctx.Context(), ctx.KubeClient(), container.Pod, container.Container.Name, arch, ctx.Log())
+	if err != nil {
+		return nil, errors.Errorf("container %s/%s cannot run any supported reverse port forwarding helper:\n- helper %s of the image: %v\n- injected DevSpace helper: %v", container.Pod.Name, container.Container.Name, helperPath, helperErr, err)
+	}
+
+	ctx.Log().Infof("Use injected DevSpace helper for reverse port forwarding")
+	return []string{inject.DevSpaceHelperContainerPath, "tunnel"}, nil
+}
+
+func doneReverseForwarding(ctx devspacecontext.Context, name string, portForwarding []*latest.ReversePortMapping, parent *tomb.Tomb) {
+	logExecuteStopHooks(ctx, name, map[string]interface{}{
 		"reverse_port_forwarding_config": portForwarding,
 	}, hook.EventsForSingle("stop:reversePortForwarding", name).With("reversePortForwarding.stop")...)
 	parent.Kill(nil)
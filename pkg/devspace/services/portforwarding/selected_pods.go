@@ -0,0 +1,48 @@
+package portforwarding
+
+import (
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/mgutz/ansi"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SelectedPods records the pod each port mapping is currently forwarded to
+type SelectedPods struct {
+	m    sync.Mutex
+	pods map[string]string
+}
+
+// Get returns the namespace/name of the pod the given port is forwarded to
+// or an empty string if the port is not forwarded yet
+func (s *SelectedPods) Get(port string) string {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	return s.pods[port]
+}
+
+// set records the pod the given port mappings are forwarded to and logs if they were
+// forwarded to a different pod before
+func (s *SelectedPods) set(ctx devspacecontext.Context, portMappings []*latest.PortMapping, pod *corev1.Pod) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if s.pods == nil {
+		s.pods = map[string]string{}
+	}
+
+	selected := pod.Namespace + "/" + pod.Name
+	for _, m := range portMappings {
+		previous := s.pods[m.Port]
+		if previous != "" && previous != selected {
+			ctx.Log().Infof("Port forwarding %s switched from pod %s to pod %s", m.Port, ansi.Color(previous, "white+b"), ansi.Color(selected, "white+b"))
+		} else {
+			ctx.Log().Debugf("Port forwarding %s uses pod %s", m.Port, selected)
+		}
+
+		s.pods[m.Port] = selected
+	}
+}
@@ -0,0 +1,49 @@
+package portforwarding
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/portforward"
+)
+
+// session holds the statistics of a port forwarding from its first start until it is stopped
+type session struct {
+	started    time.Time
+	reconnects int64
+	stopped    int32
+
+	// traffic counts the traffic of this port forwarding only, it is also added to the
+	// traffic of the dev pod
+	traffic *portforward.Traffic
+}
+
+func newSession(parent *portforward.Traffic) *session {
+	return &session{
+		started: time.Now(),
+		traffic: portforward.NewTraffic(parent),
+	}
+}
+
+func (s *session) reconnected() {
+	atomic.AddInt64(&s.reconnects, 1)
+}
+
+// stop marks the session as stopped and returns false if it was stopped already, e.g. by a
+// restart that was cancelled at the same time as the port forwarding it started
+func (s *session) stop() bool {
+	return atomic.CompareAndSwapInt32(&s.stopped, 0, 1)
+}
+
+// summary returns a one line summary of the session
+func (s *session) summary(portMappings []*latest.PortMapping) string {
+	ports := []string{}
+	for _, portMapping := range portMappings {
+		ports = append(ports, portMapping.Port)
+	}
+
+	return fmt.Sprintf("Port forwarding %s served %d connection(s), sent %s, received %s, ran for %s and reconnected %d time(s)", strings.Join(ports, ", "), s.traffic.Connections(), portforward.FormatBytes(s.traffic.Sent()), portforward.FormatBytes(s.traffic.Received()), time.Since(s.started).Round(time.Second), atomic.LoadInt64(&s.reconnects))
+}
@@ -0,0 +1,126 @@
+package portforwarding
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/mgutz/ansi"
+	"github.com/pkg/errors"
+)
+
+// socketForwarder listens on a local unix socket and forwards each connection
+// to the local port of the port forwarding
+type socketForwarder struct {
+	ctx devspacecontext.Context
+
+	path     string
+	address  string
+	listener net.Listener
+}
+
+// startSocketForwarder starts listening on the unix socket at path and forwards
+// the connections to the given address
+func startSocketForwarder(ctx devspacecontext.Context, path, address string) (*socketForwarder, error) {
+	listener, err := listenSocket(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &socketForwarder{
+		ctx:      ctx,
+		path:     path,
+		address:  address,
+		listener: listener,
+	}
+	go s.acceptConnections()
+	return s, nil
+}
+
+// listenSocket listens on the unix socket at path. A socket file left over by a previous
+// run is removed, but existing sockets that are still in use or other files are not touched.
+func listenSocket(path string) (net.Listener, error) {
+	stat, err := os.Lstat(path)
+	if err == nil {
+		if stat.Mode()&os.ModeSocket == 0 {
+			return nil, errors.Errorf("%s already exists and is not a socket", path)
+		}
+
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err == nil {
+			_ = conn.Close()
+			return nil, errors.Errorf("socket %s is already in use", path)
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "remove stale socket %s", path)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat socket %s", path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen on socket %s", path)
+	}
+
+	return listener, nil
+}
+
+func (s *socketForwarder) acceptConnections() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handleConnection(conn)
+	}
+}
+
+func (s *socketForwarder) handleConnection(conn net.Conn) {
+	defer conn.Close()
+
+	remoteConn, err := net.Dial("tcp", s.address)
+	if err != nil {
+		s.ctx.Log().Errorf("Error connecting socket %s to port forwarding: %v", s.path, err)
+		return
+	}
+	defer remoteConn.Close()
+
+	pipeConnections(conn, remoteConn)
+}
+
+// close stops listening and removes the socket file
+func (s *socketForwarder) close() {
+	_ = s.listener.Close()
+	_ = os.Remove(s.path)
+}
+
+// pipeConnections copies data between both connections until one side is done
+func pipeConnections(conn, remoteConn net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(remoteConn, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(conn, remoteConn)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// formatSocket formats a socket forwarded to a remote port for the logs
+func formatSocket(path string, remotePort int, name string) string {
+	formatted := ansi.Color(fmt.Sprintf("%s -> %d", path, remotePort), "white+b")
+	if name != "" {
+		formatted += " (" + name + ")"
+	}
+
+	return formatted
+}
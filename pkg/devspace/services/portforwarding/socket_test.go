@@ -0,0 +1,76 @@
+package portforwarding
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+)
+
+func TestSocketForwarding(t *testing.T) {
+	dir := t.TempDir()
+
+	// a regular file is not replaced
+	filePath := filepath.Join(dir, "file.sock")
+	assert.NilError(t, os.WriteFile(filePath, []byte("test"), 0644))
+	_, err := listenSocket(filePath)
+	assert.Error(t, err, filePath+" already exists and is not a socket")
+
+	// a socket that is in use is not replaced
+	socketPath := filepath.Join(dir, "app.sock")
+	inUse, err := net.Listen("unix", socketPath)
+	assert.NilError(t, err)
+	_, err = listenSocket(socketPath)
+	assert.Error(t, err, "socket "+socketPath+" is already in use")
+	_ = inUse.Close()
+
+	// a stale socket is replaced
+	if _, err := os.Stat(socketPath); os.IsNotExist(err) {
+		stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+		assert.NilError(t, err)
+		stale.SetUnlinkOnClose(false)
+		_ = stale.Close()
+	}
+
+	echoListener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	defer echoListener.Close()
+	go func() {
+		for {
+			conn, err := echoListener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				_, _ = conn.Write([]byte(line))
+			}()
+		}
+	}()
+
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard)
+	s, err := startSocketForwarder(ctx, socketPath, echoListener.Addr().String())
+	assert.NilError(t, err)
+
+	conn, err := net.Dial("unix", socketPath)
+	assert.NilError(t, err)
+	_, err = conn.Write([]byte("hello\n"))
+	assert.NilError(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NilError(t, err)
+	assert.Equal(t, line, "hello\n")
+	_ = conn.Close()
+
+	// the socket file is removed on close
+	s.close()
+	_, err = os.Stat(socketPath)
+	assert.Assert(t, os.IsNotExist(err), "socket file was not removed")
+}
@@ -0,0 +1,46 @@
+package portforwarding
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/pkg/errors"
+)
+
+// tlsConfigSetter is implemented by forwarders that can originate TLS to a port
+type tlsConfigSetter interface {
+	SetTLSConfig(remotePort uint16, config *tls.Config)
+}
+
+// loadTLSConfig loads the certificates of the tls config of a port mapping. They are loaded
+// every time the port forwarding is started, so that renewed certificates are picked up.
+func loadTLSConfig(ctx devspacecontext.Context, portTLS *latest.PortTLS) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: portTLS.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if portTLS.CA != "" {
+		ca, err := os.ReadFile(ctx.ResolvePath(portTLS.CA))
+		if err != nil {
+			return nil, errors.Wrap(err, "read ca")
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("ca %s doesn't contain a PEM encoded certificate", portTLS.CA)
+		}
+	}
+	if portTLS.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(ctx.ResolvePath(portTLS.Cert), ctx.ResolvePath(portTLS.Key))
+		if err != nil {
+			return nil, errors.Wrap(err, "load client certificate")
+		}
+
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
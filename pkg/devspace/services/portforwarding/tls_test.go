@@ -0,0 +1,31 @@
+package portforwarding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"gotest.tools/assert"
+)
+
+func TestLoadTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.Discard).WithWorkingDir(dir)
+
+	config, err := loadTLSConfig(ctx, &latest.PortTLS{ServerName: "my-service.default.svc"})
+	assert.NilError(t, err)
+	assert.Equal(t, config.ServerName, "my-service.default.svc")
+	assert.Assert(t, config.RootCAs == nil, "system certificate authorities should be used by default")
+
+	// relative paths are resolved against the working dir
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, "ca.crt"), []byte("no certificate"), 0600))
+	_, err = loadTLSConfig(ctx, &latest.PortTLS{ServerName: "my-service.default.svc", CA: "ca.crt"})
+	assert.Error(t, err, "ca ca.crt doesn't contain a PEM encoded certificate")
+
+	_, err = loadTLSConfig(ctx, &latest.PortTLS{ServerName: "my-service.default.svc", Cert: "client.crt", Key: "client.key"})
+	assert.ErrorContains(t, err, "load client certificate")
+}
@@ -0,0 +1,38 @@
+package portforwarding
+
+import (
+	"github.com/loft-sh/devspace/pkg/devspace/config/versions/latest"
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/mgutz/ansi"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// allowsUnready returns true if the port mappings may be forwarded to a pod that is not ready.
+// Port mappings with allowUnready have their own port forwarder, so it is enough to check one.
+func allowsUnready(portMappings []*latest.PortMapping) bool {
+	return len(portMappings) > 0 && portMappings[0].AllowUnready
+}
+
+// warnUnready warns that the port forwarding might break at any time if the pod is not ready
+func warnUnready(ctx devspacecontext.Context, pod *corev1.Pod) {
+	status := kubectl.GetPodStatus(pod)
+	if status == "Running" && isPodReady(pod) {
+		return
+	}
+	if status == "Running" {
+		status = "not ready"
+	}
+
+	ctx.Log().Warnf("Forwarding to pod %s although it is %s, because allowUnready is set. The port forwarding might break at any time", ansi.Color(pod.Namespace+"/"+pod.Name, "yellow+b"), status)
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package portforwarding
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	devspacecontext "github.com/loft-sh/devspace/pkg/devspace/context"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWarnUnready(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := devspacecontext.NewContext(context.Background(), nil, log.NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, log.RawFormat))
+
+	ready := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "test"},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	warnUnready(ctx, ready)
+	assert.Equal(t, out.String(), "")
+
+	crashing := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "crashing", Namespace: "test"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}},
+		},
+	}
+	warnUnready(ctx, crashing)
+	assert.Assert(t, strings.Contains(out.String(), "although it is CrashLoopBackOff"), out.String())
+}
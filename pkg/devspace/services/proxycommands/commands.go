@@ -65,7 +65,7 @@ func startProxyCommands(ctx devspacecontext.Context, name, arch string, remotePo
 
 	// start reverse port forwarding from that port
 	mapping := fmt.Sprintf("%d:%d", port, remotePort)
-	err = portforwarding.StartReversePortForwarding(ctx, name, arch, []*latest.PortMapping{
+	err = portforwarding.StartReversePortForwarding(ctx, name, arch, []*latest.ReversePortMapping{
 		{
 			Port: mapping,
 		},
@@ -62,6 +62,11 @@ func StartSyncFromCmd(ctx devspacecontext.Context, selector targetselector.Targe
 
 // StartSync starts the syncing functionality
 func StartSync(ctx devspacecontext.Context, devPod *latest.DevPod, selector targetselector.TargetSelector, parent *tomb.Tomb) (retErr error) {
+	return StartScopedSync(ctx, "", devPod, selector, parent)
+}
+
+// StartScopedSync is like StartSync, but writes the sync logs to the file log of the dev pod within the given scope
+func StartScopedSync(ctx devspacecontext.Context, scope string, devPod *latest.DevPod, selector targetselector.TargetSelector, parent *tomb.Tomb) (retErr error) {
 	if ctx == nil || ctx.Config() == nil || ctx.Config().Config() == nil {
 		return fmt.Errorf("DevSpace config is nil")
 	}
@@ -94,7 +99,7 @@ func StartSync(ctx devspacecontext.Context, devPod *latest.DevPod, selector targ
 					defer cancel()
 				}
 
-				return startSync(syncCtx, devPod.Name, string(devContainer.Arch), s, selector.WithContainer(devContainer.Container), starter, parent)
+				return startSync(syncCtx, scope, devPod.Name, string(devContainer.Arch), s, selector.WithContainer(devContainer.Container), starter, parent)
 			})
 			initDoneArray = append(initDoneArray, initDone)
 
@@ -115,7 +120,7 @@ func StartSync(ctx devspacecontext.Context, devPod *latest.DevPod, selector targ
 	return nil
 }
 
-func startSync(ctx devspacecontext.Context, name, arch string, syncConfig *latest.SyncConfig, selector targetselector.TargetSelector, starter sync.DelayedContainerStarter, parent *tomb.Tomb) error {
+func startSync(ctx devspacecontext.Context, scope, name, arch string, syncConfig *latest.SyncConfig, selector targetselector.TargetSelector, starter sync.DelayedContainerStarter, parent *tomb.Tomb) error {
 	// set options
 	options := &Options{
 		Name:       name,
@@ -131,8 +136,11 @@ func startSync(ctx devspacecontext.Context, name, arch string, syncConfig *lates
 	// should we print the logs?
 	if syncConfig.PrintLogs || ctx.Log().GetLevel() == logrus.DebugLevel {
 		options.SyncLog = ctx.Log()
+	} else if logpkg.FileLogsDisabled() {
+		// without a file log only print problems of the sync
+		options.SyncLog = ctx.Log().WithLevel(logrus.WarnLevel)
 	} else {
-		options.SyncLog = logpkg.GetDevPodFileLogger(name)
+		options.SyncLog = logpkg.GetScopedDevPodFileLogger(scope, name)
 	}
 
 	return NewController().Start(ctx, options, parent)
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"github.com/loft-sh/devspace/pkg/devspace/imageselector"
+	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -37,6 +40,10 @@ type Options struct {
 	sortContainers selector.SortContainers
 
 	waitingStrategy WaitingStrategy
+
+	// annotationKey and annotationValue restrict the selection to the single pod with the annotation
+	annotationKey   string
+	annotationValue string
 }
 
 func NewEmptyOptions() Options {
@@ -128,6 +135,16 @@ func (o Options) WithWaitingStrategy(waitingStrategy WaitingStrategy) Options {
 	return newOptions
 }
 
+// WithAnnotation only selects the pod whose annotation key has the given value. If none or multiple
+// pods have the annotation, the selector waits until exactly one of them has it, e.g. until the
+// annotation was moved from the old to the new pod. It is only used for selecting a single pod.
+func (o Options) WithAnnotation(key, value string) Options {
+	newOptions := o
+	newOptions.annotationKey = key
+	newOptions.annotationValue = value
+	return newOptions
+}
+
 func (o Options) WithPick(allowPick bool) Options {
 	newOptions := o
 	newOptions.allowPick = allowPick
@@ -167,8 +184,15 @@ func ToStringImageSelector(imageSelector []imageselector.ImageSelector) []string
 	return imageSelectors
 }
 
-type TargetSelector interface {
+// PodSelector selects a single pod. It can be implemented to use a custom strategy
+// for selecting the pod, e.g. by annotation.
+type PodSelector interface {
 	SelectSinglePod(ctx context.Context, client kubectl.Client, log log.Logger) (*v1.Pod, error)
+}
+
+type TargetSelector interface {
+	PodSelector
+
 	SelectSingleContainer(ctx context.Context, client kubectl.Client, log log.Logger) (*selector.SelectedPodContainer, error)
 
 	WithContainer(container string) TargetSelector
@@ -216,16 +240,91 @@ func (t *targetSelector) SelectSinglePod(ctx context.Context, client kubectl.Cli
 		t.options.waitingStrategy = t.options.waitingStrategy.Reset()
 	}
 
-	pod, err := t.selectSingle(ctx, client, t.options, log, t.selectSinglePod)
+	// the candidates are only logged if they changed since the last attempt
+	state := &selectState{}
+	pod, err := t.selectSingle(ctx, client, t.options, log, t.selectSinglePodFn(state))
 	if err != nil {
 		return nil, err
 	} else if pod == nil {
 		return nil, nil
 	}
 
+	log.Debugf("Selected pod %s/%s with selector %v", pod.(*v1.Pod).Namespace, pod.(*v1.Pod).Name, t.options.selector.String())
 	return pod.(*v1.Pod), nil
 }
 
+// selectState remembers what was logged during the attempts of selecting a single pod, so that
+// the same messages are not repeated on every attempt
+type selectState struct {
+	candidates string
+	annotated  string
+}
+
+// filterAnnotated returns the pods that have the annotation of the options and if exactly one
+// pod was found. If none or multiple pods have it, this is logged when it changes.
+func filterAnnotated(log log.Logger, options Options, pods []*v1.Pod, state *selectState) ([]*v1.Pod, bool) {
+	if options.annotationKey == "" {
+		return pods, true
+	}
+
+	annotated := []*v1.Pod{}
+	names := []string{}
+	for _, pod := range pods {
+		if value, ok := pod.Annotations[options.annotationKey]; ok && value == options.annotationValue {
+			annotated = append(annotated, pod)
+			names = append(names, pod.Namespace+"/"+pod.Name)
+		}
+	}
+
+	formatted := strings.Join(names, ", ")
+	if len(annotated) != 1 && formatted != state.annotated {
+		if len(annotated) == 0 {
+			log.Infof("No pod with annotation %s=%s found for selector %v, waiting...", options.annotationKey, options.annotationValue, options.selector.String())
+		} else {
+			log.Infof("Found multiple pods with annotation %s=%s (%s), waiting for a single one...", options.annotationKey, options.annotationValue, formatted)
+		}
+	}
+	state.annotated = formatted
+
+	return annotated, len(annotated) == 1
+}
+
+// logCandidates logs the pods a pod is selected from at debug level, if they are
+// different from the last ones
+func logCandidates(log log.Logger, options Options, pods []*v1.Pod, last *string) {
+	if log.GetLevel() < logrus.DebugLevel {
+		return
+	}
+
+	candidates := []string{}
+	for _, pod := range pods {
+		ready := 0
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Ready {
+				ready++
+			}
+		}
+
+		candidate := fmt.Sprintf("%s/%s (phase %s, ready %d/%d, age %s", pod.Namespace, pod.Name, pod.Status.Phase, ready, len(pod.Spec.Containers), time.Since(pod.CreationTimestamp.Time).Round(time.Second))
+		if pod.DeletionTimestamp != nil {
+			candidate += ", terminating"
+		}
+		candidates = append(candidates, candidate+")")
+	}
+
+	formatted := strings.Join(candidates, ", ")
+	if formatted == *last {
+		return
+	}
+
+	*last = formatted
+	if options.waitingStrategy != nil {
+		log.Debugf("Found %d candidate pod(s) for selector %v, selecting with waiting strategy %T: %s", len(pods), options.selector.String(), options.waitingStrategy, formatted)
+	} else {
+		log.Debugf("Found %d candidate pod(s) for selector %v: %s", len(pods), options.selector.String(), formatted)
+	}
+}
+
 func (t *targetSelector) selectSingle(ctx context.Context, client kubectl.Client, options Options, log log.Logger, selectFn func(ctx context.Context, client kubectl.Client, options Options, log log.Logger) (bool, interface{}, error)) (interface{}, error) {
 	if options.wait == nil || *options.wait {
 		timeout := time.Minute * 10
@@ -323,7 +422,14 @@ func (t *targetSelector) selectSingleContainer(ctx context.Context, client kubec
 	return true, containers[0], nil
 }
 
-func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Client, options Options, log log.Logger) (bool, interface{}, error) {
+// selectSinglePodFn returns a select func for selectSingle that remembers what was logged in state
+func (t *targetSelector) selectSinglePodFn(state *selectState) func(ctx context.Context, client kubectl.Client, options Options, log log.Logger) (bool, interface{}, error) {
+	return func(ctx context.Context, client kubectl.Client, options Options, log log.Logger) (bool, interface{}, error) {
+		return t.selectSinglePod(ctx, client, options, log, state)
+	}
+}
+
+func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Client, options Options, log log.Logger, state *selectState) (bool, interface{}, error) {
 	stack, err := selector.NewFilterWithSort(client, options.sortContainers).SelectContainers(ctx, options.selector)
 	if err != nil {
 		return false, nil, err
@@ -331,7 +437,11 @@ func (t *targetSelector) selectSinglePod(ctx context.Context, client kubectl.Cli
 
 	// transform stack
 	pods := selector.PodsFromPodContainer(stack)
-	if options.waitingStrategy != nil {
+	logCandidates(log, options, pods, &state.candidates)
+	pods, found := filterAnnotated(log, options, pods, state)
+	if !found {
+		return false, nil, nil
+	} else if options.waitingStrategy != nil {
 		namespace := options.selector.Namespace
 		if namespace == "" {
 			namespace = client.Namespace()
@@ -0,0 +1,79 @@
+package targetselector
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl"
+	"github.com/loft-sh/devspace/pkg/devspace/kubectl/selector"
+	"github.com/loft-sh/devspace/pkg/util/log"
+	v1 "k8s.io/api/core/v1"
+)
+
+// NewUntilAnyWaitingStrategy creates a new waiting strategy
+func NewUntilAnyWaitingStrategy(initialDelay time.Duration) WaitingStrategy {
+	return &untilAny{
+		originalDelay: initialDelay,
+		initialDelay:  time.Now().Add(initialDelay),
+		podInfoPrinter: &PodInfoPrinter{
+			LastWarning: time.Now().Add(initialDelay),
+		},
+	}
+}
+
+// this waiting strategy will wait until there is a pod and selects the newest one, regardless
+// if it is running, e.g. to reach a pod in CrashLoopBackOff between its restarts. This should
+// only be used if explicitly requested, because the selected pod might not be usable.
+type untilAny struct {
+	originalDelay time.Duration
+	initialDelay  time.Time
+
+	podInfoPrinter *PodInfoPrinter
+}
+
+func (u *untilAny) Reset() WaitingStrategy {
+	return &untilAny{
+		originalDelay: u.originalDelay,
+		initialDelay:  time.Now().Add(u.originalDelay),
+		podInfoPrinter: &PodInfoPrinter{
+			LastWarning: time.Now().Add(u.originalDelay),
+		},
+	}
+}
+
+func (u *untilAny) SelectPod(ctx context.Context, client kubectl.Client, namespace string, pods []*v1.Pod, log log.Logger) (bool, *v1.Pod, error) {
+	now := time.Now()
+	if now.Before(u.initialDelay) {
+		return false, nil, nil
+	} else if len(pods) == 0 {
+		if now.After(u.initialDelay.Add(time.Second * 6)) {
+			u.podInfoPrinter.PrintNotFoundWarning(ctx, client, namespace, log)
+		}
+
+		return false, nil, nil
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		return selector.SortPodsByNewest(pods, i, j)
+	})
+	return true, pods[0], nil
+}
+
+func (u *untilAny) SelectContainer(ctx context.Context, client kubectl.Client, namespace string, containers []*selector.SelectedPodContainer, log log.Logger) (bool, *selector.SelectedPodContainer, error) {
+	now := time.Now()
+	if now.Before(u.initialDelay) {
+		return false, nil, nil
+	} else if len(containers) == 0 {
+		if now.After(u.initialDelay.Add(time.Second * 6)) {
+			u.podInfoPrinter.PrintNotFoundWarning(ctx, client, namespace, log)
+		}
+
+		return false, nil, nil
+	}
+
+	sort.Slice(containers, func(i, j int) bool {
+		return selector.SortContainersByNewest(containers, i, j)
+	})
+	return true, containers[0], nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/mgutz/ansi"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,7 +20,7 @@ import (
 	"golang.org/x/net/context"
 )
 
-func ReceiveData(stream remote.Tunnel_InitTunnelClient, closeStream <-chan bool, sessionsOut chan<- *tunnel.Session, port int32, scheme string, log logpkg.Logger) error {
+func ReceiveData(stream remote.Tunnel_InitTunnelClient, closeStream <-chan bool, sessionsOut chan<- *tunnel.Session, host string, port int32, scheme string, log logpkg.Logger) error {
 loop:
 	for {
 		m, err := stream.Recv()
@@ -54,9 +55,9 @@ loop:
 				log.Debugf("new connection %s", requestID)
 
 				// new session
-				conn, err := net.DialTimeout(strings.ToLower(scheme), fmt.Sprintf("localhost:%d", port), time.Millisecond*500)
+				conn, err := net.DialTimeout(strings.ToLower(scheme), net.JoinHostPort(host, strconv.Itoa(int(port))), time.Millisecond*500)
 				if err != nil {
-					log.Errorf("failed connecting to localhost on port %d scheme %s: %v", port, scheme, err)
+					log.Errorf("failed connecting to %s on port %d scheme %s: %v", host, port, scheme, err)
 					// close the remote connection
 					resp := &remote.SocketDataRequest{
 						RequestId:   requestID.String(),
@@ -187,7 +188,7 @@ func SendData(stream remote.Tunnel_InitTunnelClient, sessions <-chan *tunnel.Ses
 	}
 }
 
-func StartReverseForward(ctx context.Context, reader io.ReadCloser, writer io.WriteCloser, tunnels []*latest.PortMapping, stopChan chan struct{}, namespace string, name string, log logpkg.Logger) error {
+func StartReverseForward(ctx context.Context, reader io.ReadCloser, writer io.WriteCloser, tunnels []*latest.ReversePortMapping, stopChan chan struct{}, namespace string, name string, log logpkg.Logger) error {
 	scheme := "TCP"
 	closeStreams := make([]chan bool, len(tunnels))
 	defer func() {
@@ -245,8 +246,13 @@ func StartReverseForward(ctx context.Context, reader io.ReadCloser, writer io.Wr
 
 		localPort := mappings[0].Local
 		remotePort := mappings[0].Remote
+		targetHost := "localhost"
+		if portMapping.TargetHost != "" {
+			targetHost = portMapping.TargetHost
+		}
+
 		c := make(chan bool, 1)
-		go func(closeStream chan bool, localPort, remotePort int32) {
+		go func(closeStream chan bool, targetHost, name string, localPort, remotePort int32) {
 			tunnelScheme, ok := remote.TunnelScheme_value[scheme]
 			if !ok {
 				errorsChan <- fmt.Errorf("unsupported connection scheme %s", scheme)
@@ -271,7 +277,7 @@ func StartReverseForward(ctx context.Context, reader io.ReadCloser, writer io.Wr
 
 			sessions := make(chan *tunnel.Session)
 			go func() {
-				err = ReceiveData(stream, closeStream, sessions, localPort, scheme, logFile)
+				err = ReceiveData(stream, closeStream, sessions, targetHost, localPort, scheme, logFile)
 				if err != nil {
 					errorsChan <- err
 				}
@@ -284,9 +290,16 @@ func StartReverseForward(ctx context.Context, reader io.ReadCloser, writer io.Wr
 			}()
 
 			// wait until close
-			log.Donef("Port forwarding started on: %s", ansi.Color(fmt.Sprintf("%d <- %d", localPort, remotePort), "white+b"))
+			formatted := ansi.Color(fmt.Sprintf("%d <- %d", localPort, remotePort), "white+b")
+			if targetHost != "localhost" {
+				formatted = ansi.Color(fmt.Sprintf("%s:%d <- %d", targetHost, localPort, remotePort), "white+b")
+			}
+			if name != "" {
+				formatted += " (" + name + ")"
+			}
+			log.Donef("Port forwarding started on: %s", formatted)
 			<-closeStream
-		}(c, int32(localPort), int32(remotePort))
+		}(c, targetHost, portMapping.Name, int32(localPort), int32(remotePort))
 		closeStreams[i] = c
 	}
 
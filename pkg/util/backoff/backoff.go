@@ -0,0 +1,216 @@
+package backoff
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Class is the class of an error that caused a restart, each class can have its own backoff
+type Class string
+
+const (
+	// ClassDefault is the backoff of all classes that are not configured
+	ClassDefault Class = "default"
+	// ClassConnectionLost are errors of a lost connection to the pod, e.g. a pod that was restarted
+	ClassConnectionLost Class = "connectionLost"
+	// ClassSelector are errors while selecting the pod, e.g. because no matching pod was found
+	ClassSelector Class = "selector"
+	// ClassPermission are errors because of missing permissions, e.g. a forbidden RBAC request
+	ClassPermission Class = "permission"
+)
+
+// Classes are all known error classes
+var Classes = []Class{ClassDefault, ClassConnectionLost, ClassSelector, ClassPermission}
+
+// DefaultPermissionMax is the default maximum delay of permission errors, which usually don't
+// resolve themselves quickly
+const DefaultPermissionMax = time.Minute * 5
+
+// Backoff defines the delay between restart attempts. The delay starts at Initial and is multiplied
+// by Factor after each failed attempt until it reaches Max. Fields that are not set are inherited
+// from the default backoff.
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	Factor  float64
+}
+
+// Delay returns the delay before the given attempt, starting with 0
+func (b Backoff) Delay(attempt int) time.Duration {
+	factor := b.Factor
+	if factor < 1 {
+		factor = 1
+	}
+
+	max := b.Max
+	if max < b.Initial {
+		max = b.Initial
+	}
+
+	delay := float64(b.Initial) * math.Pow(factor, float64(attempt))
+	if delay > float64(max) {
+		return max
+	}
+
+	return time.Duration(delay)
+}
+
+// inherit returns the backoff with all fields that are not set taken from parent
+func (b Backoff) inherit(parent Backoff) Backoff {
+	if b.Initial == 0 {
+		b.Initial = parent.Initial
+	}
+	if b.Max == 0 {
+		b.Max = parent.Max
+	}
+	if b.Factor == 0 {
+		b.Factor = parent.Factor
+	}
+	return b
+}
+
+// Config holds the backoff of each error class. Classes that are not set inherit the backoff of
+// ClassDefault.
+type Config map[Class]Backoff
+
+// New returns the default config with a fixed delay for all classes, except for permission errors,
+// which double the delay up to DefaultPermissionMax
+func New(delay time.Duration) Config {
+	return Config{
+		ClassDefault: {
+			Initial: delay,
+			Max:     delay,
+			Factor:  1,
+		},
+		ClassPermission: {
+			Max:    DefaultPermissionMax,
+			Factor: 2,
+		},
+	}
+}
+
+// Get returns the backoff of the given class
+func (c Config) Get(class Class) Backoff {
+	if class == ClassDefault {
+		return c[ClassDefault]
+	}
+
+	return c[class].inherit(c[ClassDefault])
+}
+
+// Merge returns a copy of the config with the fields that are set in overrides replaced
+func (c Config) Merge(overrides Config) Config {
+	merged := Config{}
+	for class, b := range c {
+		merged[class] = b
+	}
+	for class, b := range overrides {
+		merged[class] = b.inherit(merged[class])
+	}
+
+	return merged
+}
+
+// Parse parses backoffs in the form class=initial[:max[:factor]], e.g. permission=10s:5m:2.
+// Empty fields are inherited, e.g. permission=:10m only changes the maximum delay.
+func Parse(values []string) (Config, error) {
+	config := Config{}
+	for _, value := range values {
+		splitted := strings.SplitN(value, "=", 2)
+		if len(splitted) != 2 {
+			return nil, errors.Errorf("invalid backoff %s, expected class=initial[:max[:factor]]", value)
+		}
+
+		class := Class(strings.TrimSpace(splitted[0]))
+		if !isClass(class) {
+			return nil, errors.Errorf("invalid backoff %s: unknown class %s, please use one of %s", value, class, joinClasses())
+		}
+
+		fields := strings.Split(splitted[1], ":")
+		if len(fields) > 3 {
+			return nil, errors.Errorf("invalid backoff %s, expected class=initial[:max[:factor]]", value)
+		}
+
+		b := Backoff{}
+		durations := []*time.Duration{&b.Initial, &b.Max}
+		for index, field := range fields {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			} else if index == 2 {
+				factor, err := strconv.ParseFloat(field, 64)
+				if err != nil || factor < 1 {
+					return nil, errors.Errorf("invalid backoff %s: factor %s has to be a number of at least 1", value, field)
+				}
+				b.Factor = factor
+				continue
+			}
+
+			duration, err := time.ParseDuration(field)
+			if err != nil || duration <= 0 {
+				return nil, errors.Errorf("invalid backoff %s: %s is not a positive duration", value, field)
+			}
+			*durations[index] = duration
+		}
+
+		config[class] = b.inherit(config[class])
+	}
+
+	return config, nil
+}
+
+// Classify returns the class of the given error
+func Classify(err error) Class {
+	var selectorErr *SelectorError
+	if kerrors.IsForbidden(err) || kerrors.IsUnauthorized(err) {
+		return ClassPermission
+	} else if errors.As(err, &selectorErr) {
+		return ClassSelector
+	}
+
+	return ClassConnectionLost
+}
+
+// SelectorError marks an error that happened while selecting a pod
+type SelectorError struct {
+	Err error
+}
+
+func (s *SelectorError) Error() string {
+	return s.Err.Error()
+}
+
+func (s *SelectorError) Unwrap() error {
+	return s.Err
+}
+
+// Selector marks the error as a selector error, nil stays nil
+func Selector(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &SelectorError{Err: err}
+}
+
+func isClass(class Class) bool {
+	for _, c := range Classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func joinClasses() string {
+	classes := make([]string, len(Classes))
+	for index, class := range Classes {
+		classes[index] = string(class)
+	}
+	return strings.Join(classes, ", ")
+}
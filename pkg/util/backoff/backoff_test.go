@@ -0,0 +1,43 @@
+package backoff
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gotest.tools/assert"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestConfig(t *testing.T) {
+	config := New(time.Second * 10)
+	assert.Equal(t, config.Get(ClassConnectionLost).Delay(0), time.Second*10)
+	assert.Equal(t, config.Get(ClassSelector).Delay(5), time.Second*10)
+	assert.Equal(t, config.Get(ClassPermission).Delay(0), time.Second*10)
+	assert.Equal(t, config.Get(ClassPermission).Delay(2), time.Second*40)
+	assert.Equal(t, config.Get(ClassPermission).Delay(100), DefaultPermissionMax)
+
+	overrides, err := Parse([]string{"default=1s:8s:2", "connectionLost=500ms", "permission=:10m"})
+	assert.NilError(t, err)
+	config = config.Merge(overrides)
+	assert.Equal(t, config.Get(ClassSelector).Delay(0), time.Second)
+	assert.Equal(t, config.Get(ClassSelector).Delay(10), time.Second*8)
+	assert.Equal(t, config.Get(ClassConnectionLost).Delay(1), time.Second)
+	assert.Equal(t, config.Get(ClassPermission).Delay(0), time.Second)
+	assert.Equal(t, config.Get(ClassPermission).Delay(100), time.Minute*10)
+
+	for _, invalid := range []string{"default", "unknown=1s", "default=1s:2s:3:4", "default=abc", "default=1s:2s:0.5"} {
+		_, err := Parse([]string{invalid})
+		assert.Assert(t, err != nil, "expected an error for %s", invalid)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	forbidden := kerrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "test", fmt.Errorf("denied"))
+	assert.Equal(t, Classify(errors.New("connection lost")), ClassConnectionLost)
+	assert.Equal(t, Classify(errors.Wrap(Selector(errors.New("not found")), "start")), ClassSelector)
+	assert.Equal(t, Classify(Selector(errors.Wrap(forbidden, "list pods"))), ClassPermission)
+	assert.Equal(t, Selector(nil), nil)
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/loft-sh/devspace/pkg/util/scanner"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultDedupWindow is the default time window in which identical consecutive messages are collapsed
+const DefaultDedupWindow = 2 * time.Second
+
+// NewDedupLogger returns a logger that collapses identical consecutive messages that are
+// logged within the given window into a single "(last message repeated N times)" line.
+func NewDedupLogger(base Logger, window time.Duration) Logger {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+
+	return &dedupLogger{
+		Logger: base,
+		state: &dedupState{
+			window: window,
+		},
+	}
+}
+
+type dedupLogger struct {
+	Logger
+
+	state *dedupState
+}
+
+type dedupState struct {
+	m      sync.Mutex
+	window time.Duration
+
+	last      string
+	lastLevel logrus.Level
+	lastTime  time.Time
+	repeated  int
+	timer     *time.Timer
+}
+
+// log prints the message with the given print function unless it is a repetition
+// of the last message within the window
+func (d *dedupLogger) log(level logrus.Level, message string, print func()) {
+	s := d.state
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	key := strconv.Itoa(int(level)) + ":" + message
+	now := time.Now()
+	if key == s.last && now.Sub(s.lastTime) < s.window {
+		s.repeated++
+		if s.timer == nil {
+			s.timer = time.AfterFunc(s.window, d.flushPending)
+		}
+		return
+	}
+
+	d.flush()
+	s.last = key
+	s.lastLevel = level
+	s.lastTime = now
+	print()
+}
+
+func (d *dedupLogger) flushPending() {
+	d.state.m.Lock()
+	defer d.state.m.Unlock()
+
+	d.flush()
+	d.state.last = ""
+}
+
+// flush prints the number of suppressed messages, the lock has to be held
+func (d *dedupLogger) flush() {
+	s := d.state
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.repeated > 0 {
+		d.Logger.Printf(s.lastLevel, "(last message repeated %d times)", s.repeated)
+		s.repeated = 0
+	}
+}
+
+func (d *dedupLogger) wrap(base Logger) Logger {
+	return &dedupLogger{
+		Logger: base,
+		state: &dedupState{
+			window: d.state.window,
+		},
+	}
+}
+
+func (d *dedupLogger) Debug(args ...interface{}) {
+	d.log(logrus.DebugLevel, fmt.Sprint(args...), func() { d.Logger.Debug(args...) })
+}
+
+func (d *dedupLogger) Debugf(format string, args ...interface{}) {
+	d.log(logrus.DebugLevel, fmt.Sprintf(format, args...), func() { d.Logger.Debugf(format, args...) })
+}
+
+func (d *dedupLogger) Info(args ...interface{}) {
+	d.log(logrus.InfoLevel, fmt.Sprint(args...), func() { d.Logger.Info(args...) })
+}
+
+func (d *dedupLogger) Infof(format string, args ...interface{}) {
+	d.log(logrus.InfoLevel, fmt.Sprintf(format, args...), func() { d.Logger.Infof(format, args...) })
+}
+
+func (d *dedupLogger) Warn(args ...interface{}) {
+	d.log(logrus.WarnLevel, fmt.Sprint(args...), func() { d.Logger.Warn(args...) })
+}
+
+func (d *dedupLogger) Warnf(format string, args ...interface{}) {
+	d.log(logrus.WarnLevel, fmt.Sprintf(format, args...), func() { d.Logger.Warnf(format, args...) })
+}
+
+func (d *dedupLogger) Error(args ...interface{}) {
+	d.log(logrus.ErrorLevel, fmt.Sprint(args...), func() { d.Logger.Error(args...) })
+}
+
+func (d *dedupLogger) Errorf(format string, args ...interface{}) {
+	d.log(logrus.ErrorLevel, fmt.Sprintf(format, args...), func() { d.Logger.Errorf(format, args...) })
+}
+
+func (d *dedupLogger) Done(args ...interface{}) {
+	d.log(logrus.InfoLevel, fmt.Sprint(args...), func() { d.Logger.Done(args...) })
+}
+
+func (d *dedupLogger) Donef(format string, args ...interface{}) {
+	d.log(logrus.InfoLevel, fmt.Sprintf(format, args...), func() { d.Logger.Donef(format, args...) })
+}
+
+func (d *dedupLogger) Print(level logrus.Level, args ...interface{}) {
+	d.log(level, fmt.Sprint(args...), func() { d.Logger.Print(level, args...) })
+}
+
+func (d *dedupLogger) Printf(level logrus.Level, format string, args ...interface{}) {
+	d.log(level, fmt.Sprintf(format, args...), func() { d.Logger.Printf(level, format, args...) })
+}
+
+func (d *dedupLogger) WriteString(level logrus.Level, message string) {
+	d.log(level, message, func() { d.Logger.WriteString(level, message) })
+}
+
+func (d *dedupLogger) Writer(level logrus.Level, raw bool) io.WriteCloser {
+	if d.GetLevel() < level {
+		return &NopCloser{io.Discard}
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		sa := scanner.NewScanner(reader)
+		for sa.Scan() {
+			if raw {
+				d.WriteString(level, sa.Text()+"\n")
+			} else {
+				d.Print(level, sa.Text())
+			}
+		}
+	}()
+
+	return writer
+}
+
+func (d *dedupLogger) WithLevel(level logrus.Level) Logger {
+	return d.wrap(d.Logger.WithLevel(level))
+}
+
+func (d *dedupLogger) ErrorStreamOnly() Logger {
+	return d.wrap(d.Logger.ErrorStreamOnly())
+}
+
+func (d *dedupLogger) WithPrefix(prefix string) Logger {
+	return d.wrap(d.Logger.WithPrefix(prefix))
+}
+
+func (d *dedupLogger) WithNestedPrefix(prefix string) Logger {
+	return d.wrap(d.Logger.WithNestedPrefix(prefix))
+}
+
+func (d *dedupLogger) WithPrefixColor(prefix, color string) Logger {
+	return d.wrap(d.Logger.WithPrefixColor(prefix, color))
+}
+
+func (d *dedupLogger) WithPrefixLabel(label *PrefixLabel, color string) Logger {
+	return d.wrap(d.Logger.WithPrefixLabel(label, color))
+}
+
+func (d *dedupLogger) WithSink(sink Logger) Logger {
+	return d.wrap(d.Logger.WithSink(sink))
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestDedupLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewDedupLogger(NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, RawFormat), time.Minute)
+
+	for i := 0; i < 10; i++ {
+		logger.Info("same")
+	}
+	logger.Info("other")
+	logger.Infof("other %s", "format")
+
+	assert.Equal(t, out.String(), "same\n(last message repeated 9 times)\nother\nother format\n")
+}
+
+func TestDedupLoggerFlushesAfterWindow(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewDedupLogger(NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, RawFormat), time.Millisecond*50)
+
+	logger.Info("same")
+	logger.Info("same")
+	time.Sleep(time.Millisecond * 200)
+	logger.Info("same")
+
+	assert.Equal(t, out.String(), "same\n(last message repeated 1 times)\nsame\n")
+}
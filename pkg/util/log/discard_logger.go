@@ -121,10 +121,18 @@ func (d *DiscardLogger) WithPrefix(prefix string) Logger {
 	return d
 }
 
+func (d *DiscardLogger) WithNestedPrefix(prefix string) Logger {
+	return d
+}
+
 func (d *DiscardLogger) WithPrefixColor(prefix, color string) Logger {
 	return d
 }
 
+func (d *DiscardLogger) WithPrefixLabel(label *PrefixLabel, color string) Logger {
+	return d
+}
+
 func (d *DiscardLogger) ErrorStreamOnly() Logger {
 	return d
 }
@@ -4,11 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/acarl005/stripansi"
 
+	"github.com/loft-sh/devspace/pkg/devspace/env"
 	"github.com/loft-sh/devspace/pkg/util/survey"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -23,17 +28,101 @@ var logsMutex sync.Mutex
 
 var overrideOnce sync.Once
 
+// DevSpaceDisableFileLogs disables writing the logs of dev pods to the log directory if set to true
+const DevSpaceDisableFileLogs = "DEVSPACE_DISABLE_FILE_LOGS"
+
+// FileLogsDisabled returns true if the logs of dev pods should not be written to files
+func FileLogsDisabled() bool {
+	return env.GlobalGetEnv(DevSpaceDisableFileLogs) == "true"
+}
+
+// invalidScopeChars matches the characters of a scope that are not allowed in a file name
+var invalidScopeChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
 type fileLogger struct {
 	logger *logrus.Logger
 
 	m        *sync.Mutex
 	level    logrus.Level
 	sinks    []Logger
-	prefixes []string
+	prefixes []Prefix
 }
 
 func GetDevPodFileLogger(devPodName string) Logger {
-	return GetFileLogger("dev." + strings.TrimSpace(devPodName))
+	return GetScopedDevPodFileLogger("", devPodName)
+}
+
+// GetScopedDevPodFileLogger returns the file logger of the given dev pod within the given scope.
+// Dev pods with the same name in different scopes write to different log files.
+func GetScopedDevPodFileLogger(scope, devPodName string) Logger {
+	return GetFileLogger(devPodFileLogName(scope, devPodName))
+}
+
+// devPodFileLogName returns the name of the log file of a dev pod within a scope
+func devPodFileLogName(scope, devPodName string) string {
+	scope = strings.TrimSpace(scope)
+	if scope == "" {
+		return "dev." + strings.TrimSpace(devPodName)
+	}
+
+	return "dev." + invalidScopeChars.ReplaceAllString(scope, "_") + "." + strings.TrimSpace(devPodName)
+}
+
+// FileLogPolicy defines what happens to the log file of a dev pod when the dev pod is stopped
+type FileLogPolicy string
+
+const (
+	// FileLogKeep keeps the log file, the next session of the dev pod appends to it
+	FileLogKeep FileLogPolicy = "keep"
+	// FileLogArchive renames the log file with a timestamp, like a rotated log file
+	FileLogArchive FileLogPolicy = "archive"
+	// FileLogDelete deletes the log file
+	FileLogDelete FileLogPolicy = "delete"
+)
+
+// FileLogPolicies are all known file log policies
+var FileLogPolicies = []FileLogPolicy{FileLogKeep, FileLogArchive, FileLogDelete}
+
+// ReleaseScopedDevPodFileLog closes the log file of the given dev pod within the given scope and
+// archives or deletes it according to the policy. Messages that are logged afterwards are written
+// to a new log file. Archived files are kept as long as rotated log files.
+func ReleaseScopedDevPodFileLog(scope, devPodName string, policy FileLogPolicy) error {
+	return releaseFileLog(devPodFileLogName(scope, devPodName), policy)
+}
+
+func releaseFileLog(filename string, policy FileLogPolicy) error {
+	if policy == "" || policy == FileLogKeep {
+		return nil
+	}
+
+	filename = strings.TrimSpace(filename)
+	logsMutex.Lock()
+	defer logsMutex.Unlock()
+
+	// the file has to be closed first, it is opened again by the next message
+	if l, ok := logs[filename].(*fileLogger); ok {
+		if closer, ok := l.logger.Out.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				return fmt.Errorf("close log file: %v", err)
+			}
+		}
+	}
+
+	var err error
+	path := filepath.Join(Logdir, filename+".log")
+	switch policy {
+	case FileLogArchive:
+		err = os.Rename(path, filepath.Join(Logdir, filename+"-"+time.Now().UTC().Format(rotatedTimeFormat)+".log"))
+	case FileLogDelete:
+		err = os.Remove(path)
+	default:
+		return fmt.Errorf("unknown file log policy %s", policy)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
 }
 
 // GetFileLogger returns a logger instance for the specified filename
@@ -96,7 +185,7 @@ func OverrideRuntimeErrorHandler(discard bool) {
 func (f *fileLogger) addPrefixes(message string) string {
 	prefix := ""
 	for _, p := range f.prefixes {
-		prefix += p
+		prefix += p.text()
 	}
 
 	return prefix + message
@@ -220,7 +309,7 @@ func (f *fileLogger) Done(args ...interface{}) {
 		return
 	}
 
-	f.logger.Info(f.addPrefixes(stripEscapeSequences(fmt.Sprint(args...))))
+	f.logger.Info(f.addPrefixes(stripEscapeSequences(withDoneMarker(fmt.Sprint(args...)))))
 }
 
 func (f *fileLogger) Donef(format string, args ...interface{}) {
@@ -231,7 +320,7 @@ func (f *fileLogger) Donef(format string, args ...interface{}) {
 		return
 	}
 
-	f.logger.Info(f.addPrefixes(stripEscapeSequences(fmt.Sprintf(format, args...))))
+	f.logger.Info(f.addPrefixes(stripEscapeSequences(withDoneMarker(fmt.Sprintf(format, args...)))))
 }
 
 func (f *fileLogger) Print(level logrus.Level, args ...interface{}) {
@@ -354,7 +443,21 @@ func (f *fileLogger) WithPrefix(prefix string) Logger {
 
 	n := *f
 	n.m = &sync.Mutex{}
-	n.prefixes = append(n.prefixes, prefix)
+	n.prefixes = []Prefix{}
+	n.prefixes = append(n.prefixes, f.prefixes...)
+	n.prefixes = append(n.prefixes, Prefix{Prefix: prefix})
+	return &n
+}
+
+func (f *fileLogger) WithNestedPrefix(prefix string) Logger {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	n := *f
+	n.m = &sync.Mutex{}
+	n.prefixes = []Prefix{}
+	n.prefixes = append(n.prefixes, f.prefixes...)
+	n.prefixes = append(n.prefixes, Prefix{Prefix: nested(f.prefixes, prefix).Prefix})
 	return &n
 }
 
@@ -364,7 +467,21 @@ func (f *fileLogger) WithPrefixColor(prefix, color string) Logger {
 
 	n := *f
 	n.m = &sync.Mutex{}
-	n.prefixes = append(n.prefixes, prefix)
+	n.prefixes = []Prefix{}
+	n.prefixes = append(n.prefixes, f.prefixes...)
+	n.prefixes = append(n.prefixes, Prefix{Prefix: prefix})
+	return &n
+}
+
+func (f *fileLogger) WithPrefixLabel(label *PrefixLabel, color string) Logger {
+	f.m.Lock()
+	defer f.m.Unlock()
+
+	n := *f
+	n.m = &sync.Mutex{}
+	n.prefixes = []Prefix{}
+	n.prefixes = append(n.prefixes, f.prefixes...)
+	n.prefixes = append(n.prefixes, Prefix{Label: label})
 	return &n
 }
 
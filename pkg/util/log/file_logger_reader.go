@@ -0,0 +1,139 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxTailBytes is the maximum number of bytes read from the end of a single log file
+const maxTailBytes = 1024 * 1024
+
+// rotatedTimeFormat is the time format of rotated log files
+const rotatedTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileLogLine is a single line of a file log
+type FileLogLine struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"msg"`
+}
+
+// ReadDevPodFileLogs returns the last n lines of the file log of the given dev pod
+func ReadDevPodFileLogs(devPodName string, n int) ([]FileLogLine, error) {
+	return ReadScopedDevPodFileLogs("", devPodName, n)
+}
+
+// ReadScopedDevPodFileLogs returns the last n lines of the file log of the given dev pod within the given scope
+func ReadScopedDevPodFileLogs(scope, devPodName string, n int) ([]FileLogLine, error) {
+	return ReadFileLogs(devPodFileLogName(scope, devPodName), n)
+}
+
+// ReadFileLogs returns the last n lines of the file log with the given name, oldest first.
+// If the current log file has less than n lines, the rotated log files are read as well.
+// At most 1MB is read from the end of each file.
+func ReadFileLogs(filename string, n int) ([]FileLogLine, error) {
+	filename = strings.TrimSpace(filename)
+	if n <= 0 {
+		return []FileLogLine{}, nil
+	}
+
+	// rotated files are named <filename>-<timestamp>.log, so the newest one sorts last
+	matches, err := filepath.Glob(filepath.Join(Logdir, filename+"-*.log"))
+	if err != nil {
+		return nil, err
+	}
+	rotated := []string{}
+	for _, match := range matches {
+		timestamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), filename+"-"), ".log")
+		if _, err := time.Parse(rotatedTimeFormat, timestamp); err == nil {
+			rotated = append(rotated, match)
+		}
+	}
+	sort.Strings(rotated)
+	files := append([]string{filepath.Join(Logdir, filename+".log")}, reverse(rotated)...)
+
+	lines := []FileLogLine{}
+	for _, file := range files {
+		fileLines, err := tailFile(file, n-len(lines))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		lines = append(fileLines, lines...)
+		if len(lines) >= n {
+			break
+		}
+	}
+
+	return lines, nil
+}
+
+// tailFile returns the last n lines of the given file
+func tailFile(file string, n int) ([]FileLogLine, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := stat.Size() - maxTailBytes
+	if offset < 0 {
+		offset = 0
+	}
+
+	data := make([]byte, stat.Size()-offset)
+	_, err = f.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	rawLines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+	if offset > 0 && len(rawLines) > 0 {
+		// the first line is most likely cut
+		rawLines = rawLines[1:]
+	}
+	if len(rawLines) > n {
+		rawLines = rawLines[len(rawLines)-n:]
+	}
+
+	lines := []FileLogLine{}
+	for _, rawLine := range rawLines {
+		if len(rawLine) == 0 {
+			continue
+		}
+
+		line := FileLogLine{}
+		err := json.Unmarshal(rawLine, &line)
+		if err != nil {
+			line = FileLogLine{Message: string(rawLine)}
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func reverse(s []string) []string {
+	reversed := make([]string, 0, len(s))
+	for i := len(s) - 1; i >= 0; i-- {
+		reversed = append(reversed, s[i])
+	}
+
+	return reversed
+}
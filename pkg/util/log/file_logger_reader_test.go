@@ -0,0 +1,50 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestReadFileLogs(t *testing.T) {
+	oldLogdir := Logdir
+	defer func() { Logdir = oldLogdir }()
+	Logdir = t.TempDir()
+
+	files := map[string]string{
+		"dev.app-2022-01-01T10-00-00.000.log": `{"level":"info","msg":"first","time":"2022-01-01T09:00:00Z"}` + "\n",
+		"dev.app-2022-01-02T10-00-00.000.log": `{"level":"info","msg":"second","time":"2022-01-02T09:00:00Z"}` + "\n" + `{"level":"warning","msg":"third","time":"2022-01-02T09:30:00Z"}` + "\n",
+		"dev.app-worker.log":                  `{"level":"info","msg":"other dev pod","time":"2022-01-03T09:00:00Z"}` + "\n",
+		"dev.app.log":                         `{"level":"info","msg":"fourth","time":"2022-01-03T09:00:00Z"}` + "\n" + "not json\n",
+	}
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(Logdir, name), []byte(content), 0644))
+	}
+
+	lines, err := ReadDevPodFileLogs("app", 2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, messages(lines), []string{"fourth", "not json"})
+
+	lines, err = ReadDevPodFileLogs("app", 4)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, messages(lines), []string{"second", "third", "fourth", "not json"})
+	assert.Equal(t, lines[1].Level, "warning")
+
+	lines, err = ReadDevPodFileLogs("app", 10)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, messages(lines), []string{"first", "second", "third", "fourth", "not json"})
+
+	lines, err = ReadDevPodFileLogs("other", 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(lines), 0)
+}
+
+func messages(lines []FileLogLine) []string {
+	messages := []string{}
+	for _, line := range lines {
+		messages = append(messages, line.Message)
+	}
+	return messages
+}
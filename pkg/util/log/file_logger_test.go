@@ -0,0 +1,49 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestScopedDevPodFileLogger(t *testing.T) {
+	assert.Equal(t, devPodFileLogName("", "app"), "dev.app")
+	assert.Equal(t, devPodFileLogName("session-1", "app"), "dev.session-1.app")
+	assert.Equal(t, devPodFileLogName("arn:aws:eks/cluster", "app"), "dev.arn_aws_eks_cluster.app")
+
+	assert.Assert(t, GetScopedDevPodFileLogger("", "app") == GetDevPodFileLogger("app"))
+	assert.Assert(t, GetScopedDevPodFileLogger("session-1", "app") == GetScopedDevPodFileLogger("session-1", "app"))
+	assert.Assert(t, GetScopedDevPodFileLogger("session-1", "app") != GetScopedDevPodFileLogger("session-2", "app"))
+}
+
+func TestReleaseDevPodFileLog(t *testing.T) {
+	oldLogdir := Logdir
+	defer func() { Logdir = oldLogdir }()
+	Logdir = t.TempDir() + "/"
+
+	logger := GetScopedDevPodFileLogger("release", "app")
+	logger.Info("first")
+	assert.NilError(t, ReleaseScopedDevPodFileLog("release", "app", FileLogKeep))
+	assert.NilError(t, ReleaseScopedDevPodFileLog("release", "app", FileLogArchive))
+	_, err := os.Stat(filepath.Join(Logdir, "dev.release.app.log"))
+	assert.Assert(t, os.IsNotExist(err), "log file should be archived")
+
+	// the next message is written to a new file, the archived one is still read
+	logger.Info("second")
+	lines, err := ReadScopedDevPodFileLogs("release", "app", 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(lines), 2)
+	assert.Equal(t, lines[0].Message, "first")
+	assert.Equal(t, lines[1].Message, "second")
+
+	assert.NilError(t, ReleaseScopedDevPodFileLog("release", "app", FileLogDelete))
+	lines, err = ReadScopedDevPodFileLogs("release", "app", 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(lines), 1)
+	assert.Equal(t, lines[0].Message, "first")
+
+	// releasing a file that doesn't exist is not an error
+	assert.NilError(t, ReleaseScopedDevPodFileLog("release", "app", FileLogDelete))
+}
@@ -48,6 +48,17 @@ func GetBaseInstance() Logger {
 	return baseLog
 }
 
+// RepeatedLevel returns the level a repeated message should be printed with. If quiet is
+// enabled, only the first occurrence is printed with the given level and all following
+// occurrences with debug level.
+func RepeatedLevel(level logrus.Level, occurrence int, quiet bool) logrus.Level {
+	if quiet && occurrence > 0 && level < logrus.DebugLevel {
+		return logrus.DebugLevel
+	}
+
+	return level
+}
+
 func PrintTable(s Logger, header []string, values [][]string) {
 	PrintTableWithOptions(s, header, values, nil)
 }
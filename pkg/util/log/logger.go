@@ -28,7 +28,12 @@ type Logger interface {
 	Question(params *survey.QuestionOptions) (string, error)
 	ErrorStreamOnly() Logger
 	WithPrefix(prefix string) Logger
+	// WithNestedPrefix is like WithPrefix, but the prefix keeps the color of the current
+	// prefix and is separated from it by a space, e.g. to tag the lines of a component
+	WithNestedPrefix(prefix string) Logger
 	WithPrefixColor(prefix, color string) Logger
+	// WithPrefixLabel is like WithPrefixColor, but prints the current text of the label
+	WithPrefixLabel(label *PrefixLabel, color string) Logger
 	WithSink(sink Logger) Logger
 	AddSink(sink Logger)
 
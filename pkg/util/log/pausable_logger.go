@@ -0,0 +1,170 @@
+package log
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PauseSwitch pauses and resumes all loggers that were created with it
+type PauseSwitch struct {
+	paused int32
+}
+
+// Pause discards all following messages of the loggers until Resume is called
+func (p *PauseSwitch) Pause() {
+	atomic.StoreInt32(&p.paused, 1)
+}
+
+// Resume writes the following messages of the loggers again
+func (p *PauseSwitch) Resume() {
+	atomic.StoreInt32(&p.paused, 0)
+}
+
+// Paused returns true if the loggers are paused
+func (p *PauseSwitch) Paused() bool {
+	return atomic.LoadInt32(&p.paused) == 1
+}
+
+// NewPausableLogger returns a logger that discards all messages while the pause switch is paused.
+// Loggers derived from it share the same switch. Fatal messages are always written.
+func NewPausableLogger(base Logger, pause *PauseSwitch) Logger {
+	return &pausableLogger{
+		Logger: base,
+		pause:  pause,
+	}
+}
+
+type pausableLogger struct {
+	Logger
+
+	pause *PauseSwitch
+}
+
+func (p *pausableLogger) log(print func()) {
+	if p.pause.Paused() {
+		return
+	}
+
+	print()
+}
+
+func (p *pausableLogger) wrap(base Logger) Logger {
+	return &pausableLogger{
+		Logger: base,
+		pause:  p.pause,
+	}
+}
+
+func (p *pausableLogger) Debug(args ...interface{}) {
+	p.log(func() { p.Logger.Debug(args...) })
+}
+
+func (p *pausableLogger) Debugf(format string, args ...interface{}) {
+	p.log(func() { p.Logger.Debugf(format, args...) })
+}
+
+func (p *pausableLogger) Info(args ...interface{}) {
+	p.log(func() { p.Logger.Info(args...) })
+}
+
+func (p *pausableLogger) Infof(format string, args ...interface{}) {
+	p.log(func() { p.Logger.Infof(format, args...) })
+}
+
+func (p *pausableLogger) Warn(args ...interface{}) {
+	p.log(func() { p.Logger.Warn(args...) })
+}
+
+func (p *pausableLogger) Warnf(format string, args ...interface{}) {
+	p.log(func() { p.Logger.Warnf(format, args...) })
+}
+
+func (p *pausableLogger) Error(args ...interface{}) {
+	p.log(func() { p.Logger.Error(args...) })
+}
+
+func (p *pausableLogger) Errorf(format string, args ...interface{}) {
+	p.log(func() { p.Logger.Errorf(format, args...) })
+}
+
+func (p *pausableLogger) Done(args ...interface{}) {
+	p.log(func() { p.Logger.Done(args...) })
+}
+
+func (p *pausableLogger) Donef(format string, args ...interface{}) {
+	p.log(func() { p.Logger.Donef(format, args...) })
+}
+
+func (p *pausableLogger) Print(level logrus.Level, args ...interface{}) {
+	if level <= logrus.FatalLevel {
+		p.Logger.Print(level, args...)
+		return
+	}
+
+	p.log(func() { p.Logger.Print(level, args...) })
+}
+
+func (p *pausableLogger) Printf(level logrus.Level, format string, args ...interface{}) {
+	if level <= logrus.FatalLevel {
+		p.Logger.Printf(level, format, args...)
+		return
+	}
+
+	p.log(func() { p.Logger.Printf(level, format, args...) })
+}
+
+func (p *pausableLogger) WriteString(level logrus.Level, message string) {
+	p.log(func() { p.Logger.WriteString(level, message) })
+}
+
+func (p *pausableLogger) Writer(level logrus.Level, raw bool) io.WriteCloser {
+	return &pausableWriter{
+		WriteCloser: p.Logger.Writer(level, raw),
+		pause:       p.pause,
+	}
+}
+
+func (p *pausableLogger) WithLevel(level logrus.Level) Logger {
+	return p.wrap(p.Logger.WithLevel(level))
+}
+
+func (p *pausableLogger) ErrorStreamOnly() Logger {
+	return p.wrap(p.Logger.ErrorStreamOnly())
+}
+
+func (p *pausableLogger) WithPrefix(prefix string) Logger {
+	return p.wrap(p.Logger.WithPrefix(prefix))
+}
+
+func (p *pausableLogger) WithNestedPrefix(prefix string) Logger {
+	return p.wrap(p.Logger.WithNestedPrefix(prefix))
+}
+
+func (p *pausableLogger) WithPrefixColor(prefix, color string) Logger {
+	return p.wrap(p.Logger.WithPrefixColor(prefix, color))
+}
+
+func (p *pausableLogger) WithPrefixLabel(label *PrefixLabel, color string) Logger {
+	return p.wrap(p.Logger.WithPrefixLabel(label, color))
+}
+
+func (p *pausableLogger) WithSink(sink Logger) Logger {
+	return p.wrap(p.Logger.WithSink(sink))
+}
+
+// pausableWriter discards the written data while the pause switch is paused
+type pausableWriter struct {
+	io.WriteCloser
+
+	pause *PauseSwitch
+}
+
+func (p *pausableWriter) Write(data []byte) (int, error) {
+	if p.pause.Paused() {
+		return len(data), nil
+	}
+
+	return p.WriteCloser.Write(data)
+}
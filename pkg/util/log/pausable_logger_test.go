@@ -0,0 +1,44 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/acarl005/stripansi"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestPausableLogger(t *testing.T) {
+	console := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+	pause := &PauseSwitch{}
+	logger := NewStreamLoggerWithFormat(console, console, logrus.InfoLevel, RawFormat).WithSink(NewPausableLogger(NewStreamLoggerWithFormat(file, file, logrus.InfoLevel, RawFormat), pause))
+
+	logger.Info("first")
+	pause.Pause()
+	logger.Info("second")
+	logger.WithPrefix("sub ").Info("third")
+	pause.Resume()
+	logger.Info("fourth")
+	assert.Equal(t, stripansi.Strip(console.String()), "first\nsecond\nsub third\nfourth\n")
+	assert.DeepEqual(t, strings.Fields(file.String()), []string{"first", "fourth"})
+
+	// pausing concurrently to writes is safe
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				pause.Pause()
+			} else {
+				pause.Resume()
+			}
+			logger.Info("message")
+		}(i)
+	}
+	wg.Wait()
+}
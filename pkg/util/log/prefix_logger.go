@@ -0,0 +1,194 @@
+package log
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/loft-sh/devspace/pkg/devspace/env"
+	"github.com/loft-sh/devspace/pkg/util/hash"
+	"github.com/mgutz/ansi"
+)
+
+// DevSpaceLogPrefixSymbols overrides the comma separated symbols that are printed in front of colored
+// prefixes if colors are disabled, "none" prints no symbols
+const DevSpaceLogPrefixSymbols = "DEVSPACE_LOG_PREFIX_SYMBOLS"
+
+// DevSpaceLogColors selects how prefix colors are assigned. "ordered" assigns the colors in the order
+// set with SetColorOrder instead of deriving them from a hash, e.g. for reproducible log snapshots.
+const DevSpaceLogColors = "DEVSPACE_LOG_COLORS"
+
+// colorOrder is the index into Colors of each key in ordered mode
+var colorOrder = struct {
+	m    sync.Mutex
+	keys map[string]int
+}{keys: map[string]int{}}
+
+// OrderedColors returns true if colors are assigned in order instead of by hash
+func OrderedColors() bool {
+	return env.GlobalGetEnv(DevSpaceLogColors) == "ordered"
+}
+
+// SetColorOrder assigns the colors in the given order of keys if DEVSPACE_LOG_COLORS is ordered, the
+// first key gets Colors[0] and so on. Keys that have a color already keep it and keys that are not
+// part of the order get the next free color when they are first used.
+func SetColorOrder(keys []string) {
+	colorOrder.m.Lock()
+	defer colorOrder.m.Unlock()
+
+	for _, key := range keys {
+		if _, ok := colorOrder.keys[key]; !ok {
+			colorOrder.keys[key] = len(colorOrder.keys)
+		}
+	}
+}
+
+// orderedColor returns the color of the key in ordered mode
+func orderedColor(key string) string {
+	colorOrder.m.Lock()
+	defer colorOrder.m.Unlock()
+
+	index, ok := colorOrder.keys[key]
+	if !ok {
+		index = len(colorOrder.keys)
+		colorOrder.keys[key] = index
+	}
+
+	return Colors[index%len(Colors)]
+}
+
+// PrefixSymbols are the default symbols that distinguish colored prefixes, e.g. the ones of
+// different dev pods, if colors are disabled
+var PrefixSymbols = []string{"*", "+", "#", "@", "%", "&", "=", "~", "^"}
+
+var colorsDisabled int32
+
+// DisableColors disables or enables the colors of all log output. While colors are disabled,
+// colored prefixes are preceded by a symbol that is stable for the prefix color instead.
+func DisableColors(disabled bool) {
+	ansi.DisableColors(disabled)
+	if disabled {
+		atomic.StoreInt32(&colorsDisabled, 1)
+	} else {
+		atomic.StoreInt32(&colorsDisabled, 0)
+	}
+}
+
+// NewDefaultPrefixLogger returns a logger that prefixes all messages with the given prefix.
+// The prefix color is derived from the prefix itself.
+func NewDefaultPrefixLogger(prefix string, base Logger) Logger {
+	return NewDefaultPrefixLoggerWithKey(prefix, prefix, base)
+}
+
+// NewDefaultPrefixLoggerWithKey returns a logger that prefixes all messages with the given prefix,
+// but derives the prefix color from colorKey instead. This keeps the color stable for prefixes
+// that change over time, e.g. because they contain a pod name.
+func NewDefaultPrefixLoggerWithKey(prefix, colorKey string, base Logger) Logger {
+	return base.WithPrefixColor(prefix, colorForKey(colorKey))
+}
+
+// PrefixLabel is a prefix whose text can be changed while it is used by a logger, e.g. to rename
+// the prefix of a running dev pod. It is safe for concurrent use.
+type PrefixLabel struct {
+	m    sync.RWMutex
+	text string
+}
+
+// NewPrefixLabel returns a prefix label with the given text
+func NewPrefixLabel(text string) *PrefixLabel {
+	return &PrefixLabel{text: text}
+}
+
+// Set changes the text of the label, messages that are logged afterwards are printed with it
+func (p *PrefixLabel) Set(text string) {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	p.text = text
+}
+
+func (p *PrefixLabel) String() string {
+	p.m.RLock()
+	defer p.m.RUnlock()
+
+	return p.text
+}
+
+// NewPrefixLabelLoggerWithKey returns a logger that prefixes all messages with the current text of
+// the label. Like NewDefaultPrefixLoggerWithKey, the color is derived from colorKey, so it doesn't
+// change when the label is renamed.
+func NewPrefixLabelLoggerWithKey(label *PrefixLabel, colorKey string, base Logger) Logger {
+	return base.WithPrefixLabel(label, colorForKey(colorKey))
+}
+
+// ColorForPrefix returns the ansi color name, e.g. blue+b, a prefix is printed with in the terminal.
+// For prefixes created with NewDefaultPrefixLoggerWithKey, e.g. the ones of dev pods that use the
+// dev pod name as key, the key has to be passed instead.
+func ColorForPrefix(prefix string) string {
+	return colorForKey(prefix)
+}
+
+// colorForKey returns the color a prefix with the given key is printed with
+func colorForKey(key string) string {
+	if OrderedColors() {
+		return orderedColor(key)
+	}
+
+	hashNumber := int(hash.StringToNumber(key))
+	if hashNumber < 0 {
+		hashNumber = hashNumber * -1
+	}
+
+	return Colors[hashNumber%len(Colors)]
+}
+
+// prefixSymbol returns the symbol a prefix with the given color is preceded with if colors
+// are disabled, or an empty string if no symbol should be printed
+func prefixSymbol(color string) string {
+	if color == "" || atomic.LoadInt32(&colorsDisabled) == 0 {
+		return ""
+	}
+
+	symbols := PrefixSymbols
+	if value := env.GlobalGetEnv(DevSpaceLogPrefixSymbols); value == "none" {
+		return ""
+	} else if value != "" {
+		symbols = strings.Split(value, ",")
+	}
+
+	for index, c := range Colors {
+		if c == color {
+			return strings.TrimSpace(symbols[index%len(symbols)]) + " "
+		}
+	}
+
+	return ""
+}
+
+// nested returns the prefix nested in the last of the given prefixes, it keeps the color of the
+// last prefix. Without prefixes the color is derived from the prefix itself like for WithPrefix.
+func nested(prefixes []Prefix, prefix string) Prefix {
+	if len(prefixes) == 0 {
+		return Prefix{Prefix: prefix, Color: colorForKey(prefix)}
+	}
+
+	parent := prefixes[len(prefixes)-1]
+	return Prefix{Prefix: nestedPrefix(parent.text(), prefix), Color: parent.Color}
+}
+
+// nestedPrefix returns the prefix that is nested in the parent prefix. If the parent prefix
+// doesn't end with a whitespace, a space is added so that e.g. "dev:app" and "[pf] " are
+// printed as "dev:app [pf] " instead of being glued together.
+func nestedPrefix(parent, prefix string) string {
+	if parent == "" || prefix == "" || strings.HasPrefix(prefix, " ") {
+		return prefix
+	}
+
+	last := rune(parent[len(parent)-1])
+	if unicode.IsSpace(last) {
+		return prefix
+	}
+
+	return " " + prefix
+}
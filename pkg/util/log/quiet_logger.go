@@ -0,0 +1,254 @@
+package log
+
+import (
+	"io"
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/util/scanner"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultQuietBufferSize is the default number of messages a quiet logger keeps
+const DefaultQuietBufferSize = 100
+
+// NewQuietLogger returns a logger that holds back info and debug messages and only prints
+// the last size of them once an error is logged, all other held back messages are discarded.
+// Warnings and errors are printed right away. Sinks of the returned logger still receive
+// every message.
+func NewQuietLogger(base Logger, size int) Logger {
+	if size <= 0 {
+		size = DefaultQuietBufferSize
+	}
+
+	return &quietLogger{
+		Logger: base,
+		state: &quietState{
+			size: size,
+		},
+	}
+}
+
+type quietLogger struct {
+	Logger
+
+	// m protects sinks, which can be added while messages are logged
+	m     sync.Mutex
+	sinks []Logger
+	state *quietState
+}
+
+// quietState is shared by all loggers derived from the same quiet logger, so that
+// an error prints the held back messages of all of them
+type quietState struct {
+	m    sync.Mutex
+	size int
+
+	// messages is a ring buffer, next is the index the next message is written to
+	messages  []func()
+	next      int
+	discarded int
+}
+
+func (q *quietLogger) log(level logrus.Level, print func(l Logger)) {
+	for _, sink := range q.currentSinks() {
+		print(sink)
+	}
+
+	if level > logrus.WarnLevel {
+		if q.Logger.GetLevel() >= level {
+			q.state.hold(func() { print(q.Logger) })
+		}
+		return
+	} else if level <= logrus.ErrorLevel {
+		q.flush()
+	}
+
+	print(q.Logger)
+}
+
+// hold adds the message to the ring buffer and overwrites the oldest message if it is full
+func (s *quietState) hold(message func()) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if len(s.messages) < s.size {
+		s.messages = append(s.messages, message)
+		return
+	}
+
+	s.messages[s.next] = message
+	s.next = (s.next + 1) % s.size
+	s.discarded++
+}
+
+// flush prints the held back messages in the order they were logged
+func (q *quietLogger) flush() {
+	s := q.state
+	s.m.Lock()
+	messages := append(append([]func(){}, s.messages[s.next:]...), s.messages[:s.next]...)
+	discarded := s.discarded
+	s.messages = nil
+	s.next = 0
+	s.discarded = 0
+	s.m.Unlock()
+
+	if len(messages) == 0 {
+		return
+	}
+
+	if discarded > 0 {
+		q.Logger.Infof("(%d older messages were discarded)", discarded)
+	}
+	for _, message := range messages {
+		message()
+	}
+}
+
+// currentSinks returns the sinks of the logger. AddSink never changes the returned slice,
+// so it can be used without holding the lock.
+func (q *quietLogger) currentSinks() []Logger {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	return q.sinks
+}
+
+func (q *quietLogger) wrap(base Logger, sinks []Logger) Logger {
+	return &quietLogger{
+		Logger: base,
+		sinks:  sinks,
+		state:  q.state,
+	}
+}
+
+func (q *quietLogger) Debug(args ...interface{}) {
+	q.log(logrus.DebugLevel, func(l Logger) { l.Debug(args...) })
+}
+
+func (q *quietLogger) Debugf(format string, args ...interface{}) {
+	q.log(logrus.DebugLevel, func(l Logger) { l.Debugf(format, args...) })
+}
+
+func (q *quietLogger) Info(args ...interface{}) {
+	q.log(logrus.InfoLevel, func(l Logger) { l.Info(args...) })
+}
+
+func (q *quietLogger) Infof(format string, args ...interface{}) {
+	q.log(logrus.InfoLevel, func(l Logger) { l.Infof(format, args...) })
+}
+
+func (q *quietLogger) Warn(args ...interface{}) {
+	q.log(logrus.WarnLevel, func(l Logger) { l.Warn(args...) })
+}
+
+func (q *quietLogger) Warnf(format string, args ...interface{}) {
+	q.log(logrus.WarnLevel, func(l Logger) { l.Warnf(format, args...) })
+}
+
+func (q *quietLogger) Error(args ...interface{}) {
+	q.log(logrus.ErrorLevel, func(l Logger) { l.Error(args...) })
+}
+
+func (q *quietLogger) Errorf(format string, args ...interface{}) {
+	q.log(logrus.ErrorLevel, func(l Logger) { l.Errorf(format, args...) })
+}
+
+func (q *quietLogger) Fatal(args ...interface{}) {
+	q.log(logrus.FatalLevel, func(l Logger) { l.Fatal(args...) })
+}
+
+func (q *quietLogger) Fatalf(format string, args ...interface{}) {
+	q.log(logrus.FatalLevel, func(l Logger) { l.Fatalf(format, args...) })
+}
+
+func (q *quietLogger) Done(args ...interface{}) {
+	q.log(logrus.InfoLevel, func(l Logger) { l.Done(args...) })
+}
+
+func (q *quietLogger) Donef(format string, args ...interface{}) {
+	q.log(logrus.InfoLevel, func(l Logger) { l.Donef(format, args...) })
+}
+
+func (q *quietLogger) Print(level logrus.Level, args ...interface{}) {
+	q.log(level, func(l Logger) { l.Print(level, args...) })
+}
+
+func (q *quietLogger) Printf(level logrus.Level, format string, args ...interface{}) {
+	q.log(level, func(l Logger) { l.Printf(level, format, args...) })
+}
+
+func (q *quietLogger) WriteString(level logrus.Level, message string) {
+	q.log(level, func(l Logger) { l.WriteString(level, message) })
+}
+
+func (q *quietLogger) Writer(level logrus.Level, raw bool) io.WriteCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		sa := scanner.NewScanner(reader)
+		for sa.Scan() {
+			if raw {
+				q.WriteString(level, sa.Text()+"\n")
+			} else {
+				q.Print(level, sa.Text())
+			}
+		}
+	}()
+
+	return writer
+}
+
+func (q *quietLogger) WithLevel(level logrus.Level) Logger {
+	return q.wrap(q.Logger.WithLevel(level), q.currentSinks())
+}
+
+func (q *quietLogger) ErrorStreamOnly() Logger {
+	return q.wrap(q.Logger.ErrorStreamOnly(), q.currentSinks())
+}
+
+func (q *quietLogger) WithPrefix(prefix string) Logger {
+	sinks := []Logger{}
+	for _, sink := range q.currentSinks() {
+		sinks = append(sinks, sink.WithPrefix(prefix))
+	}
+
+	return q.wrap(q.Logger.WithPrefix(prefix), sinks)
+}
+
+func (q *quietLogger) WithNestedPrefix(prefix string) Logger {
+	sinks := []Logger{}
+	for _, sink := range q.currentSinks() {
+		sinks = append(sinks, sink.WithNestedPrefix(prefix))
+	}
+
+	return q.wrap(q.Logger.WithNestedPrefix(prefix), sinks)
+}
+
+func (q *quietLogger) WithPrefixColor(prefix, color string) Logger {
+	sinks := []Logger{}
+	for _, sink := range q.currentSinks() {
+		sinks = append(sinks, sink.WithPrefixColor(prefix, color))
+	}
+
+	return q.wrap(q.Logger.WithPrefixColor(prefix, color), sinks)
+}
+
+func (q *quietLogger) WithPrefixLabel(label *PrefixLabel, color string) Logger {
+	sinks := []Logger{}
+	for _, sink := range q.currentSinks() {
+		sinks = append(sinks, sink.WithPrefixLabel(label, color))
+	}
+
+	return q.wrap(q.Logger.WithPrefixLabel(label, color), sinks)
+}
+
+// WithSink adds a sink that receives all messages, including the held back ones
+func (q *quietLogger) WithSink(sink Logger) Logger {
+	return q.wrap(q.Logger, append(append([]Logger{}, q.currentSinks()...), sink))
+}
+
+func (q *quietLogger) AddSink(sink Logger) {
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	q.sinks = append(append([]Logger{}, q.sinks...), sink)
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/acarl005/stripansi"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestQuietLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	sink := &bytes.Buffer{}
+	logger := NewQuietLogger(NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, RawFormat), 2).WithSink(NewStreamLoggerWithFormat(sink, sink, logrus.InfoLevel, RawFormat))
+
+	logger.Info("first")
+	logger.Info("second")
+	logger.WithPrefix("sub ").Info("third")
+	logger.Debug("debug")
+	assert.Equal(t, out.String(), "")
+
+	logger.Warn("warning")
+	assert.Equal(t, out.String(), "warning\n")
+
+	logger.Error("error")
+	assert.Equal(t, stripansi.Strip(out.String()), "warning\n(1 older messages were discarded)\nsecond\nsub third\nerror\n")
+	assert.Equal(t, stripansi.Strip(sink.String()), "first\nsecond\nsub third\nwarning\nerror\n")
+
+	// held back messages are only printed once
+	out.Reset()
+	logger.Info("fourth")
+	logger.Error("error")
+	assert.Equal(t, out.String(), "fourth\nerror\n")
+}
+
+func TestQuietLoggerAddSinkWhileLogging(t *testing.T) {
+	logger := NewQuietLogger(Discard, 10)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.Info("message")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		logger.AddSink(Discard)
+	}
+	<-done
+}
@@ -0,0 +1,214 @@
+package log
+
+import (
+	"io"
+	"sync"
+
+	"github.com/loft-sh/devspace/pkg/util/scanner"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultReplayBufferSize is the default number of messages a replay logger keeps
+const DefaultReplayBufferSize = 500
+
+// NewReplayLogger returns a logger that keeps the last size messages and replays them to
+// sinks that are added later with AddSink, before the sink receives any new message.
+func NewReplayLogger(base Logger, size int) Logger {
+	if size <= 0 {
+		size = DefaultReplayBufferSize
+	}
+
+	return &replayLogger{
+		Logger: base,
+		state: &replayState{
+			size: size,
+		},
+	}
+}
+
+type replayLogger struct {
+	Logger
+
+	// prefixes are the prefixes of derived loggers that are applied to the sinks
+	prefixes []Prefix
+	state    *replayState
+}
+
+// replayState is shared by all loggers derived from the same replay logger, so that
+// a new sink receives the messages of all of them
+type replayState struct {
+	m    sync.Mutex
+	size int
+
+	// messages is a ring buffer, next is the index the next message is written to
+	messages []func(sink Logger)
+	next     int
+	sinks    []Logger
+}
+
+func (r *replayLogger) log(print func(l Logger)) {
+	print(r.Logger)
+
+	// labels are resolved now, so that replayed messages keep the prefix they were logged with
+	prefixes := r.prefixes
+	texts := make([]string, len(prefixes))
+	for index, prefix := range prefixes {
+		texts[index] = prefix.text()
+	}
+	message := func(sink Logger) {
+		for index, prefix := range prefixes {
+			if prefix.Color != "" {
+				sink = sink.WithPrefixColor(texts[index], prefix.Color)
+			} else {
+				sink = sink.WithPrefix(texts[index])
+			}
+		}
+
+		print(sink)
+	}
+
+	// the lock is held while writing to the sinks, so that a sink that is added
+	// concurrently either receives the message through the replay or directly
+	s := r.state
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if len(s.messages) < s.size {
+		s.messages = append(s.messages, message)
+	} else {
+		s.messages[s.next] = message
+		s.next = (s.next + 1) % s.size
+	}
+	for _, sink := range s.sinks {
+		message(sink)
+	}
+}
+
+func (r *replayLogger) wrap(base Logger, prefixes []Prefix) Logger {
+	return &replayLogger{
+		Logger:   base,
+		prefixes: prefixes,
+		state:    r.state,
+	}
+}
+
+func (r *replayLogger) withPrefix(prefix Prefix) []Prefix {
+	return append(append([]Prefix{}, r.prefixes...), prefix)
+}
+
+func (r *replayLogger) Debug(args ...interface{}) {
+	r.log(func(l Logger) { l.Debug(args...) })
+}
+
+func (r *replayLogger) Debugf(format string, args ...interface{}) {
+	r.log(func(l Logger) { l.Debugf(format, args...) })
+}
+
+func (r *replayLogger) Info(args ...interface{}) {
+	r.log(func(l Logger) { l.Info(args...) })
+}
+
+func (r *replayLogger) Infof(format string, args ...interface{}) {
+	r.log(func(l Logger) { l.Infof(format, args...) })
+}
+
+func (r *replayLogger) Warn(args ...interface{}) {
+	r.log(func(l Logger) { l.Warn(args...) })
+}
+
+func (r *replayLogger) Warnf(format string, args ...interface{}) {
+	r.log(func(l Logger) { l.Warnf(format, args...) })
+}
+
+func (r *replayLogger) Error(args ...interface{}) {
+	r.log(func(l Logger) { l.Error(args...) })
+}
+
+func (r *replayLogger) Errorf(format string, args ...interface{}) {
+	r.log(func(l Logger) { l.Errorf(format, args...) })
+}
+
+func (r *replayLogger) Fatal(args ...interface{}) {
+	r.log(func(l Logger) { l.Fatal(args...) })
+}
+
+func (r *replayLogger) Fatalf(format string, args ...interface{}) {
+	r.log(func(l Logger) { l.Fatalf(format, args...) })
+}
+
+func (r *replayLogger) Done(args ...interface{}) {
+	r.log(func(l Logger) { l.Done(args...) })
+}
+
+func (r *replayLogger) Donef(format string, args ...interface{}) {
+	r.log(func(l Logger) { l.Donef(format, args...) })
+}
+
+func (r *replayLogger) Print(level logrus.Level, args ...interface{}) {
+	r.log(func(l Logger) { l.Print(level, args...) })
+}
+
+func (r *replayLogger) Printf(level logrus.Level, format string, args ...interface{}) {
+	r.log(func(l Logger) { l.Printf(level, format, args...) })
+}
+
+func (r *replayLogger) WriteString(level logrus.Level, message string) {
+	r.log(func(l Logger) { l.WriteString(level, message) })
+}
+
+func (r *replayLogger) Writer(level logrus.Level, raw bool) io.WriteCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		sa := scanner.NewScanner(reader)
+		for sa.Scan() {
+			if raw {
+				r.WriteString(level, sa.Text()+"\n")
+			} else {
+				r.Print(level, sa.Text())
+			}
+		}
+	}()
+
+	return writer
+}
+
+func (r *replayLogger) WithLevel(level logrus.Level) Logger {
+	return r.wrap(r.Logger.WithLevel(level), r.prefixes)
+}
+
+func (r *replayLogger) ErrorStreamOnly() Logger {
+	return r.wrap(r.Logger.ErrorStreamOnly(), r.prefixes)
+}
+
+func (r *replayLogger) WithPrefix(prefix string) Logger {
+	return r.wrap(r.Logger.WithPrefix(prefix), r.withPrefix(Prefix{Prefix: prefix}))
+}
+
+func (r *replayLogger) WithNestedPrefix(prefix string) Logger {
+	return r.wrap(r.Logger.WithNestedPrefix(prefix), r.withPrefix(nested(r.prefixes, prefix)))
+}
+
+func (r *replayLogger) WithPrefixColor(prefix, color string) Logger {
+	return r.wrap(r.Logger.WithPrefixColor(prefix, color), r.withPrefix(Prefix{Prefix: prefix, Color: color}))
+}
+
+func (r *replayLogger) WithPrefixLabel(label *PrefixLabel, color string) Logger {
+	return r.wrap(r.Logger.WithPrefixLabel(label, color), r.withPrefix(Prefix{Label: label, Color: color}))
+}
+
+func (r *replayLogger) WithSink(sink Logger) Logger {
+	return r.wrap(r.Logger.WithSink(sink), r.prefixes)
+}
+
+// AddSink replays the buffered messages to the sink in the order they were logged and
+// then adds it, so that it receives all following messages as well
+func (r *replayLogger) AddSink(sink Logger) {
+	s := r.state
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	for _, message := range append(append([]func(Logger){}, s.messages[s.next:]...), s.messages[:s.next]...) {
+		message(sink)
+	}
+	s.sinks = append(s.sinks, sink)
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/acarl005/stripansi"
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+func TestReplayLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewReplayLogger(NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, RawFormat), 2)
+
+	logger.Info("first")
+	logger.Info("second")
+	logger.WithPrefix("sub ").Info("third")
+	assert.Equal(t, stripansi.Strip(out.String()), "first\nsecond\nsub third\n")
+
+	// the last messages are replayed to a new sink before the new ones
+	sink := &bytes.Buffer{}
+	logger.AddSink(NewStreamLoggerWithFormat(sink, sink, logrus.InfoLevel, RawFormat))
+	assert.Equal(t, stripansi.Strip(sink.String()), "second\nsub third\n")
+
+	logger.Info("fourth")
+	assert.Equal(t, stripansi.Strip(sink.String()), "second\nsub third\nfourth\n")
+}
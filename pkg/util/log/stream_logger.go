@@ -9,9 +9,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/loft-sh/devspace/pkg/devspace/env"
-	"github.com/loft-sh/devspace/pkg/util/hash"
 	"github.com/loft-sh/devspace/pkg/util/scanner"
 
 	"github.com/acarl005/stripansi"
@@ -46,6 +46,21 @@ var Colors = []string{
 
 const DevSpaceLogTimestamps = "DEVSPACE_LOG_TIMESTAMPS"
 
+// DevSpaceLogDoneMarker overrides the marker, e.g. "✓" or "Done:", that is printed in front of
+// success messages logged with Done and Donef
+const DevSpaceLogDoneMarker = "DEVSPACE_LOG_DONE_MARKER"
+
+// DoneMarker is the default marker in front of success messages. It is empty by default, so that
+// success messages look like info messages to existing log parsers.
+var DoneMarker = ""
+
+// DevSpaceLogMaxMessageLength overrides the maximum length of a single log message. 0 disables truncation.
+const DevSpaceLogMaxMessageLength = "DEVSPACE_LOG_MAX_MESSAGE_LENGTH"
+
+// MaxMessageLength is the default maximum length of a single log message, longer
+// messages are truncated. Setting this to 0 disables truncation.
+var MaxMessageLength = 1024 * 1024
+
 var stdout = goansi.NewAnsiStdout()
 var stderr = goansi.NewAnsiStderr()
 
@@ -112,6 +127,18 @@ type StreamLogger struct {
 type Prefix struct {
 	Prefix string
 	Color  string
+
+	// Label replaces Prefix with its current text if set
+	Label *PrefixLabel
+}
+
+// text returns the text the prefix is currently printed with
+func (p Prefix) text() string {
+	if p.Label != nil {
+		return p.Label.String()
+	}
+
+	return p.Prefix
 }
 
 type Line struct {
@@ -193,22 +220,29 @@ func (s *StreamLogger) WithPrefix(prefix string) Logger {
 	s.m.Lock()
 	defer s.m.Unlock()
 
-	hashNumber := int(hash.StringToNumber(prefix))
-	if hashNumber < 0 {
-		hashNumber = hashNumber * -1
-	}
-
 	n := *s
 	n.m = &sync.Mutex{}
 	n.prefixes = []Prefix{}
 	n.prefixes = append(n.prefixes, s.prefixes...)
 	n.prefixes = append(n.prefixes, Prefix{
 		Prefix: prefix,
-		Color:  Colors[hashNumber%len(Colors)],
+		Color:  colorForKey(prefix),
 	})
 	return &n
 }
 
+func (s *StreamLogger) WithNestedPrefix(prefix string) Logger {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	n := *s
+	n.m = &sync.Mutex{}
+	n.prefixes = []Prefix{}
+	n.prefixes = append(n.prefixes, s.prefixes...)
+	n.prefixes = append(n.prefixes, nested(s.prefixes, prefix))
+	return &n
+}
+
 func (s *StreamLogger) WithPrefixColor(prefix, color string) Logger {
 	s.m.Lock()
 	defer s.m.Unlock()
@@ -224,6 +258,21 @@ func (s *StreamLogger) WithPrefixColor(prefix, color string) Logger {
 	return &n
 }
 
+func (s *StreamLogger) WithPrefixLabel(label *PrefixLabel, color string) Logger {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	n := *s
+	n.m = &sync.Mutex{}
+	n.prefixes = []Prefix{}
+	n.prefixes = append(n.prefixes, s.prefixes...)
+	n.prefixes = append(n.prefixes, Prefix{
+		Label: label,
+		Color: color,
+	})
+	return &n
+}
+
 func (s *StreamLogger) AddSink(log Logger) {
 	s.m.Lock()
 	defer s.m.Unlock()
@@ -263,20 +312,55 @@ func (s *StreamLogger) getStream(level logrus.Level) io.Writer {
 
 func (s *StreamLogger) writePrefixes(message string) string {
 	prefix := ""
-	for _, prefixDef := range s.prefixes {
+	for index, prefixDef := range s.prefixes {
 		if prefixDef.Color != "" {
-			prefix += ansi.Color(prefixDef.Prefix, prefixDef.Color)
+			// only the outermost prefix, e.g. the one of the dev pod, gets a symbol
+			if index == 0 {
+				prefix += prefixSymbol(prefixDef.Color)
+			}
+			prefix += ansi.Color(prefixDef.text(), prefixDef.Color)
 		} else {
-			prefix += prefixDef.Prefix
+			prefix += prefixDef.text()
 		}
 	}
 
 	return prefix + message
 }
 
+func getMaxMessageLength() int {
+	if value := env.GlobalGetEnv(DevSpaceLogMaxMessageLength); value != "" {
+		maxLength, err := strconv.Atoi(value)
+		if err == nil {
+			return maxLength
+		}
+	}
+
+	return MaxMessageLength
+}
+
+// truncateMessage cuts messages that are longer than the maximum message length
+func truncateMessage(message string, maxLength int) string {
+	if maxLength <= 0 || len(message) <= maxLength {
+		return message
+	}
+
+	// make sure we don't cut in the middle of a character
+	cut := maxLength
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+
+	suffix := ""
+	if strings.HasSuffix(message, "\n") {
+		suffix = "\n"
+	}
+
+	return message[:cut] + fmt.Sprintf("...(truncated %d bytes)", len(message)-cut) + suffix
+}
+
 func (s *StreamLogger) writeMessage(fnType logFunctionType, message string) {
 	fnInformation := fnTypeInformationMap[fnType]
-	message = s.writePrefixes(message)
+	message = s.writePrefixes(truncateMessage(message, getMaxMessageLength()))
 	for _, s := range s.sinks {
 		if fnInformation.logLevel == logrus.PanicLevel || fnInformation.logLevel == logrus.FatalLevel {
 			s.Print(logrus.ErrorLevel, message)
@@ -308,6 +392,19 @@ func (s *StreamLogger) writeMessage(fnType logFunctionType, message string) {
 	}
 }
 
+// withDoneMarker returns the message preceded by the done marker, if one is set
+func withDoneMarker(message string) string {
+	marker := DoneMarker
+	if value := env.GlobalGetEnv(DevSpaceLogDoneMarker); value != "" {
+		marker = value
+	}
+	if marker == "" {
+		return message
+	}
+
+	return marker + " " + message
+}
+
 func (s *StreamLogger) writeJSON(message string, level logrus.Level) {
 	stream := s.getStream(level)
 	line, err := json.Marshal(&Line{
@@ -404,15 +501,14 @@ func (s *StreamLogger) Done(args ...interface{}) {
 	s.m.Lock()
 	defer s.m.Unlock()
 
-	s.writeMessage(doneFn, fmt.Sprintln(args...))
-
+	s.writeMessage(doneFn, withDoneMarker(fmt.Sprintln(args...)))
 }
 
 func (s *StreamLogger) Donef(format string, args ...interface{}) {
 	s.m.Lock()
 	defer s.m.Unlock()
 
-	s.writeMessage(doneFn, fmt.Sprintf(format, args...)+"\n")
+	s.writeMessage(doneFn, withDoneMarker(fmt.Sprintf(format, args...)+"\n"))
 }
 
 func (s *StreamLogger) Print(level logrus.Level, args ...interface{}) {
@@ -486,6 +582,7 @@ func (s *StreamLogger) WriteString(level logrus.Level, message string) {
 	s.m.Lock()
 	defer s.m.Unlock()
 
+	message = truncateMessage(message, getMaxMessageLength())
 	for _, s := range s.sinks {
 		s.WriteString(level, message)
 	}
@@ -0,0 +1,178 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"gotest.tools/assert"
+)
+
+type truncateTestCase struct {
+	name      string
+	message   string
+	maxLength int
+	expected  string
+}
+
+func TestTruncateMessage(t *testing.T) {
+	testCases := []truncateTestCase{
+		{
+			name:      "Short message",
+			message:   "hello world\n",
+			maxLength: 20,
+			expected:  "hello world\n",
+		},
+		{
+			name:      "Disabled truncation",
+			message:   strings.Repeat("a", 100),
+			maxLength: 0,
+			expected:  strings.Repeat("a", 100),
+		},
+		{
+			name:      "Long message",
+			message:   strings.Repeat("a", 100) + "\n",
+			maxLength: 10,
+			expected:  strings.Repeat("a", 10) + "...(truncated 91 bytes)\n",
+		},
+		{
+			name:      "Multibyte character at cut",
+			message:   "aaäbb",
+			maxLength: 3,
+			expected:  "aa...(truncated 4 bytes)",
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, truncateMessage(testCase.message, testCase.maxLength), testCase.expected, testCase.name)
+	}
+}
+
+func TestNestedPrefix(t *testing.T) {
+	logger := NewStreamLogger(nil, nil, 0).WithPrefixColor("dev:app ", "blue+b")
+	first := logger.WithNestedPrefix("[pf] ").WithNestedPrefix("[probe] ").(*StreamLogger)
+	second := logger.WithNestedPrefix("sync").WithNestedPrefix("[upload] ").(*StreamLogger)
+
+	assert.DeepEqual(t, first.prefixes, []Prefix{
+		{Prefix: "dev:app ", Color: "blue+b"},
+		{Prefix: "[pf] ", Color: "blue+b"},
+		{Prefix: "[probe] ", Color: "blue+b"},
+	})
+	assert.DeepEqual(t, second.prefixes, []Prefix{
+		{Prefix: "dev:app ", Color: "blue+b"},
+		{Prefix: "sync", Color: "blue+b"},
+		{Prefix: " [upload] ", Color: "blue+b"},
+	})
+}
+
+func TestWithPrefixNotNested(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, TextFormat).WithPrefixColor("dependency ", "blue+b")
+	build := logger.WithPrefix("build:image").(*StreamLogger)
+
+	// each prefix keeps its own color and is printed as is
+	assert.DeepEqual(t, build.prefixes, []Prefix{
+		{Prefix: "dependency ", Color: "blue+b"},
+		{Prefix: "build:image", Color: ColorForPrefix("build:image")},
+	})
+
+	DisableColors(true)
+	defer DisableColors(false)
+	t.Setenv(DevSpaceLogPrefixSymbols, "none")
+	build.Info("done")
+	assert.Assert(t, strings.Contains(out.String(), "dependency build:imagedone"), out.String())
+}
+
+func TestColorForPrefix(t *testing.T) {
+	logger := NewDefaultPrefixLoggerWithKey("dev:app ", "app", NewStreamLogger(nil, nil, 0)).(*StreamLogger)
+	assert.Equal(t, logger.prefixes[0].Color, ColorForPrefix("app"))
+
+	logger = NewStreamLogger(nil, nil, 0).WithPrefix("deploy ").(*StreamLogger)
+	assert.Equal(t, logger.prefixes[0].Color, ColorForPrefix("deploy "))
+}
+
+func TestColorOrder(t *testing.T) {
+	t.Setenv(DevSpaceLogColors, "ordered")
+	defer func() { colorOrder.keys = map[string]int{} }()
+
+	SetColorOrder([]string{"backend", "frontend"})
+	assert.Equal(t, ColorForPrefix("frontend"), Colors[1])
+	assert.Equal(t, ColorForPrefix("backend"), Colors[0])
+
+	// unknown keys get the next free color and registered keys keep theirs
+	assert.Equal(t, ColorForPrefix("deploy "), Colors[2])
+	SetColorOrder([]string{"api", "frontend"})
+	assert.Equal(t, ColorForPrefix("api"), Colors[3])
+	assert.Equal(t, ColorForPrefix("frontend"), Colors[1])
+}
+
+func TestPrefixLabel(t *testing.T) {
+	defer DisableColors(false)
+	DisableColors(true)
+
+	out := &bytes.Buffer{}
+	label := NewPrefixLabel("dev:app ")
+	logger := NewReplayLogger(NewPrefixLabelLoggerWithKey(label, "app", NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, RawFormat)), 10)
+	symbol := prefixSymbol(ColorForPrefix("app"))
+
+	logger.Info("first")
+	label.Set("dev:app(pod-1) ")
+	logger.WithPrefix("[pf] ").Info("second")
+	assert.Equal(t, out.String(), symbol+"dev:app first\n"+symbol+"dev:app(pod-1) [pf] second\n")
+
+	// a sink with the same label is printed with the current text as well
+	sink := &bytes.Buffer{}
+	logger.AddSink(NewStreamLoggerWithFormat(sink, sink, logrus.InfoLevel, RawFormat).WithPrefixLabel(label, ColorForPrefix("app")))
+	label.Set("dev:app(pod-2) ")
+	logger.Info("third")
+	assert.Equal(t, sink.String(), symbol+"dev:app(pod-1) first\n"+symbol+"dev:app(pod-1) [pf] second\n"+symbol+"dev:app(pod-2) third\n")
+}
+
+func TestSinkLevels(t *testing.T) {
+	console := &bytes.Buffer{}
+	file := &bytes.Buffer{}
+	logger := NewStreamLogger(console, console, logrus.WarnLevel).WithSink(NewStreamLogger(file, file, logrus.DebugLevel))
+
+	logger.Debugf("debug message")
+	logger.Warnf("warn message")
+	assert.Assert(t, !strings.Contains(console.String(), "debug message"))
+	assert.Assert(t, strings.Contains(console.String(), "warn message"))
+	assert.Assert(t, strings.Contains(file.String(), "debug message"))
+	assert.Assert(t, strings.Contains(file.String(), "warn message"))
+}
+
+func TestPrefixSymbols(t *testing.T) {
+	defer DisableColors(false)
+
+	out := &bytes.Buffer{}
+	logger := NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, RawFormat)
+	DisableColors(true)
+	NewDefaultPrefixLoggerWithKey("dev:a ", "a", logger).Info("message")
+	NewDefaultPrefixLoggerWithKey("dev:a ", "a", logger).WithPrefix("[pf] ").Info("message")
+	logger.WithPrefix("deploy ").Info("message")
+
+	symbol := prefixSymbol(ColorForPrefix("a"))
+	assert.Assert(t, symbol != "")
+	assert.Equal(t, out.String(), symbol+"dev:a message\n"+symbol+"dev:a [pf] message\n"+prefixSymbol(ColorForPrefix("deploy "))+"deploy message\n")
+
+	// no symbols are printed while colors are enabled
+	DisableColors(false)
+	assert.Equal(t, prefixSymbol(ColorForPrefix("a")), "")
+}
+
+func TestDoneMarker(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewStreamLoggerWithFormat(out, out, logrus.InfoLevel, RawFormat)
+
+	// success messages are not marked by default
+	logger.Donef("started")
+	assert.Equal(t, out.String(), "started\n")
+
+	out.Reset()
+	t.Setenv(DevSpaceLogDoneMarker, "Done:")
+	logger.Donef("started")
+	logger.Done("synced")
+	logger.Info("info")
+	assert.Equal(t, out.String(), "Done: started\nDone: synced\ninfo\n")
+}
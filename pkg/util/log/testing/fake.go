@@ -136,10 +136,18 @@ func (d *FakeLogger) WithPrefix(prefix string) log.Logger {
 	return d
 }
 
+func (d *FakeLogger) WithNestedPrefix(prefix string) log.Logger {
+	return d
+}
+
 func (d *FakeLogger) WithPrefixColor(prefix, color string) log.Logger {
 	return d
 }
 
+func (d *FakeLogger) WithPrefixLabel(label *log.PrefixLabel, color string) log.Logger {
+	return d
+}
+
 func (d *FakeLogger) ErrorStreamOnly() log.Logger {
 	return d
 }
@@ -64,6 +64,7 @@ package tomb
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -78,6 +79,9 @@ type Tomb struct {
 	dead   chan struct{}
 	reason error
 
+	// running counts the outstanding goroutines by name
+	running map[string]int
+
 	// context.Context is available in Go 1.7+.
 	parent interface{}
 	child  map[interface{}]childContext
@@ -94,6 +98,18 @@ var (
 	ErrDying      = errors.New("tomb: dying")
 )
 
+// State is the lifecycle state of a tomb
+type State string
+
+const (
+	StateAlive State = "alive"
+	StateDying State = "dying"
+	StateDead  State = "dead"
+)
+
+// unnamed is reported for goroutines that were started without a name
+const unnamed = "unnamed"
+
 func (t *Tomb) init() {
 	t.m.Lock()
 	if t.dead == nil {
@@ -156,6 +172,12 @@ func (t *Tomb) Wait() error {
 // causes a runtime panic. For that reason, calling the Go
 // method a second time out of a tracked goroutine is unsafe.
 func (t *Tomb) Go(f func() error) {
+	t.GoNamed("", f)
+}
+
+// GoNamed is the same as Go, but the goroutine is reported with
+// the given name by Goroutines until it returns
+func (t *Tomb) GoNamed(name string, f func() error) {
 	t.init()
 	t.m.Lock()
 	defer t.m.Unlock()
@@ -164,13 +186,18 @@ func (t *Tomb) Go(f func() error) {
 		panic("tomb.Go called after all goroutines terminated")
 	default:
 	}
-	t.alive++
-	go t.run(f, nil)
+	t.track(name)
+	go t.run(name, f, nil)
 }
 
 // NotifyGo is the same as Go but returns a channel that is
 // closed after the function has been run
 func (t *Tomb) NotifyGo(f func() error) chan struct{} {
+	return t.NotifyGoNamed("", f)
+}
+
+// NotifyGoNamed is the same as NotifyGo with a name like GoNamed
+func (t *Tomb) NotifyGoNamed(name string, f func() error) chan struct{} {
 	t.init()
 	t.m.Lock()
 	defer t.m.Unlock()
@@ -179,17 +206,59 @@ func (t *Tomb) NotifyGo(f func() error) chan struct{} {
 		panic("tomb.Go called after all goroutines terminated")
 	default:
 	}
-	t.alive++
+	t.track(name)
 	done := make(chan struct{})
-	go t.run(f, done)
+	go t.run(name, f, done)
 	return done
 }
 
-func (t *Tomb) run(f func() error, done chan struct{}) {
+// TryGo is the same as Go, but if the tomb is already dying or dead
+// f is not run and false is returned instead
+func (t *Tomb) TryGo(f func() error) bool {
+	return t.TryGoNamed("", f)
+}
+
+// TryGoNamed is the same as TryGo with a name like GoNamed
+func (t *Tomb) TryGoNamed(name string, f func() error) bool {
+	t.init()
+	t.m.Lock()
+	defer t.m.Unlock()
+	select {
+	case <-t.dying:
+		return false
+	case <-t.dead:
+		return false
+	default:
+	}
+	t.track(name)
+	go t.run(name, f, nil)
+	return true
+}
+
+// track counts a new goroutine, t.m has to be locked
+func (t *Tomb) track(name string) {
+	if name == "" {
+		name = unnamed
+	}
+	if t.running == nil {
+		t.running = map[string]int{}
+	}
+	t.alive++
+	t.running[name]++
+}
+
+func (t *Tomb) run(name string, f func() error, done chan struct{}) {
 	err := f()
 	t.m.Lock()
 	defer t.m.Unlock()
 	t.alive--
+	if name == "" {
+		name = unnamed
+	}
+	t.running[name]--
+	if t.running[name] == 0 {
+		delete(t.running, name)
+	}
 	if t.alive == 0 || err != nil {
 		t.kill(err)
 		if t.alive == 0 {
@@ -264,3 +333,30 @@ func (t *Tomb) Err() (reason error) {
 func (t *Tomb) Alive() bool {
 	return t.Err() == ErrStillAlive
 }
+
+// State returns whether the tomb is alive, dying or dead.
+func (t *Tomb) State() State {
+	if t.Terminated() {
+		return StateDead
+	} else if !t.Alive() {
+		return StateDying
+	}
+	return StateAlive
+}
+
+// Goroutines returns the sorted names of the tracked goroutines that
+// didn't return yet. A name is repeated for every goroutine that is
+// running with it and goroutines without a name are reported as unnamed.
+func (t *Tomb) Goroutines() []string {
+	t.init()
+	t.m.Lock()
+	defer t.m.Unlock()
+	names := []string{}
+	for name, count := range t.running {
+		for i := 0; i < count; i++ {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}